@@ -0,0 +1,314 @@
+// api-gateway/admin.go
+// Admin API: 별도 포트(ADMIN_PORT)에서 ADMIN_TOKEN Bearer 인증으로 런타임 조회/제어
+
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+var adminToken = getEnv("ADMIN_TOKEN", "")
+
+// requireAdminToken rejects requests without "Authorization: Bearer <ADMIN_TOKEN>".
+// Admin endpoints are disabled entirely when ADMIN_TOKEN is not set.
+func requireAdminToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if adminToken == "" {
+			notFound(w, r)
+			return
+		}
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+			writeError(w, r, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// === Audit Log ===
+type auditEntry struct {
+	Time   time.Time `json:"time"`
+	Actor  string    `json:"actor"`
+	Remote string    `json:"remote"`
+	Action string    `json:"action"`
+	Target string    `json:"target,omitempty"`
+	Status int       `json:"status"`
+}
+
+// auditLog keeps the most recent admin actions in memory and writes each one to the log.
+type auditLog struct {
+	mu      sync.Mutex
+	entries []auditEntry
+	max     int
+}
+
+func newAuditLog(max int) *auditLog {
+	return &auditLog{max: max}
+}
+
+func (a *auditLog) Record(e auditEntry) {
+	line, _ := json.Marshal(e)
+	log.Printf("[AUDIT] %s", line)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.entries = append(a.entries, e)
+	if len(a.entries) > a.max {
+		a.entries = a.entries[len(a.entries)-a.max:]
+	}
+}
+
+func (a *auditLog) Entries() []auditEntry {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]auditEntry(nil), a.entries...)
+}
+
+// === Admin Server ===
+type AdminServer struct {
+	config   *GatewayConfig
+	router   *Router
+	limiter  *RateLimiter
+	apiKeys  *APIKeyAuth
+	ipFilter *IPFilter
+	audit    *auditLog
+}
+
+func NewAdminServer(cfg *GatewayConfig, router *Router, limiter *RateLimiter, apiKeys *APIKeyAuth, ipFilter *IPFilter) *AdminServer {
+	return &AdminServer{
+		config:   cfg,
+		router:   router,
+		limiter:  limiter,
+		apiKeys:  apiKeys,
+		ipFilter: ipFilter,
+		audit:    newAuditLog(100),
+	}
+}
+
+// Handler returns the token-protected admin mux.
+func (s *AdminServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /admin/config", s.handleConfig)
+	mux.HandleFunc("GET /admin/routes", s.handleRoutes)
+	mux.HandleFunc("GET /admin/upstreams", s.handleUpstreams)
+	mux.HandleFunc("POST /admin/upstreams/{name}/drain", s.action("drain-upstream", s.handleDrain(true)))
+	mux.HandleFunc("POST /admin/upstreams/{name}/undrain", s.action("undrain-upstream", s.handleDrain(false)))
+	mux.HandleFunc("POST /admin/upstreams/{name}/reset-breaker", s.action("reset-breaker", s.handleResetBreaker))
+	mux.HandleFunc("GET /admin/ratelimits", s.handleRateLimits)
+	mux.HandleFunc("DELETE /admin/ratelimits/{client}", s.action("reset-ratelimit", s.handleResetRateLimit))
+	mux.HandleFunc("GET /admin/bans", s.handleBans)
+	mux.HandleFunc("DELETE /admin/bans/{ip}", s.action("unban", s.handleUnban))
+	mux.HandleFunc("GET /admin/maintenance", s.handleMaintenance)
+	mux.HandleFunc("PUT /admin/maintenance", s.action("set-maintenance", s.handleSetMaintenance))
+	mux.HandleFunc("POST /admin/reload", s.action("reload-config", s.handleReload))
+	mux.HandleFunc("GET /admin/quotas", s.handleQuotas)
+	mux.HandleFunc("GET /admin/audit", s.handleAudit)
+	return requireAdminToken(mux)
+}
+
+// action wraps a state-changing handler so that every call is audit-logged.
+// The optional X-Admin-User header names the operator.
+func (s *AdminServer) action(name string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rec := wrapResponseWriter(w)
+		h(rec, r)
+
+		actor := r.Header.Get("X-Admin-User")
+		if actor == "" {
+			actor = "admin-token"
+		}
+		target := r.PathValue("name")
+		if target == "" {
+			target = r.PathValue("client")
+		}
+		if target == "" {
+			target = r.PathValue("ip")
+		}
+		s.audit.Record(auditEntry{
+			Time:   time.Now().UTC(),
+			Actor:  actor,
+			Remote: r.RemoteAddr,
+			Action: name,
+			Target: target,
+			Status: rec.status,
+		})
+	}
+}
+
+func (s *AdminServer) handleConfig(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.config)
+}
+
+func (s *AdminServer) handleRoutes(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"routes": s.router.Routes()})
+}
+
+type versionStatus struct {
+	Name    string `json:"name"`
+	Target  string `json:"target"`
+	Weight  int    `json:"weight,omitempty"`
+	Canary  bool   `json:"canary,omitempty"`
+	Circuit string `json:"circuit"`
+}
+
+type upstreamStatus struct {
+	Name     string          `json:"name"`
+	Target   string          `json:"target"`
+	Draining bool            `json:"draining"`
+	Circuit  string          `json:"circuit"`
+	Health   upstreamHealth  `json:"health"`
+	Versions []versionStatus `json:"versions"`
+}
+
+func (s *AdminServer) handleUpstreams(w http.ResponseWriter, r *http.Request) {
+	statuses := make([]upstreamStatus, 0, len(s.router.upstreams))
+	for _, name := range sortedKeys(s.router.upstreams) {
+		u := s.router.upstreams[name]
+		var versions []versionStatus
+		for _, v := range u.versions() {
+			versions = append(versions, versionStatus{
+				Name:    v.Name,
+				Target:  v.Target.String(),
+				Weight:  v.Weight,
+				Canary:  v.Canary,
+				Circuit: v.breaker.State().String(),
+			})
+		}
+		statuses = append(statuses, upstreamStatus{
+			Name:     u.Name,
+			Target:   u.Target.String(),
+			Draining: u.draining.Load(),
+			Circuit:  u.breaker.State().String(),
+			Health:   u.Health(),
+			Versions: versions,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"upstreams": statuses})
+}
+
+func (s *AdminServer) handleDrain(draining bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		u := s.router.Upstream(r.PathValue("name"))
+		if u == nil {
+			writeError(w, r, http.StatusNotFound, "Unknown upstream")
+			return
+		}
+		u.SetDraining(draining)
+		writeJSON(w, http.StatusOK, map[string]interface{}{"upstream": u.Name, "draining": draining})
+	}
+}
+
+func (s *AdminServer) handleResetBreaker(w http.ResponseWriter, r *http.Request) {
+	u := s.router.Upstream(r.PathValue("name"))
+	if u == nil {
+		writeError(w, r, http.StatusNotFound, "Unknown upstream")
+		return
+	}
+	// Canary 등 Version별 Breaker도 함께 닫음
+	u.breaker.Reset()
+	for _, v := range u.versions() {
+		v.breaker.Reset()
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"upstream": u.Name, "circuit": u.breaker.State().String()})
+}
+
+func (s *AdminServer) handleRateLimits(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"rate":    float64(s.limiter.r),
+		"burst":   s.limiter.burst,
+		"clients": s.limiter.Snapshot(),
+	})
+}
+
+func (s *AdminServer) handleResetRateLimit(w http.ResponseWriter, r *http.Request) {
+	client := r.PathValue("client")
+	if !s.limiter.Reset(client) {
+		writeError(w, r, http.StatusNotFound, "Unknown client")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"client": client, "reset": true})
+}
+
+func (s *AdminServer) handleBans(w http.ResponseWriter, r *http.Request) {
+	bans, err := s.ipFilter.Bans(r.Context())
+	if err != nil {
+		writeError(w, r, http.StatusServiceUnavailable, "Ban store unavailable: "+err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"bans": bans})
+}
+
+func (s *AdminServer) handleUnban(w http.ResponseWriter, r *http.Request) {
+	ip := r.PathValue("ip")
+	removed, err := s.ipFilter.Unban(r.Context(), ip)
+	if err != nil {
+		writeError(w, r, http.StatusServiceUnavailable, "Ban store unavailable: "+err.Error())
+		return
+	}
+	if !removed {
+		writeError(w, r, http.StatusNotFound, "IP is not banned")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"ip": ip, "banned": false})
+}
+
+func (s *AdminServer) handleMaintenance(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, Maintenance())
+}
+
+// handleSetMaintenance replaces the maintenance config; an empty object turns it off.
+func (s *AdminServer) handleSetMaintenance(w http.ResponseWriter, r *http.Request) {
+	var cfg MaintenanceConfig
+	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+		writeError(w, r, http.StatusBadRequest, "Invalid maintenance config: "+err.Error())
+		return
+	}
+	if err := SetMaintenance(s.router, cfg); err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, Maintenance())
+}
+
+// handleReload re-reads GATEWAY_CONFIG_FILE, same as sending SIGHUP.
+func (s *AdminServer) handleReload(w http.ResponseWriter, r *http.Request) {
+	if s.config.ConfigFile == "" {
+		writeError(w, r, http.StatusConflict, "GATEWAY_CONFIG_FILE is not set")
+		return
+	}
+	if err := reloadConfigFile(s.config.ConfigFile, s.router); err != nil {
+		writeError(w, r, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"reloaded": s.config.ConfigFile})
+}
+
+// handleQuotas lists API key quota usage for the current day/month.
+func (s *AdminServer) handleQuotas(w http.ResponseWriter, r *http.Request) {
+	usage, err := s.apiKeys.Usage(r.Context())
+	if err != nil {
+		writeError(w, r, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"mode": s.apiKeys.mode,
+		"keys": usage,
+	})
+}
+
+func (s *AdminServer) handleAudit(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"entries": s.audit.Entries()})
+}
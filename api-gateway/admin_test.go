@@ -0,0 +1,213 @@
+// api-gateway/admin_test.go
+// 단위 테스트: Admin API 조회/제어 및 Audit Log
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newTestAdmin builds an admin handler over echo backends with a known token
+func newTestAdmin(t *testing.T) (*AdminServer, http.Handler) {
+	t.Helper()
+	originalToken := adminToken
+	adminToken = "admin-secret"
+	t.Cleanup(func() { adminToken = originalToken })
+
+	admin := NewAdminServer(
+		&GatewayConfig{Port: "8000", AdminPort: "9000"},
+		newTestRouter(t),
+		NewRateLimiter(1, 1),
+		newTestAPIKeyAuth(apiKeyModeOff, newMemoryStore()),
+		NewIPFilter(newMemoryStore()),
+	)
+	return admin, admin.Handler()
+}
+
+func adminRequest(handler http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer admin-secret")
+	req.Header.Set("X-Admin-User", "alice")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+// TestAdminAuth tests token enforcement
+func TestAdminAuth(t *testing.T) {
+	_, handler := newTestAdmin(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/config", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Status = %d; want 401", rr.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/admin/config", nil)
+	req.Header.Set("Authorization", "admin-secret")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Status without Bearer prefix = %d; want 401", rr.Code)
+	}
+
+	adminToken = ""
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/config", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Status without ADMIN_TOKEN = %d; want 404", rr.Code)
+	}
+}
+
+// TestAdminInspection tests the read-only endpoints
+func TestAdminInspection(t *testing.T) {
+	admin, handler := newTestAdmin(t)
+
+	t.Run("config", func(t *testing.T) {
+		rr := adminRequest(handler, http.MethodGet, "/admin/config", "")
+		var cfg GatewayConfig
+		if err := json.NewDecoder(rr.Body).Decode(&cfg); err != nil || cfg.AdminPort != "9000" {
+			t.Errorf("config = %+v, %v; want admin_port 9000", cfg, err)
+		}
+	})
+
+	t.Run("routes", func(t *testing.T) {
+		rr := adminRequest(handler, http.MethodGet, "/admin/routes", "")
+		var body struct {
+			Routes []Route `json:"routes"`
+		}
+		json.NewDecoder(rr.Body).Decode(&body)
+		if len(body.Routes) != len(defaultRoutes()) || body.Routes[0].Name != "auth-login" {
+			t.Errorf("routes = %+v; want default table", body.Routes)
+		}
+	})
+
+	t.Run("upstreams", func(t *testing.T) {
+		rr := adminRequest(handler, http.MethodGet, "/admin/upstreams", "")
+		var body struct {
+			Upstreams []upstreamStatus `json:"upstreams"`
+		}
+		json.NewDecoder(rr.Body).Decode(&body)
+		if len(body.Upstreams) != 3 || body.Upstreams[0].Name != "auth-service" || body.Upstreams[0].Circuit != "closed" {
+			t.Errorf("upstreams = %+v; want 3 sorted, closed", body.Upstreams)
+		}
+	})
+
+	t.Run("ratelimits", func(t *testing.T) {
+		admin.limiter.GetLimiter("10.1.1.1").Allow()
+		rr := adminRequest(handler, http.MethodGet, "/admin/ratelimits", "")
+		var body struct {
+			Clients []visitorSnapshot `json:"clients"`
+		}
+		json.NewDecoder(rr.Body).Decode(&body)
+		if len(body.Clients) != 1 || body.Clients[0].Client != "10.1.1.1" {
+			t.Errorf("clients = %+v; want 10.1.1.1", body.Clients)
+		}
+	})
+}
+
+// TestAdminActions tests state-changing endpoints and their audit entries
+func TestAdminActions(t *testing.T) {
+	admin, handler := newTestAdmin(t)
+
+	t.Run("upstream drain/undrain", func(t *testing.T) {
+		rr := adminRequest(handler, http.MethodPost, "/admin/upstreams/blog-service/drain", "")
+		if rr.Code != http.StatusOK || !admin.router.Upstream("blog-service").draining.Load() {
+			t.Fatalf("Status = %d; want 200 and draining", rr.Code)
+		}
+
+		proxied := httptest.NewRecorder()
+		admin.router.ServeHTTP(proxied, httptest.NewRequest(http.MethodGet, "/blog/api/posts", nil))
+		if proxied.Code != http.StatusServiceUnavailable {
+			t.Errorf("drained upstream Status = %d; want 503", proxied.Code)
+		}
+
+		adminRequest(handler, http.MethodPost, "/admin/upstreams/blog-service/undrain", "")
+		if admin.router.Upstream("blog-service").draining.Load() {
+			t.Error("upstream should no longer be draining")
+		}
+	})
+
+	t.Run("존재하지 않는 upstream", func(t *testing.T) {
+		rr := adminRequest(handler, http.MethodPost, "/admin/upstreams/nope/drain", "")
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("Status = %d; want 404", rr.Code)
+		}
+	})
+
+	t.Run("rate limit 초기화", func(t *testing.T) {
+		admin.limiter.GetLimiter("10.2.2.2")
+		rr := adminRequest(handler, http.MethodDelete, "/admin/ratelimits/10.2.2.2", "")
+		if rr.Code != http.StatusOK || len(admin.limiter.Snapshot()) != 0 {
+			t.Errorf("Status = %d, clients = %d; want 200, 0", rr.Code, len(admin.limiter.Snapshot()))
+		}
+	})
+
+	t.Run("maintenance 토글", func(t *testing.T) {
+		defer SetMaintenance(admin.router, MaintenanceConfig{})
+
+		rr := adminRequest(handler, http.MethodPut, "/admin/maintenance", `{"enabled": true}`)
+		if rr.Code != http.StatusOK || !Maintenance().Enabled {
+			t.Fatalf("Status = %d; want 200 and maintenance enabled", rr.Code)
+		}
+		rr = adminRequest(handler, http.MethodPut, "/admin/maintenance", `{"allow_cidrs": ["not-a-cidr"]}`)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("Status = %d; want 400", rr.Code)
+		}
+	})
+
+	t.Run("breaker 초기화는 Version별 Breaker 포함", func(t *testing.T) {
+		u := admin.router.Upstream("user-service")
+		if err := u.SetTrafficSplit(&TrafficSplitConfig{Versions: []VersionConfig{
+			{Name: "stable", URL: u.Target.String(), Weight: 1},
+			{Name: "canary", URL: u.Target.String(), Weight: 1, Canary: true},
+		}}); err != nil {
+			t.Fatalf("SetTrafficSplit error: %v", err)
+		}
+		defer u.SetTrafficSplit(nil)
+		for _, v := range u.versions() {
+			for range v.breaker.threshold {
+				v.breaker.RecordFailure()
+			}
+		}
+
+		rr := adminRequest(handler, http.MethodPost, "/admin/upstreams/user-service/reset-breaker", "")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Status = %d; want 200", rr.Code)
+		}
+		for _, v := range u.versions() {
+			if v.breaker.State() != breakerClosed {
+				t.Errorf("version %s breaker = %s; want closed", v.Name, v.breaker.State())
+			}
+		}
+	})
+
+	t.Run("모든 action은 audit log에 기록", func(t *testing.T) {
+		entries := admin.audit.Entries()
+		if len(entries) != 7 {
+			t.Fatalf("audit entries = %d; want 7", len(entries))
+		}
+		first := entries[0]
+		if first.Action != "drain-upstream" || first.Target != "blog-service" || first.Actor != "alice" || first.Status != http.StatusOK {
+			t.Errorf("first entry = %+v", first)
+		}
+		if entries[2].Status != http.StatusNotFound {
+			t.Errorf("failed action should be logged with its status, got %+v", entries[2])
+		}
+
+		rr := adminRequest(handler, http.MethodGet, "/admin/audit", "")
+		var body struct {
+			Entries []auditEntry `json:"entries"`
+		}
+		json.NewDecoder(rr.Body).Decode(&body)
+		if len(body.Entries) != 7 {
+			t.Errorf("GET /admin/audit entries = %d; want 7", len(body.Entries))
+		}
+	})
+}
@@ -0,0 +1,377 @@
+// api-gateway/apikey.go
+// API Key 인증: 머신 클라이언트(k6, traffic-generator)용 Key, Scope, 일/월 Quota
+
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	apiKeyRequestsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "api_key_requests_total",
+			Help: "Total number of requests presenting an API key, by result",
+		},
+		[]string{"key_id", "result"},
+	)
+	apiKeyQuotaUsage = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "api_key_quota_usage",
+			Help: "Requests counted against the API key quota in the current period",
+		},
+		[]string{"key_id", "period"},
+	)
+)
+
+const (
+	apiKeyModeOff      = "off"      // API Key 무시
+	apiKeyModeOptional = "optional" // Key가 있으면 검증, 없으면 익명 허용
+	apiKeyModeRequired = "required" // /api/, /blog/api/ 는 Key 필수
+
+	apiKeyHeader = "X-API-Key"
+)
+
+type contextKey string
+
+const apiKeyContextKey contextKey = "api-key"
+
+// apiKey describes one machine client. Only the SHA-256 of the secret is stored.
+type apiKey struct {
+	ID           string   `json:"id"`
+	Hash         string   `json:"hash"`
+	Scopes       []string `json:"scopes"`
+	DailyQuota   int64    `json:"daily_quota"`
+	MonthlyQuota int64    `json:"monthly_quota"`
+}
+
+// allows reports whether one of the key's scopes covers the request.
+// Scope format: "*", "route-name", "/path/prefix", or either of the last two
+// prefixed with "METHOD ".
+func (k *apiKey) allows(rt *Router, r *http.Request) bool {
+	route := rt.Resolve(r.URL.Path)
+	for _, scope := range k.Scopes {
+		if scope == "*" {
+			return true
+		}
+		method, target := "", scope
+		if i := strings.IndexByte(scope, ' '); i > 0 {
+			method, target = scope[:i], strings.TrimSpace(scope[i+1:])
+		}
+		if method != "" && !strings.EqualFold(method, r.Method) {
+			continue
+		}
+		if !strings.HasPrefix(target, "/") {
+			if route != nil && route.Name == target {
+				return true
+			}
+			continue
+		}
+		if scopeCovers(rt, route, r.URL.Path, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// scopeCovers reports whether a path prefix scope covers a request for route.
+// The route must already be decided by the segment the prefix ends in, so a
+// prefix cannot reach routes chosen by a later segment: "/api/users" does not
+// cover /api/users/x/login (auth-login).
+func scopeCovers(rt *Router, route *Route, path, prefix string) bool {
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	// 버전 세그먼트(/api/v2/...)는 Resolve와 마찬가지로 제외하고 비교
+	path, _ = splitPathVersion(path)
+	prefix, _ = splitPathVersion(prefix)
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	covered := path
+	if i := strings.IndexByte(path[len(prefix):], '/'); i >= 0 {
+		covered = path[:len(prefix)+i]
+	}
+	return rt.Resolve(covered) == route
+}
+
+type apiKeyFile struct {
+	Keys []*apiKey `json:"keys"`
+}
+
+// APIKeyAuth authenticates API keys from a file (API_KEYS_FILE) or from the
+// gateway store under "apikeys:<sha256>", and tracks daily/monthly quotas.
+type APIKeyAuth struct {
+	mode  string
+	keys  map[string]*apiKey // sha256 hex -> key
+	store kvStore
+	now   func() time.Time
+}
+
+func hashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func NewAPIKeyAuth(mode string, keys []*apiKey, store kvStore) *APIKeyAuth {
+	a := &APIKeyAuth{
+		mode:  mode,
+		keys:  make(map[string]*apiKey),
+		store: store,
+		now:   time.Now,
+	}
+	for _, k := range keys {
+		a.keys[strings.ToLower(k.Hash)] = k
+	}
+	return a
+}
+
+func loadAPIKeyFile(path string) ([]*apiKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f apiKeyFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	for _, k := range f.Keys {
+		if k.ID == "" || len(k.Hash) != sha256.Size*2 {
+			return nil, fmt.Errorf("parse %s: key %q needs an id and a sha256 hex hash", path, k.ID)
+		}
+	}
+	return f.Keys, nil
+}
+
+func newAPIKeyAuthFromEnv() *APIKeyAuth {
+	mode := getEnv("API_KEY_MODE", apiKeyModeOff)
+	var keys []*apiKey
+	if path := getEnv("API_KEYS_FILE", ""); path != "" && mode != apiKeyModeOff {
+		loaded, err := loadAPIKeyFile(path)
+		if err != nil {
+			log.Fatalf("API key file: %v", err)
+		}
+		keys = loaded
+		log.Printf("Loaded %d API keys from %s (mode=%s)", len(keys), path, mode)
+	}
+	return NewAPIKeyAuth(mode, keys, gatewayStore)
+}
+
+var globalAPIKeyAuth = newAPIKeyAuthFromEnv()
+
+// presentedAPIKey extracts the secret from X-API-Key or "Authorization: ApiKey <key>".
+func presentedAPIKey(r *http.Request) string {
+	if k := r.Header.Get(apiKeyHeader); k != "" {
+		return k
+	}
+	if auth := r.Header.Get("Authorization"); len(auth) > 7 && strings.EqualFold(auth[:7], "ApiKey ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return ""
+}
+
+func (a *APIKeyAuth) lookup(ctx context.Context, secret string) (*apiKey, error) {
+	hash := hashAPIKey(secret)
+	if k, ok := a.keys[hash]; ok {
+		return k, nil
+	}
+	data, ok, err := a.store.Get(ctx, "apikeys:"+hash)
+	if err != nil || !ok {
+		return nil, err
+	}
+	var k apiKey
+	if err := json.Unmarshal(data, &k); err != nil {
+		return nil, err
+	}
+	k.Hash = hash
+	return &k, nil
+}
+
+// quotaPeriod is one quota window (UTC day or month).
+type quotaPeriod struct {
+	name  string
+	id    string
+	limit int64
+	reset time.Time
+}
+
+func (a *APIKeyAuth) periods(k *apiKey) []quotaPeriod {
+	now := a.now().UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return []quotaPeriod{
+		{name: "daily", id: day.Format("2006-01-02"), limit: k.DailyQuota, reset: day.AddDate(0, 0, 1)},
+		{name: "monthly", id: month.Format("2006-01"), limit: k.MonthlyQuota, reset: month.AddDate(0, 1, 0)},
+	}
+}
+
+func quotaKey(k *apiKey, p quotaPeriod) string {
+	return "quota:" + k.ID + ":" + p.name + ":" + p.id
+}
+
+// consumeQuota counts one request against every configured period and returns
+// the first period whose limit is exceeded, if any.
+func (a *APIKeyAuth) consumeQuota(ctx context.Context, w http.ResponseWriter, k *apiKey) (*quotaPeriod, error) {
+	var exceeded *quotaPeriod
+	for _, p := range a.periods(k) {
+		if p.limit <= 0 {
+			continue
+		}
+		// 다음 기간 시작 이후까지 보관하여 조회 시점 차이를 흡수
+		used, err := a.store.Incr(ctx, quotaKey(k, p), p.reset.Sub(a.now())+time.Hour)
+		if err != nil {
+			return nil, err
+		}
+		apiKeyQuotaUsage.WithLabelValues(k.ID, p.name).Set(float64(used))
+
+		remaining := p.limit - used
+		if remaining < 0 {
+			remaining = 0
+		}
+		suffix := "-" + strings.ToUpper(p.name[:1]) + p.name[1:]
+		w.Header().Set("X-Quota-Limit"+suffix, strconv.FormatInt(p.limit, 10))
+		w.Header().Set("X-Quota-Remaining"+suffix, strconv.FormatInt(remaining, 10))
+		if used > p.limit && exceeded == nil {
+			period := p
+			exceeded = &period
+		}
+	}
+	return exceeded, nil
+}
+
+// quotaUsage is the admin view of one key's consumption.
+type quotaUsage struct {
+	KeyID  string           `json:"key_id"`
+	Scopes []string         `json:"scopes"`
+	Usage  map[string]int64 `json:"usage"`
+	Limits map[string]int64 `json:"limits"`
+}
+
+// Usage reports current-period counters for every known key.
+func (a *APIKeyAuth) Usage(ctx context.Context) ([]quotaUsage, error) {
+	keys := make([]*apiKey, 0, len(a.keys))
+	for _, k := range a.keys {
+		keys = append(keys, k)
+	}
+	storeKeys, err := a.store.Keys(ctx, "apikeys:")
+	if err != nil {
+		return nil, err
+	}
+	for _, sk := range storeKeys {
+		hash := strings.TrimPrefix(sk, "apikeys:")
+		if _, ok := a.keys[hash]; ok {
+			continue
+		}
+		data, ok, err := a.store.Get(ctx, sk)
+		if err != nil {
+			return nil, err
+		}
+		var k apiKey
+		if ok && json.Unmarshal(data, &k) == nil {
+			keys = append(keys, &k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+
+	result := make([]quotaUsage, 0, len(keys))
+	for _, k := range keys {
+		u := quotaUsage{KeyID: k.ID, Scopes: k.Scopes, Usage: map[string]int64{}, Limits: map[string]int64{}}
+		for _, p := range a.periods(k) {
+			u.Limits[p.name] = p.limit
+			data, ok, err := a.store.Get(ctx, quotaKey(k, p))
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				n, _ := strconv.ParseInt(string(data), 10, 64)
+				u.Usage[p.name] = n
+			} else {
+				u.Usage[p.name] = 0
+			}
+		}
+		result = append(result, u)
+	}
+	return result, nil
+}
+
+// apiKeyFromContext returns the authenticated key, or nil for anonymous requests.
+func apiKeyFromContext(ctx context.Context) *apiKey {
+	k, _ := ctx.Value(apiKeyContextKey).(*apiKey)
+	return k
+}
+
+func isAPIPath(path string) bool {
+	return strings.HasPrefix(path, "/api/") || strings.HasPrefix(path, "/blog/api/")
+}
+
+func apiKeyMiddleware(router *Router, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a := globalAPIKeyAuth
+		if a.mode == apiKeyModeOff {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		secret := presentedAPIKey(r)
+		if secret == "" {
+			if a.mode == apiKeyModeRequired && isAPIPath(r.URL.Path) {
+				writeError(w, r, http.StatusUnauthorized, "API key required")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		k, err := a.lookup(r.Context(), secret)
+		if err != nil {
+			log.Printf("API key lookup failed: %v", err)
+			writeError(w, r, http.StatusServiceUnavailable, "API key backend unavailable")
+			return
+		}
+		if k == nil {
+			apiKeyRequestsTotal.WithLabelValues("unknown", "invalid").Inc()
+			writeError(w, r, http.StatusUnauthorized, "Invalid API key")
+			return
+		}
+		if !k.allows(router, r) {
+			apiKeyRequestsTotal.WithLabelValues(k.ID, "forbidden").Inc()
+			writeError(w, r, http.StatusForbidden, "API key not allowed for this route")
+			return
+		}
+
+		exceeded, err := a.consumeQuota(r.Context(), w, k)
+		if err != nil {
+			// Quota 저장소 장애 시에는 fail-open (트래픽 차단보다 과금 누락이 낫다)
+			log.Printf("API key quota tracking failed for %s: %v", k.ID, err)
+		}
+		if exceeded != nil {
+			apiKeyRequestsTotal.WithLabelValues(k.ID, "quota_exceeded").Inc()
+			retryAfter := int(exceeded.reset.Sub(a.now()).Seconds()) + 1
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			writeError(w, r, http.StatusTooManyRequests, exceeded.name+" quota exceeded")
+			return
+		}
+
+		apiKeyRequestsTotal.WithLabelValues(k.ID, "allowed").Inc()
+		// Key 원문은 upstream으로 전달하지 않음
+		r.Header.Del(apiKeyHeader)
+		if r.Header.Get("Authorization") != "" && presentedAPIKey(r) != "" {
+			r.Header.Del("Authorization")
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiKeyContextKey, k)))
+	})
+}
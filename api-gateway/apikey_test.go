@@ -0,0 +1,301 @@
+// api-gateway/apikey_test.go
+// 단위 테스트: API Key 인증, Scope, Quota, Admin 조회
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// useAPIKeyAuth swaps the global authenticator for the duration of a test
+func useAPIKeyAuth(t *testing.T, a *APIKeyAuth) {
+	t.Helper()
+	original := globalAPIKeyAuth
+	globalAPIKeyAuth = a
+	t.Cleanup(func() { globalAPIKeyAuth = original })
+}
+
+func newTestAPIKeyAuth(mode string, store kvStore) *APIKeyAuth {
+	return NewAPIKeyAuth(mode, []*apiKey{
+		{ID: "k6", Hash: hashAPIKey("k6-secret"), Scopes: []string{"GET /blog/api/", "/api/login"}, DailyQuota: 2},
+		{ID: "ops", Hash: hashAPIKey("ops-secret"), Scopes: []string{"*"}},
+	}, store)
+}
+
+// TestAPIKeyAllows tests scope matching
+func TestAPIKeyAllows(t *testing.T) {
+	router := newTestRouter(t)
+	k := &apiKey{Scopes: []string{"GET /blog/api/", "/api/login", "/api/users", "POST user-register"}}
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		expected bool
+	}{
+		{"메서드+prefix 일치", http.MethodGet, "/blog/api/posts", true},
+		{"메서드 불일치", http.MethodPost, "/blog/api/posts", false},
+		{"메서드 없는 scope", http.MethodPost, "/api/login", true},
+		{"scope 밖 경로", http.MethodGet, "/api/posts/1", false},
+		{"prefix 하위 경로", http.MethodGet, "/api/users/alice", true},
+		{"prefix 아래 login Route는 제외", http.MethodPost, "/api/users/x/login", false},
+		{"blog prefix 아래 login Route는 제외", http.MethodGet, "/blog/api/x/login", false},
+		{"Route 이름 scope", http.MethodPost, "/api/users/x/register", true},
+		{"Route 이름 scope 메서드 불일치", http.MethodGet, "/api/register", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if got := k.allows(router, req); got != tt.expected {
+				t.Errorf("allows(%s %s) = %v; want %v", tt.method, tt.path, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestPresentedAPIKey tests key extraction from headers
+func TestPresentedAPIKey(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-API-Key", "from-header")
+	if got := presentedAPIKey(req); got != "from-header" {
+		t.Errorf("presentedAPIKey = %s; want from-header", got)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "ApiKey from-auth")
+	if got := presentedAPIKey(req); got != "from-auth" {
+		t.Errorf("presentedAPIKey = %s; want from-auth", got)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer jwt")
+	if got := presentedAPIKey(req); got != "" {
+		t.Errorf("Bearer token should not be treated as API key, got %s", got)
+	}
+}
+
+// TestAPIKeyMiddleware tests authentication, scopes and quotas end to end
+func TestAPIKeyMiddleware(t *testing.T) {
+	router := newTestRouter(t)
+	var seenKey *apiKey
+	var seenHeader string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seenKey = apiKeyFromContext(r.Context())
+		seenHeader = r.Header.Get("X-API-Key")
+		w.WriteHeader(http.StatusOK)
+	})
+
+	send := func(handler http.Handler, method, path, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("off 모드는 Key 무시", func(t *testing.T) {
+		useAPIKeyAuth(t, newTestAPIKeyAuth(apiKeyModeOff, newMemoryStore()))
+		rr := send(apiKeyMiddleware(router, next), http.MethodGet, "/api/users/a", "bogus")
+		if rr.Code != http.StatusOK {
+			t.Errorf("Status = %d; want 200", rr.Code)
+		}
+	})
+
+	t.Run("optional 모드 - 익명 허용", func(t *testing.T) {
+		useAPIKeyAuth(t, newTestAPIKeyAuth(apiKeyModeOptional, newMemoryStore()))
+		rr := send(apiKeyMiddleware(router, next), http.MethodGet, "/api/users/a", "")
+		if rr.Code != http.StatusOK || seenKey != nil {
+			t.Errorf("Status = %d, key = %v; want 200, nil", rr.Code, seenKey)
+		}
+	})
+
+	t.Run("required 모드 - Key 없으면 401", func(t *testing.T) {
+		useAPIKeyAuth(t, newTestAPIKeyAuth(apiKeyModeRequired, newMemoryStore()))
+		rr := send(apiKeyMiddleware(router, next), http.MethodGet, "/api/users/a", "")
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("Status = %d; want 401", rr.Code)
+		}
+		// API 경로가 아니면 Key 불필요
+		rr = send(apiKeyMiddleware(router, next), http.MethodGet, "/health", "")
+		if rr.Code != http.StatusOK {
+			t.Errorf("/health Status = %d; want 200", rr.Code)
+		}
+	})
+
+	t.Run("잘못된 Key는 401", func(t *testing.T) {
+		useAPIKeyAuth(t, newTestAPIKeyAuth(apiKeyModeOptional, newMemoryStore()))
+		rr := send(apiKeyMiddleware(router, next), http.MethodGet, "/blog/api/posts", "wrong")
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("Status = %d; want 401", rr.Code)
+		}
+	})
+
+	t.Run("유효한 Key - context 전달, Key 헤더 제거", func(t *testing.T) {
+		useAPIKeyAuth(t, newTestAPIKeyAuth(apiKeyModeOptional, newMemoryStore()))
+		rr := send(apiKeyMiddleware(router, next), http.MethodGet, "/blog/api/posts", "ops-secret")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Status = %d; want 200", rr.Code)
+		}
+		if seenKey == nil || seenKey.ID != "ops" {
+			t.Errorf("context key = %v; want ops", seenKey)
+		}
+		if seenHeader != "" {
+			t.Error("X-API-Key should not be forwarded upstream")
+		}
+	})
+
+	t.Run("Scope 밖 경로는 403", func(t *testing.T) {
+		useAPIKeyAuth(t, newTestAPIKeyAuth(apiKeyModeOptional, newMemoryStore()))
+		rr := send(apiKeyMiddleware(router, next), http.MethodDelete, "/blog/api/posts/1", "k6-secret")
+		if rr.Code != http.StatusForbidden {
+			t.Errorf("Status = %d; want 403", rr.Code)
+		}
+	})
+
+	t.Run("Scope prefix 아래 login 경로로 auth-service 접근 불가", func(t *testing.T) {
+		useAPIKeyAuth(t, newTestAPIKeyAuth(apiKeyModeOptional, newMemoryStore()))
+		rr := send(apiKeyMiddleware(router, next), http.MethodGet, "/blog/api/posts/login", "k6-secret")
+		if rr.Code != http.StatusForbidden {
+			t.Errorf("Status = %d; want 403", rr.Code)
+		}
+	})
+
+	t.Run("일일 Quota 초과 시 429", func(t *testing.T) {
+		useAPIKeyAuth(t, newTestAPIKeyAuth(apiKeyModeOptional, newMemoryStore()))
+		handler := apiKeyMiddleware(router, next)
+		for i := 0; i < 2; i++ {
+			rr := send(handler, http.MethodGet, "/blog/api/posts", "k6-secret")
+			if rr.Code != http.StatusOK {
+				t.Fatalf("request %d Status = %d; want 200", i+1, rr.Code)
+			}
+		}
+		rr := send(handler, http.MethodGet, "/blog/api/posts", "k6-secret")
+		if rr.Code != http.StatusTooManyRequests {
+			t.Errorf("Status = %d; want 429", rr.Code)
+		}
+		if rr.Header().Get("Retry-After") == "" {
+			t.Error("Retry-After header should be set")
+		}
+		if got := rr.Header().Get("X-Quota-Remaining-Daily"); got != "0" {
+			t.Errorf("X-Quota-Remaining-Daily = %s; want 0", got)
+		}
+	})
+
+	t.Run("Redis에 저장된 Key 조회", func(t *testing.T) {
+		store := newMemoryStore()
+		data, _ := json.Marshal(apiKey{ID: "redis-key", Scopes: []string{"*"}})
+		store.Set(context.Background(), "apikeys:"+hashAPIKey("stored-secret"), data, 0)
+		useAPIKeyAuth(t, newTestAPIKeyAuth(apiKeyModeOptional, store))
+
+		rr := send(apiKeyMiddleware(router, next), http.MethodGet, "/api/users/a", "stored-secret")
+		if rr.Code != http.StatusOK || seenKey == nil || seenKey.ID != "redis-key" {
+			t.Errorf("Status = %d, key = %v; want 200, redis-key", rr.Code, seenKey)
+		}
+	})
+}
+
+// TestAPIKeyQuotaPeriods tests that counters roll over with the UTC day
+func TestAPIKeyQuotaPeriods(t *testing.T) {
+	now := time.Date(2026, 10, 18, 23, 59, 0, 0, time.UTC)
+	a := newTestAPIKeyAuth(apiKeyModeOptional, newMemoryStore())
+	a.now = func() time.Time { return now }
+	k := a.keys[hashAPIKey("k6-secret")]
+
+	for i := 0; i < 3; i++ {
+		a.consumeQuota(context.Background(), httptest.NewRecorder(), k)
+	}
+	now = now.Add(2 * time.Minute)
+	exceeded, err := a.consumeQuota(context.Background(), httptest.NewRecorder(), k)
+	if err != nil {
+		t.Fatalf("consumeQuota error: %v", err)
+	}
+	if exceeded != nil {
+		t.Errorf("new day should reset the daily quota, exceeded %s", exceeded.name)
+	}
+}
+
+// TestLoadAPIKeyFile tests key file parsing and validation
+func TestLoadAPIKeyFile(t *testing.T) {
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "keys.json")
+	os.WriteFile(valid, []byte(`{"keys":[{"id":"k6","hash":"`+hashAPIKey("s")+`","scopes":["*"],"daily_quota":10}]}`), 0o600)
+	keys, err := loadAPIKeyFile(valid)
+	if err != nil || len(keys) != 1 || keys[0].DailyQuota != 10 {
+		t.Errorf("loadAPIKeyFile = %v, %v; want 1 key with quota 10", keys, err)
+	}
+
+	invalid := filepath.Join(dir, "bad.json")
+	os.WriteFile(invalid, []byte(`{"keys":[{"id":"k6","hash":"plaintext"}]}`), 0o600)
+	if _, err := loadAPIKeyFile(invalid); err == nil {
+		t.Error("non-sha256 hash should be rejected")
+	}
+}
+
+// TestRateLimitMiddlewareSkipsAPIKeys tests that key clients bypass the IP limiter
+func TestRateLimitMiddlewareSkipsAPIKeys(t *testing.T) {
+	originalLimiter := globalLimiter
+	globalLimiter = NewRateLimiter(1, 1)
+	defer func() { globalLimiter = originalLimiter }()
+
+	handler := rateLimitMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for i := 0; i < 5; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/api/users", nil)
+		req.RemoteAddr = "192.168.250.1:12345"
+		req = req.WithContext(context.WithValue(req.Context(), apiKeyContextKey, &apiKey{ID: "k6"}))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("request %d Status = %d; want 200", i+1, rr.Code)
+		}
+	}
+}
+
+// TestAdminQuotasHandler tests the admin quota listing and token check
+func TestAdminQuotasHandler(t *testing.T) {
+	originalToken := adminToken
+	adminToken = "admin-secret"
+	defer func() { adminToken = originalToken }()
+
+	a := newTestAPIKeyAuth(apiKeyModeOptional, newMemoryStore())
+	a.consumeQuota(context.Background(), httptest.NewRecorder(), a.keys[hashAPIKey("k6-secret")])
+
+	handler := NewAdminServer(&GatewayConfig{}, NewRouter(nil, nil, nil), NewRateLimiter(1, 1), a, NewIPFilter(newMemoryStore())).Handler()
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/quotas", nil))
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("without token Status = %d; want 401", rr.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/quotas", nil)
+	req.Header.Set("Authorization", "Bearer admin-secret")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Status = %d; want 200", rr.Code)
+	}
+
+	var body struct {
+		Keys []quotaUsage `json:"keys"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if len(body.Keys) != 2 || body.Keys[0].KeyID != "k6" || body.Keys[0].Usage["daily"] != 1 {
+		t.Errorf("keys = %+v; want k6 with daily usage 1", body.Keys)
+	}
+}
@@ -0,0 +1,341 @@
+// api-gateway/bff.go
+// BFF(Backend-for-Frontend) 세션: 로그인 응답의 JWT를 서버 측에 보관하고 HttpOnly Cookie로 교체, 이후 요청에 Authorization 주입
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var bffSessionsTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "bff_sessions_total",
+		Help: "Total number of BFF session events (created, renewed, expired, logout)",
+	},
+	[]string{"event"},
+)
+
+const (
+	// sessionModeHeader opts a login request into a cookie session. Clients
+	// that do not send it (CLI, mobile) keep receiving the token as before
+	sessionModeHeader = "X-Auth-Mode"
+	sessionModeCookie = "cookie"
+
+	bffLogoutPath = "/api/logout"
+)
+
+// BFFConfig is the "bff" section of the config file.
+type BFFConfig struct {
+	Enabled    bool   `json:"enabled"`
+	CookieName string `json:"cookie_name,omitempty"`
+	// IdleTimeout expires a session that has not been used; every use slides it
+	IdleTimeout jsonDuration `json:"idle_timeout,omitempty"`
+	// MaxLifetime caps a session regardless of activity (and never outlives
+	// the JWT's own exp)
+	MaxLifetime jsonDuration `json:"max_lifetime,omitempty"`
+	// InsecureCookie drops the Secure attribute on plain-HTTP requests, for
+	// local development without TLS
+	InsecureCookie bool `json:"insecure_cookie,omitempty"`
+}
+
+func newBFFConfig(cfg BFFConfig) (*BFFConfig, error) {
+	if cfg.CookieName == "" {
+		cfg.CookieName = "session"
+	}
+	if err := (&http.Cookie{Name: cfg.CookieName, Value: "x"}).Valid(); err != nil {
+		return nil, fmt.Errorf("bff: invalid cookie_name: %w", err)
+	}
+	if cfg.IdleTimeout < 0 || cfg.MaxLifetime < 0 {
+		return nil, fmt.Errorf("bff: durations must not be negative")
+	}
+	if cfg.IdleTimeout == 0 {
+		cfg.IdleTimeout = jsonDuration(30 * time.Minute)
+	}
+	if cfg.MaxLifetime == 0 {
+		cfg.MaxLifetime = jsonDuration(12 * time.Hour)
+	}
+	if cfg.MaxLifetime < cfg.IdleTimeout {
+		return nil, fmt.Errorf("bff: max_lifetime must not be shorter than idle_timeout")
+	}
+	return &cfg, nil
+}
+
+// bffSession is the stored record. The store key is a hash of the cookie
+// value, so a dump of the store cannot be replayed as cookies.
+type bffSession struct {
+	Token    string    `json:"token"`
+	Username string    `json:"username,omitempty"`
+	Created  time.Time `json:"created"`
+	LastSeen time.Time `json:"last_seen"`
+	Expires  time.Time `json:"expires"`
+}
+
+// BFFSessions keeps sessions in the gateway store (shared by replicas with
+// GATEWAY_STORE=redis).
+type BFFSessions struct {
+	store  kvStore
+	config atomic.Pointer[BFFConfig]
+	now    func() time.Time
+}
+
+func NewBFFSessions(store kvStore) *BFFSessions {
+	s := &BFFSessions{store: store, now: time.Now}
+	cfg, _ := newBFFConfig(BFFConfig{})
+	s.config.Store(cfg)
+	return s
+}
+
+var globalBFF = NewBFFSessions(gatewayStore)
+
+// SetConfig replaces the session settings.
+func (s *BFFSessions) SetConfig(cfg BFFConfig) error {
+	c, err := newBFFConfig(cfg)
+	if err != nil {
+		return err
+	}
+	s.config.Store(c)
+	return nil
+}
+
+func sessionKey(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return "sessions:" + hex.EncodeToString(sum[:])
+}
+
+// save stores sess with the idle timeout, bounded by its absolute expiry.
+func (s *BFFSessions) save(ctx context.Context, cfg *BFFConfig, id string, sess *bffSession) error {
+	ttl := min(time.Duration(cfg.IdleTimeout), sess.Expires.Sub(s.now()))
+	if ttl <= 0 {
+		return s.store.Delete(ctx, sessionKey(id))
+	}
+	data, _ := json.Marshal(sess)
+	return s.store.Set(ctx, sessionKey(id), data, ttl)
+}
+
+func (s *BFFSessions) load(ctx context.Context, id string) (*bffSession, error) {
+	data, ok, err := s.store.Get(ctx, sessionKey(id))
+	if err != nil || !ok {
+		return nil, err
+	}
+	var sess bffSession
+	if err := json.Unmarshal(data, &sess); err != nil {
+		return nil, err
+	}
+	return &sess, nil
+}
+
+// create starts a session for token and returns its cookie value.
+func (s *BFFSessions) create(ctx context.Context, cfg *BFFConfig, token string) (string, *bffSession, error) {
+	b := make([]byte, 32)
+	rand.Read(b)
+	id := base64.RawURLEncoding.EncodeToString(b)
+
+	now := s.now()
+	sess := &bffSession{Token: token, Created: now, LastSeen: now, Expires: now.Add(time.Duration(cfg.MaxLifetime))}
+	if claims, ok := jwtClaims(token); ok {
+		sess.Username = claims.Username
+		if claims.Exp > 0 {
+			if exp := time.Unix(claims.Exp, 0); exp.Before(sess.Expires) {
+				sess.Expires = exp
+			}
+		}
+	}
+	return id, sess, s.save(ctx, cfg, id, sess)
+}
+
+type jwtPayload struct {
+	Username string `json:"username"`
+	Exp      int64  `json:"exp"`
+}
+
+// jwtClaims reads the payload without verifying the signature; the services
+// still verify the token on every request.
+func jwtClaims(token string) (jwtPayload, bool) {
+	var claims jwtPayload
+	return claims, decodeJWTPayload(token, &claims)
+}
+
+// decodeJWTPayload unmarshals the (unverified) payload segment of token into v.
+func decodeJWTPayload(token string, v any) bool {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return false
+	}
+	data, err := base64.RawURLEncoding.DecodeString(parts[1])
+	return err == nil && json.Unmarshal(data, v) == nil
+}
+
+func requestIsHTTPS(r *http.Request) bool {
+	return r.TLS != nil || trustedHopValue(r.Header, "X-Forwarded-Proto") == "https"
+}
+
+func (s *BFFSessions) setCookie(w http.ResponseWriter, r *http.Request, cfg *BFFConfig, value string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     cfg.CookieName,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		Secure:   !cfg.InsecureCookie || requestIsHTTPS(r),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// stripCookie removes the session cookie so upstreams never see it.
+func stripCookie(r *http.Request, name string) {
+	cookies := r.Cookies()
+	r.Header.Del("Cookie")
+	for _, c := range cookies {
+		if c.Name != name {
+			r.AddCookie(c)
+		}
+	}
+}
+
+// bufferedResponse holds a response so that it can be rewritten before it is
+// sent.
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header { return b.header }
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	b.WriteHeader(http.StatusOK)
+	return b.body.Write(p)
+}
+
+// bffMiddleware swaps login tokens for session cookies, injects the stored
+// token into requests that carry the cookie and handles POST /api/logout.
+func bffMiddleware(s *BFFSessions, router *Router, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg := s.config.Load()
+		if !cfg.Enabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx := r.Context()
+
+		var sessionID string
+		if c, err := r.Cookie(cfg.CookieName); err == nil {
+			sessionID = c.Value
+			stripCookie(r, cfg.CookieName)
+		}
+
+		if r.URL.Path == bffLogoutPath && r.Method == http.MethodPost {
+			if sessionID != "" {
+				if err := s.store.Delete(ctx, sessionKey(sessionID)); err != nil {
+					log.Printf("BFF session delete failed: %v", err)
+				}
+				bffSessionsTotal.WithLabelValues("logout").Inc()
+			}
+			s.setCookie(w, r, cfg, "", -1)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if route := router.Resolve(r.URL.Path); route != nil && route.Name == "auth-login" &&
+			r.Method == http.MethodPost && r.Header.Get(sessionModeHeader) == sessionModeCookie {
+			serveSessionLogin(s, cfg, w, r, next)
+			return
+		}
+
+		if sessionID != "" && r.Header.Get("Authorization") == "" {
+			sess, err := s.load(ctx, sessionID)
+			if err != nil {
+				log.Printf("BFF session lookup failed: %v", err)
+			}
+			now := s.now()
+			switch {
+			case sess == nil || !now.Before(sess.Expires):
+				if err == nil {
+					// 만료된 세션: Cookie 삭제 후 인증 없이 전달 (upstream이 401 응답)
+					bffSessionsTotal.WithLabelValues("expired").Inc()
+					s.setCookie(w, r, cfg, "", -1)
+				}
+			default:
+				r.Header.Set("Authorization", "Bearer "+sess.Token)
+				// Sliding renewal: 매 요청마다 쓰지 않도록 idle_timeout의 1/4이 지난 경우에만 갱신
+				if now.Sub(sess.LastSeen) >= time.Duration(cfg.IdleTimeout)/4 {
+					sess.LastSeen = now
+					if err := s.save(ctx, cfg, sessionID, sess); err != nil {
+						log.Printf("BFF session renew failed: %v", err)
+					} else {
+						bffSessionsTotal.WithLabelValues("renewed").Inc()
+					}
+				}
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// serveSessionLogin forwards the login and, on success, keeps the token and
+// answers with a session cookie and the username instead.
+func serveSessionLogin(s *BFFSessions, cfg *BFFConfig, w http.ResponseWriter, r *http.Request, next http.Handler) {
+	buf := &bufferedResponse{header: http.Header{}}
+	next.ServeHTTP(buf, r)
+	if buf.status == 0 {
+		buf.status = http.StatusOK
+	}
+
+	var body struct {
+		Token string `json:"token"`
+	}
+	if buf.status == http.StatusOK && json.Unmarshal(buf.body.Bytes(), &body) == nil && body.Token != "" {
+		id, sess, err := s.create(r.Context(), cfg, body.Token)
+		if err != nil {
+			log.Printf("BFF session create failed: %v", err)
+			writeError(w, r, http.StatusServiceUnavailable, "Session store unavailable")
+			return
+		}
+		bffSessionsTotal.WithLabelValues("created").Inc()
+		// Upstream 헤더(Set-Cookie, 헤더 변환 규칙 등)는 유지하고 본문 관련 헤더만 새로 작성
+		for k, v := range buf.header {
+			if k != "Content-Length" && k != "Content-Type" {
+				w.Header()[k] = v
+			}
+		}
+		s.setCookie(w, r, cfg, id, 0)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		json.NewEncoder(w).Encode(map[string]any{
+			"status":     "success",
+			"username":   sess.Username,
+			"expires_at": sess.Expires,
+		})
+		return
+	}
+
+	// 실패 응답은 그대로 전달
+	for k, v := range buf.header {
+		if k != "Content-Length" {
+			w.Header()[k] = v
+		}
+	}
+	w.WriteHeader(buf.status)
+	w.Write(buf.body.Bytes())
+}
@@ -0,0 +1,231 @@
+// api-gateway/bff_test.go
+// 단위 테스트: BFF 세션 로그인/토큰 주입/로그아웃, Sliding 갱신과 만료
+
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testJWT builds an unsigned token with the given claims; the gateway only reads them
+func testJWT(username string, exp time.Time) string {
+	payload, _ := json.Marshal(jwtPayload{Username: username, Exp: exp.Unix()})
+	return "eyJhbGciOiJSUzI1NiJ9." + base64.RawURLEncoding.EncodeToString(payload) + ".sig"
+}
+
+type bffTestEnv struct {
+	handler http.Handler
+	clock   *time.Time
+	seen    *http.Request
+	token   string
+}
+
+func newBFFTestEnv(t *testing.T, cfg BFFConfig) *bffTestEnv {
+	t.Helper()
+	store := newMemoryStore()
+	clock := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	env := &bffTestEnv{clock: &clock, token: testJWT("alice", clock.Add(24*time.Hour))}
+	store.now = func() time.Time { return *env.clock }
+
+	s := NewBFFSessions(store)
+	s.now = store.now
+	if err := s.SetConfig(cfg); err != nil {
+		t.Fatalf("SetConfig error: %v", err)
+	}
+	env.handler = bffMiddleware(s, newTestRouter(t), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		env.seen = r
+		if r.URL.Path == "/api/login" {
+			var c struct{ Password string }
+			json.NewDecoder(r.Body).Decode(&c)
+			w.Header().Set("Content-Type", "application/json")
+			if c.Password != "correct" {
+				w.WriteHeader(http.StatusUnauthorized)
+				io.WriteString(w, `{"status": "failed", "message": "Invalid username or password"}`)
+				return
+			}
+			w.Header().Set("X-Upstream", "auth-service")
+			io.WriteString(w, `{"status": "success", "token": "`+env.token+`"}`)
+		}
+	}))
+	return env
+}
+
+func (e *bffTestEnv) login(t *testing.T, password string, cookieMode bool) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "https://gateway.example.com/api/login", strings.NewReader(`{"username": "alice", "password": "`+password+`"}`))
+	if cookieMode {
+		req.Header.Set(sessionModeHeader, sessionModeCookie)
+	}
+	rr := httptest.NewRecorder()
+	e.handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func (e *bffTestEnv) get(cookie string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "https://gateway.example.com/blog/api/posts", nil)
+	req.Header.Set("Cookie", cookie)
+	rr := httptest.NewRecorder()
+	e.handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func sessionCookie(t *testing.T, rr *httptest.ResponseRecorder) *http.Cookie {
+	t.Helper()
+	for _, c := range rr.Result().Cookies() {
+		if c.Name == "session" {
+			return c
+		}
+	}
+	return nil
+}
+
+// TestBFFSessionFlow tests login, token injection and logout
+func TestBFFSessionFlow(t *testing.T) {
+	env := newBFFTestEnv(t, BFFConfig{Enabled: true})
+
+	rr := env.login(t, "correct", true)
+	c := sessionCookie(t, rr)
+	if rr.Code != http.StatusOK || c == nil {
+		t.Fatalf("login = %d, cookie %v; want 200 with session cookie", rr.Code, c)
+	}
+	if !c.HttpOnly || !c.Secure || c.SameSite != http.SameSiteLaxMode {
+		t.Errorf("cookie = %+v; want HttpOnly, Secure, SameSite=Lax", c)
+	}
+	t.Run("HTTP 요청이어도 기본은 Secure", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "http://gateway.example.com/api/login", strings.NewReader(`{"username": "alice", "password": "correct"}`))
+		req.Header.Set(sessionModeHeader, sessionModeCookie)
+		rr := httptest.NewRecorder()
+		env.handler.ServeHTTP(rr, req)
+		if c := sessionCookie(t, rr); c == nil || !c.Secure {
+			t.Errorf("cookie = %+v; want Secure", c)
+		}
+	})
+
+	t.Run("insecure_cookie면 HTTP 요청에 Secure 없음", func(t *testing.T) {
+		env := newBFFTestEnv(t, BFFConfig{Enabled: true, InsecureCookie: true})
+		req := httptest.NewRequest(http.MethodPost, "http://gateway.example.com/api/login", strings.NewReader(`{"username": "alice", "password": "correct"}`))
+		req.Header.Set(sessionModeHeader, sessionModeCookie)
+		req.Header.Set("X-Forwarded-Proto", "https")
+		rr := httptest.NewRecorder()
+		env.handler.ServeHTTP(rr, req)
+		if c := sessionCookie(t, rr); c == nil || c.Secure {
+			t.Errorf("cookie = %+v; want no Secure (X-Forwarded-Proto from an untrusted client)", c)
+		}
+	})
+
+	var body map[string]any
+	json.NewDecoder(rr.Body).Decode(&body)
+	if body["username"] != "alice" || body["token"] != nil {
+		t.Errorf("login body = %v; want username and no token", body)
+	}
+	if rr.Header().Get("X-Upstream") != "auth-service" {
+		t.Errorf("login headers = %v; want upstream headers kept", rr.Header())
+	}
+
+	env.get("theme=dark; session=" + c.Value)
+	if got := env.seen.Header.Get("Authorization"); got != "Bearer "+env.token {
+		t.Errorf("upstream Authorization = %q; want stored token", got)
+	}
+	if cookie := env.seen.Header.Get("Cookie"); cookie != "theme=dark" {
+		t.Errorf("upstream Cookie = %q; want session cookie stripped", cookie)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, bffLogoutPath, nil)
+	req.Header.Set("Cookie", "session="+c.Value)
+	rr = httptest.NewRecorder()
+	env.handler.ServeHTTP(rr, req)
+	if cleared := sessionCookie(t, rr); rr.Code != http.StatusNoContent || cleared == nil || cleared.MaxAge >= 0 {
+		t.Errorf("logout = %d, cookie %v; want 204 and cleared cookie", rr.Code, cleared)
+	}
+	env.get("session=" + c.Value)
+	if got := env.seen.Header.Get("Authorization"); got != "" {
+		t.Errorf("Authorization after logout = %q; want none", got)
+	}
+}
+
+// TestBFFLoginPassThrough tests logins that must not be turned into sessions
+func TestBFFLoginPassThrough(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        BFFConfig
+		password   string
+		cookieMode bool
+		expectCode int
+		expectBody string
+	}{
+		{"Cookie 모드 요청 아님", BFFConfig{Enabled: true}, "correct", false, http.StatusOK, `"token"`},
+		{"BFF 비활성화", BFFConfig{}, "correct", true, http.StatusOK, `"token"`},
+		{"로그인 실패", BFFConfig{Enabled: true}, "wrong", true, http.StatusUnauthorized, "Invalid username or password"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newBFFTestEnv(t, tt.cfg)
+			rr := env.login(t, tt.password, tt.cookieMode)
+			if rr.Code != tt.expectCode || !strings.Contains(rr.Body.String(), tt.expectBody) {
+				t.Errorf("login = %d %s; want %d containing %s", rr.Code, rr.Body.String(), tt.expectCode, tt.expectBody)
+			}
+			if c := sessionCookie(t, rr); c != nil {
+				t.Errorf("unexpected session cookie %+v", c)
+			}
+		})
+	}
+}
+
+// TestBFFSessionExpiry tests sliding renewal, idle expiry and the JWT cap
+func TestBFFSessionExpiry(t *testing.T) {
+	t.Run("사용 중에는 갱신, 유휴 시 만료", func(t *testing.T) {
+		env := newBFFTestEnv(t, BFFConfig{Enabled: true, IdleTimeout: jsonDuration(30 * time.Minute)})
+		cookie := "session=" + sessionCookie(t, env.login(t, "correct", true)).Value
+
+		for i := 0; i < 3; i++ {
+			*env.clock = env.clock.Add(20 * time.Minute)
+			env.get(cookie)
+			if env.seen.Header.Get("Authorization") == "" {
+				t.Fatalf("session expired after %d active periods", i+1)
+			}
+		}
+
+		*env.clock = env.clock.Add(31 * time.Minute)
+		rr := env.get(cookie)
+		if env.seen.Header.Get("Authorization") != "" {
+			t.Error("idle session should have expired")
+		}
+		if c := sessionCookie(t, rr); c == nil || c.MaxAge >= 0 {
+			t.Errorf("expired session cookie = %v; want cleared", c)
+		}
+	})
+
+	t.Run("JWT exp보다 오래 유지하지 않음", func(t *testing.T) {
+		env := newBFFTestEnv(t, BFFConfig{Enabled: true, IdleTimeout: jsonDuration(time.Hour)})
+		env.token = testJWT("alice", env.clock.Add(45*time.Minute))
+		cookie := "session=" + sessionCookie(t, env.login(t, "correct", true)).Value
+
+		*env.clock = env.clock.Add(30 * time.Minute)
+		env.get(cookie)
+		*env.clock = env.clock.Add(20 * time.Minute)
+		env.get(cookie)
+		if env.seen.Header.Get("Authorization") != "" {
+			t.Error("session should not outlive the token")
+		}
+	})
+}
+
+// TestBFFConfigErrors tests rejected bff sections
+func TestBFFConfigErrors(t *testing.T) {
+	for _, cfg := range []BFFConfig{
+		{CookieName: "bad name"},
+		{IdleTimeout: jsonDuration(-time.Second)},
+		{IdleTimeout: jsonDuration(2 * time.Hour), MaxLifetime: jsonDuration(time.Hour)},
+	} {
+		if err := NewBFFSessions(newMemoryStore()).SetConfig(cfg); err == nil {
+			t.Errorf("SetConfig(%+v) should fail", cfg)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// api-gateway/bodylimit.go
+// 요청 본문 크기 제한: Route별 한도, Content-Length 사전 거부, 대용량 업로드용 Streaming 모드
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var requestBodyLimitExceededTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "request_body_limit_exceeded_total",
+		Help: "Total number of requests rejected for exceeding the body size limit, by check (content_length, body)",
+	},
+	[]string{"route", "check"},
+)
+
+// === Request Size Limit ===
+const MaxRequestBodySize = 10 << 20 // 10MB (Gemini recommendation)
+
+// BodyLimitConfig overrides a route's body limit
+// ("body_limits" section of the config file, keyed by route name).
+type BodyLimitConfig struct {
+	MaxBytes int64 `json:"max_bytes"`
+	// Streaming lifts the server read/write deadlines for the request so that
+	// large uploads are not cut off by WriteTimeout, and skips request
+	// validation, which would buffer the whole body
+	Streaming bool `json:"streaming,omitempty"`
+}
+
+func (rt *Router) newBodyLimits(configs map[string]BodyLimitConfig) (map[string]BodyLimitConfig, error) {
+	for name, cfg := range configs {
+		if rt.route(name) == nil {
+			return nil, fmt.Errorf("body_limits: unknown route %q", name)
+		}
+		if cfg.MaxBytes <= 0 {
+			return nil, fmt.Errorf("body_limits %s: max_bytes must be positive", name)
+		}
+	}
+	return configs, nil
+}
+
+// SetBodyLimits replaces the per-route body limit overrides.
+func (rt *Router) SetBodyLimits(configs map[string]BodyLimitConfig) error {
+	limits, err := rt.newBodyLimits(configs)
+	if err != nil {
+		return err
+	}
+	rt.bodyLimits.Store(&limits)
+	return nil
+}
+
+// bodyLimit returns the effective limit for route: the config file override,
+// else the route table default, else MaxRequestBodySize.
+func (rt *Router) bodyLimit(route *Route) BodyLimitConfig {
+	if route == nil {
+		return BodyLimitConfig{MaxBytes: MaxRequestBodySize}
+	}
+	if limits := rt.bodyLimits.Load(); limits != nil {
+		if cfg, ok := (*limits)[route.Name]; ok {
+			return cfg
+		}
+	}
+	if route.MaxBodyBytes > 0 {
+		return BodyLimitConfig{MaxBytes: route.MaxBodyBytes}
+	}
+	return BodyLimitConfig{MaxBytes: MaxRequestBodySize}
+}
+
+// requestSizeLimitMiddleware applies the body limit of the route the request
+// resolves to. A declared Content-Length over the limit is rejected before
+// anything is read; chunked bodies are cut off by MaxBytesReader.
+func requestSizeLimitMiddleware(router *Router, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := router.Resolve(r.URL.Path)
+		limit := router.bodyLimit(route)
+		routeName := "none"
+		if route != nil {
+			routeName = route.Name
+		}
+
+		if r.ContentLength > limit.MaxBytes {
+			requestBodyLimitExceededTotal.WithLabelValues(routeName, "content_length").Inc()
+			// 본문을 읽지 않고 응답하므로 연결은 재사용하지 않음
+			w.Header().Set("Connection", "close")
+			writeProblem(w, r, &problem{
+				Type:   problemTypeRequestTooLarge,
+				Status: http.StatusRequestEntityTooLarge,
+				Detail: fmt.Sprintf("Request body exceeds %d bytes", limit.MaxBytes),
+			})
+			return
+		}
+
+		if limit.Streaming {
+			// 업로드 시간이 WriteTimeout을 넘을 수 있으므로 이 요청만 deadline 해제
+			rc := http.NewResponseController(w)
+			rc.SetReadDeadline(time.Time{})
+			rc.SetWriteDeadline(time.Time{})
+		}
+		r.Body = &limitedBody{ReadCloser: http.MaxBytesReader(w, r.Body, limit.MaxBytes), route: routeName}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// limitedBody counts the first time a body runs into its MaxBytesReader limit.
+type limitedBody struct {
+	io.ReadCloser
+	route    string
+	exceeded bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	var tooLarge *http.MaxBytesError
+	if err != nil && !b.exceeded && errors.As(err, &tooLarge) {
+		b.exceeded = true
+		requestBodyLimitExceededTotal.WithLabelValues(b.route, "body").Inc()
+	}
+	return n, err
+}
@@ -0,0 +1,108 @@
+// api-gateway/bodylimit_test.go
+// 단위 테스트: Route별 본문 크기 제한, Content-Length 사전 거부, Streaming 모드
+
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRequestBodyLimits tests route defaults, overrides and early rejection
+func TestRequestBodyLimits(t *testing.T) {
+	router := newTestRouter(t)
+	if err := router.SetBodyLimits(map[string]BodyLimitConfig{"blog-api": {MaxBytes: 20 << 20}}); err != nil {
+		t.Fatalf("SetBodyLimits error: %v", err)
+	}
+
+	var read bool
+	handler := requestSizeLimitMiddleware(router, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		read = true
+		if _, err := io.Copy(io.Discard, r.Body); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				w.WriteHeader(http.StatusRequestEntityTooLarge)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name          string
+		path          string
+		size          int
+		contentLength int64
+		expectCode    int
+		expectRead    bool
+	}{
+		{"로그인 한도 이내", "/api/login", 1 << 10, 1 << 10, http.StatusOK, true},
+		{"로그인 Content-Length 초과는 읽기 전 거부", "/api/login", 100 << 10, 100 << 10, http.StatusRequestEntityTooLarge, false},
+		{"Chunked 본문은 읽는 중 차단", "/api/login", 100 << 10, -1, http.StatusRequestEntityTooLarge, true},
+		{"users 1MB 한도", "/api/users/alice", 2 << 20, 2 << 20, http.StatusRequestEntityTooLarge, false},
+		{"설정 파일로 늘린 blog-api 한도", "/blog/api/posts", 15 << 20, 15 << 20, http.StatusOK, true},
+		{"Route 없는 경로는 기본 10MB", "/health", 11 << 20, 11 << 20, http.StatusRequestEntityTooLarge, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			read = false
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(strings.Repeat("x", tt.size)))
+			req.ContentLength = tt.contentLength
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tt.expectCode || read != tt.expectRead {
+				t.Errorf("Status = %d, read = %v; want %d, %v", rr.Code, read, tt.expectCode, tt.expectRead)
+			}
+			if !tt.expectRead && rr.Header().Get("Content-Type") != problemContentType {
+				t.Errorf("Content-Type = %s; want %s", rr.Header().Get("Content-Type"), problemContentType)
+			}
+		})
+	}
+}
+
+// TestBodyLimitConfigErrors tests rejected body_limits entries
+func TestBodyLimitConfigErrors(t *testing.T) {
+	router := newTestRouter(t)
+	for name, cfg := range map[string]map[string]BodyLimitConfig{
+		"알 수 없는 Route": {"nope": {MaxBytes: 1}},
+		"0 바이트 한도":     {"users": {MaxBytes: 0}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if err := router.SetBodyLimits(cfg); err == nil {
+				t.Error("expected config error")
+			}
+		})
+	}
+}
+
+// TestStreamingBodyLimit tests that streaming routes outlive the server WriteTimeout
+func TestStreamingBodyLimit(t *testing.T) {
+	for _, streaming := range []bool{false, true} {
+		router := newTestRouter(t)
+		router.SetBodyLimits(map[string]BodyLimitConfig{"blog-api": {MaxBytes: 1 << 20, Streaming: streaming}})
+		srv := httptest.NewUnstartedServer(requestSizeLimitMiddleware(router, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// 느린 업로드를 흉내
+			time.Sleep(150 * time.Millisecond)
+			io.WriteString(w, "stored")
+		})))
+		srv.Config.WriteTimeout = 50 * time.Millisecond
+		srv.Start()
+
+		resp, err := http.Post(srv.URL+"/blog/api/posts/1/images", "image/png", strings.NewReader("png"))
+		ok := err == nil
+		if ok {
+			body, readErr := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			ok = readErr == nil && string(body) == "stored"
+		}
+		srv.Close()
+		if ok != streaming {
+			t.Errorf("streaming=%v: response completed = %v", streaming, ok)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+// api-gateway/canary.go
+// Canary 배포: Upstream Version 간 가중치 분배, Sticky 할당, 헤더 강제 라우팅
+
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	upstreamVersionRequestsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "upstream_version_requests_total",
+			Help: "Total number of proxied requests per upstream version",
+		},
+		[]string{"upstream", "version", "status"},
+	)
+	upstreamVersionRequestDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "upstream_version_request_duration_seconds",
+			Help:    "Duration of proxied requests per upstream version",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"upstream", "version"},
+	)
+)
+
+const (
+	defaultVersionName    = "stable"
+	defaultForceHeader    = "X-Canary"
+	upstreamVersionHeader = "X-Upstream-Version"
+)
+
+// VersionConfig is one deployment of an upstream in a traffic split.
+type VersionConfig struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Weight int    `json:"weight"`
+	// Canary marks the version selected by "X-Canary: true"
+	Canary bool `json:"canary,omitempty"`
+}
+
+// TrafficSplitConfig configures weighted routing for one upstream
+// ("traffic_splits" section of the config file, keyed by upstream name).
+type TrafficSplitConfig struct {
+	Versions []VersionConfig `json:"versions"`
+	// StickyCookie pins a client to the version it was first assigned
+	StickyCookie string `json:"sticky_cookie,omitempty"`
+	// StickyHeader assigns by hashing a request header (e.g. X-User-Id)
+	StickyHeader string `json:"sticky_header,omitempty"`
+	// ForceHeader overrides the assignment: "true"/"false" or a version name
+	ForceHeader string `json:"force_header,omitempty"`
+}
+
+type trafficSplit struct {
+	config      TrafficSplitConfig
+	versions    []*upstreamVersion
+	totalWeight int
+}
+
+// newTrafficSplit validates cfg and creates one proxy per version.
+func (u *Upstream) newTrafficSplit(cfg TrafficSplitConfig) (*trafficSplit, error) {
+	if len(cfg.Versions) == 0 {
+		return nil, fmt.Errorf("traffic split %s: no versions", u.Name)
+	}
+	if cfg.ForceHeader == "" {
+		cfg.ForceHeader = defaultForceHeader
+	}
+
+	split := &trafficSplit{config: cfg}
+	seen := map[string]bool{}
+	for _, vc := range cfg.Versions {
+		if vc.Name == "" || seen[vc.Name] {
+			return nil, fmt.Errorf("traffic split %s: version names must be unique and non-empty", u.Name)
+		}
+		seen[vc.Name] = true
+		if vc.Weight < 0 {
+			return nil, fmt.Errorf("traffic split %s/%s: negative weight", u.Name, vc.Name)
+		}
+		target, err := url.Parse(vc.URL)
+		if err != nil || target.Scheme == "" || target.Host == "" {
+			return nil, fmt.Errorf("traffic split %s/%s: invalid url %q", u.Name, vc.Name, vc.URL)
+		}
+
+		breaker := newCircuitBreaker(u.Name+"/"+vc.Name, u.breaker.threshold, u.breaker.openTimeout)
+		v := u.newVersion(vc.Name, target, breaker)
+		v.Weight = vc.Weight
+		v.Canary = vc.Canary
+		split.versions = append(split.versions, v)
+		split.totalWeight += vc.Weight
+	}
+	if split.totalWeight == 0 {
+		return nil, fmt.Errorf("traffic split %s: total weight must be positive", u.Name)
+	}
+	return split, nil
+}
+
+// SetTrafficSplit replaces the version split; nil routes everything to the primary target.
+func (u *Upstream) SetTrafficSplit(cfg *TrafficSplitConfig) error {
+	if cfg == nil {
+		u.split.Store(nil)
+		return nil
+	}
+	split, err := u.newTrafficSplit(*cfg)
+	if err != nil {
+		return err
+	}
+	u.split.Store(split)
+	return nil
+}
+
+func (s *trafficSplit) byName(name string) *upstreamVersion {
+	for _, v := range s.versions {
+		if v.Name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// forced resolves the force header: "true" -> first canary, "false" -> first
+// non-canary, otherwise a version name.
+func (s *trafficSplit) forced(value string) *upstreamVersion {
+	switch strings.ToLower(value) {
+	case "":
+		return nil
+	case "true", "false":
+		wantCanary := strings.EqualFold(value, "true")
+		for _, v := range s.versions {
+			if v.Canary == wantCanary {
+				return v
+			}
+		}
+		return nil
+	default:
+		return s.byName(value)
+	}
+}
+
+// pick maps n in [0, totalWeight) onto the weighted version list.
+func (s *trafficSplit) pick(n int) *upstreamVersion {
+	for _, v := range s.versions {
+		if n < v.Weight {
+			return v
+		}
+		n -= v.Weight
+	}
+	return s.versions[len(s.versions)-1]
+}
+
+func stickyHash(value string) int {
+	h := fnv.New32a()
+	h.Write([]byte(value))
+	return int(h.Sum32() & 0x7fffffff)
+}
+
+// selectVersion chooses the version for r: forced header, sticky cookie,
+// sticky header hash, then weighted random (remembered in the cookie).
+func (u *Upstream) selectVersion(w http.ResponseWriter, r *http.Request) *upstreamVersion {
+	split := u.split.Load()
+	if split == nil {
+		return u.primary
+	}
+
+	v := split.forced(r.Header.Get(split.config.ForceHeader))
+	if v == nil && split.config.StickyCookie != "" {
+		if c, err := r.Cookie(split.config.StickyCookie); err == nil {
+			// weight 0으로 내린 Version은 Sticky 할당도 해제
+			if pinned := split.byName(c.Value); pinned != nil && pinned.Weight > 0 {
+				v = pinned
+			}
+		}
+	}
+	if v == nil && split.config.StickyHeader != "" {
+		if key := r.Header.Get(split.config.StickyHeader); key != "" {
+			v = split.pick(stickyHash(key) % split.totalWeight)
+		}
+	}
+	if v == nil {
+		v = split.pick(rand.IntN(split.totalWeight))
+		if split.config.StickyCookie != "" {
+			http.SetCookie(w, &http.Cookie{
+				Name:     split.config.StickyCookie,
+				Value:    v.Name,
+				Path:     "/",
+				HttpOnly: true,
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+	}
+
+	w.Header().Set(upstreamVersionHeader, v.Name)
+	return v
+}
+
+// versions returns the active versions (the primary alone when no split is set).
+func (u *Upstream) versions() []*upstreamVersion {
+	if split := u.split.Load(); split != nil {
+		return split.versions
+	}
+	return []*upstreamVersion{u.primary}
+}
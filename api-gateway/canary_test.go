@@ -0,0 +1,239 @@
+// api-gateway/canary_test.go
+// 단위 테스트: Canary 가중치 분배, 강제 헤더, Sticky 할당, Version별 Circuit Breaker
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newTestSplit routes svc between a stable and a canary echo backend
+func newTestSplit(t *testing.T, stableWeight, canaryWeight int, opts TrafficSplitConfig) *Upstream {
+	t.Helper()
+	u := newTestUpstream(t, "svc", newTestBackend(t, "primary"))
+	opts.Versions = []VersionConfig{
+		{Name: "stable", URL: newTestBackend(t, "stable").URL, Weight: stableWeight},
+		{Name: "canary", URL: newTestBackend(t, "canary").URL, Weight: canaryWeight, Canary: true},
+	}
+	if err := u.SetTrafficSplit(&opts); err != nil {
+		t.Fatalf("SetTrafficSplit error: %v", err)
+	}
+	return u
+}
+
+func serveSplit(u *Upstream, req *http.Request) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	u.ServeHTTP(rr, req)
+	return rr
+}
+
+// TestTrafficSplitWeights tests weighted distribution and the version header
+func TestTrafficSplitWeights(t *testing.T) {
+	u := newTestSplit(t, 80, 20, TrafficSplitConfig{})
+
+	counts := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		rr := serveSplit(u, httptest.NewRequest(http.MethodGet, "/x", nil))
+		if rr.Header().Get("X-Backend") != rr.Header().Get(upstreamVersionHeader) {
+			t.Fatalf("X-Upstream-Version = %s; served by %s", rr.Header().Get(upstreamVersionHeader), rr.Header().Get("X-Backend"))
+		}
+		counts[rr.Header().Get("X-Backend")]++
+	}
+	if counts["canary"] < 120 || counts["canary"] > 280 {
+		t.Errorf("canary share = %d/1000; want about 200", counts["canary"])
+	}
+
+	t.Run("weight 0은 할당되지 않음", func(t *testing.T) {
+		u := newTestSplit(t, 100, 0, TrafficSplitConfig{})
+		for i := 0; i < 100; i++ {
+			if got := serveSplit(u, httptest.NewRequest(http.MethodGet, "/x", nil)).Header().Get("X-Backend"); got != "stable" {
+				t.Fatalf("X-Backend = %s; want stable", got)
+			}
+		}
+	})
+
+	t.Run("split 해제 시 기본 Upstream", func(t *testing.T) {
+		u.SetTrafficSplit(nil)
+		rr := serveSplit(u, httptest.NewRequest(http.MethodGet, "/x", nil))
+		if rr.Header().Get("X-Backend") != "primary" || rr.Header().Get(upstreamVersionHeader) != "" {
+			t.Errorf("X-Backend = %s; want primary without version header", rr.Header().Get("X-Backend"))
+		}
+	})
+}
+
+// TestTrafficSplitForceHeader tests X-Canary overrides
+func TestTrafficSplitForceHeader(t *testing.T) {
+	u := newTestSplit(t, 50, 50, TrafficSplitConfig{})
+
+	tests := []struct {
+		value   string
+		backend string
+	}{
+		{"true", "canary"},
+		{"false", "stable"},
+		{"stable", "stable"},
+		{"canary", "canary"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			for i := 0; i < 20; i++ {
+				req := httptest.NewRequest(http.MethodGet, "/x", nil)
+				req.Header.Set(defaultForceHeader, tt.value)
+				if got := serveSplit(u, req).Header().Get("X-Backend"); got != tt.backend {
+					t.Fatalf("X-Backend = %s; want %s", got, tt.backend)
+				}
+			}
+		})
+	}
+}
+
+// TestTrafficSplitSticky tests cookie and header based assignment
+func TestTrafficSplitSticky(t *testing.T) {
+	t.Run("Cookie 설정 후 유지", func(t *testing.T) {
+		u := newTestSplit(t, 50, 50, TrafficSplitConfig{StickyCookie: "svc_version"})
+		rr := serveSplit(u, httptest.NewRequest(http.MethodGet, "/x", nil))
+		cookies := rr.Result().Cookies()
+		if len(cookies) != 1 || cookies[0].Value != rr.Header().Get("X-Backend") {
+			t.Fatalf("cookies = %v; want svc_version=%s", cookies, rr.Header().Get("X-Backend"))
+		}
+
+		for i := 0; i < 20; i++ {
+			req := httptest.NewRequest(http.MethodGet, "/x", nil)
+			req.AddCookie(cookies[0])
+			next := serveSplit(u, req)
+			if next.Header().Get("X-Backend") != cookies[0].Value {
+				t.Fatalf("X-Backend = %s; want pinned %s", next.Header().Get("X-Backend"), cookies[0].Value)
+			}
+			if len(next.Result().Cookies()) != 0 {
+				t.Fatal("pinned request should not reissue the cookie")
+			}
+		}
+	})
+
+	t.Run("weight 0 Version의 Cookie는 무시", func(t *testing.T) {
+		u := newTestSplit(t, 100, 0, TrafficSplitConfig{StickyCookie: "svc_version"})
+		req := httptest.NewRequest(http.MethodGet, "/x", nil)
+		req.AddCookie(&http.Cookie{Name: "svc_version", Value: "canary"})
+		if got := serveSplit(u, req).Header().Get("X-Backend"); got != "stable" {
+			t.Errorf("X-Backend = %s; want stable", got)
+		}
+	})
+
+	t.Run("헤더 해시는 결정적", func(t *testing.T) {
+		u := newTestSplit(t, 50, 50, TrafficSplitConfig{StickyHeader: "X-User-Id"})
+		seen := map[string]bool{}
+		for _, user := range []string{"alice", "bob", "carol", "dave", "erin", "frank"} {
+			var first string
+			for i := 0; i < 10; i++ {
+				req := httptest.NewRequest(http.MethodGet, "/x", nil)
+				req.Header.Set("X-User-Id", user)
+				got := serveSplit(u, req).Header().Get("X-Backend")
+				if first == "" {
+					first = got
+				} else if got != first {
+					t.Fatalf("user %s moved from %s to %s", user, first, got)
+				}
+			}
+			seen[first] = true
+		}
+		if len(seen) != 2 {
+			t.Errorf("versions used = %v; want both", seen)
+		}
+	})
+}
+
+// TestTrafficSplitValidation tests rejected configs
+func TestTrafficSplitValidation(t *testing.T) {
+	u := newTestUpstream(t, "svc", newTestBackend(t, "primary"))
+
+	tests := []struct {
+		name string
+		cfg  TrafficSplitConfig
+	}{
+		{"Version 없음", TrafficSplitConfig{}},
+		{"이름 중복", TrafficSplitConfig{Versions: []VersionConfig{{Name: "a", URL: "http://a", Weight: 1}, {Name: "a", URL: "http://b", Weight: 1}}}},
+		{"음수 weight", TrafficSplitConfig{Versions: []VersionConfig{{Name: "a", URL: "http://a", Weight: -1}}}},
+		{"weight 합계 0", TrafficSplitConfig{Versions: []VersionConfig{{Name: "a", URL: "http://a"}}}},
+		{"잘못된 URL", TrafficSplitConfig{Versions: []VersionConfig{{Name: "a", URL: "not a url", Weight: 1}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := u.SetTrafficSplit(&tt.cfg); err == nil {
+				t.Error("expected validation error")
+			}
+		})
+	}
+	if u.split.Load() != nil {
+		t.Error("rejected config should not be applied")
+	}
+}
+
+// TestTrafficSplitBreakerIsolation tests that a failing canary does not trip stable
+func TestTrafficSplitBreakerIsolation(t *testing.T) {
+	u := newTestUpstream(t, "svc", newTestBackend(t, "primary"))
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	u.SetTrafficSplit(&TrafficSplitConfig{Versions: []VersionConfig{
+		{Name: "stable", URL: newTestBackend(t, "stable").URL, Weight: 1},
+		{Name: "canary", URL: failing.URL, Weight: 1, Canary: true},
+	}})
+
+	forced := func(value string) int {
+		req := httptest.NewRequest(http.MethodGet, "/x", nil)
+		req.Header.Set(defaultForceHeader, value)
+		return serveSplit(u, req).Code
+	}
+	for i := 0; i < 3; i++ {
+		forced("true")
+	}
+	if code := forced("true"); code != http.StatusServiceUnavailable {
+		t.Errorf("canary Status = %d; want 503 from open breaker", code)
+	}
+	if code := forced("false"); code != http.StatusOK {
+		t.Errorf("stable Status = %d; want 200", code)
+	}
+	if u.breaker.State() != breakerClosed {
+		t.Errorf("upstream breaker = %s; want closed", u.breaker.State())
+	}
+}
+
+// TestReloadTrafficSplits tests the traffic_splits config file section
+func TestReloadTrafficSplits(t *testing.T) {
+	router := newTestRouter(t)
+	dir := t.TempDir()
+	canary := newTestBackend(t, "canary")
+
+	valid := filepath.Join(dir, "gateway.json")
+	os.WriteFile(valid, []byte(`{"traffic_splits": {"blog-service": {"versions": [
+		{"name": "canary", "url": "`+canary.URL+`", "weight": 1, "canary": true}]}}}`), 0o644)
+	if err := reloadConfigFile(valid, router); err != nil {
+		t.Fatalf("reloadConfigFile error: %v", err)
+	}
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/blog/api/posts", nil))
+	if rr.Header().Get("X-Backend") != "canary" {
+		t.Errorf("X-Backend = %s; want canary", rr.Header().Get("X-Backend"))
+	}
+
+	unknown := filepath.Join(dir, "unknown.json")
+	os.WriteFile(unknown, []byte(`{"traffic_splits": {"nope": {"versions": []}}}`), 0o644)
+	if err := reloadConfigFile(unknown, router); err == nil {
+		t.Error("unknown upstream should be rejected")
+	}
+	if router.Upstream("blog-service").split.Load() == nil {
+		t.Error("failed reload should keep the previous split")
+	}
+
+	empty := filepath.Join(dir, "empty.json")
+	os.WriteFile(empty, []byte(`{}`), 0o644)
+	reloadConfigFile(empty, router)
+	if router.Upstream("blog-service").split.Load() != nil {
+		t.Error("removed split should fall back to the primary target")
+	}
+}
@@ -0,0 +1,253 @@
+// api-gateway/cmd/replay/main.go
+// Capture 재생 도구: 기록된 요청을 대상 Gateway로 다시 보내고 상태 코드/JSON 본문 차이를 보고
+
+// Command replay sends the requests of gateway capture files to a target
+// gateway and diffs the responses against the recorded ones.
+//
+//	go run ./cmd/replay -target http://localhost:8000 /var/lib/gateway/captures/capture-*.jsonl
+//
+// Redacted headers are not replayed; use -header to supply test credentials.
+// The exit status is 1 when any response differs.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"titanium-api-go/recording"
+)
+
+type headerFlags []string
+
+func (h *headerFlags) String() string     { return strings.Join(*h, ", ") }
+func (h *headerFlags) Set(v string) error { *h = append(*h, v); return nil }
+
+type replayer struct {
+	target  string
+	client  *http.Client
+	headers http.Header
+	// ignore lists JSON fields (by key, at any depth) left out of the diff
+	ignore map[string]bool
+	out    io.Writer
+}
+
+// result is the outcome of replaying one record.
+type result struct {
+	Status int
+	Diffs  []string
+	Err    error
+}
+
+func main() {
+	target := flag.String("target", "http://localhost:8000", "gateway base URL to replay against")
+	timeout := flag.Duration("timeout", 10*time.Second, "per-request timeout")
+	ignore := flag.String("ignore", "", "comma-separated JSON fields to ignore (e.g. created_at,id)")
+	var headers headerFlags
+	flag.Var(&headers, "header", `extra request header "Name: value" (repeatable)`)
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		log.Fatal("usage: replay [-target URL] [-header 'Name: value'] capture.jsonl...")
+	}
+
+	r := &replayer{
+		target:  strings.TrimSuffix(*target, "/"),
+		client:  &http.Client{Timeout: *timeout},
+		headers: http.Header{},
+		ignore:  map[string]bool{},
+		out:     os.Stdout,
+	}
+	for _, h := range headers {
+		name, value, ok := strings.Cut(h, ":")
+		if !ok {
+			log.Fatalf("invalid -header %q", h)
+		}
+		r.headers.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	for _, field := range strings.Split(*ignore, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			r.ignore[field] = true
+		}
+	}
+
+	total, failed := 0, 0
+	for _, path := range flag.Args() {
+		f, err := os.Open(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		err = recording.Read(f, func(rec *recording.Record) error {
+			total++
+			if !r.report(rec, r.replay(rec)) {
+				failed++
+			}
+			return nil
+		})
+		f.Close()
+		if err != nil {
+			log.Fatalf("%s: %v", path, err)
+		}
+	}
+
+	fmt.Fprintf(r.out, "\n%d replayed, %d differed\n", total, failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// replay sends rec.Request to the target and diffs the response.
+func (r *replayer) replay(rec *recording.Record) result {
+	body, err := rec.Request.BodyBytes()
+	if err != nil {
+		return result{Err: err}
+	}
+	if rec.Request.Truncated {
+		return result{Err: fmt.Errorf("request body was not captured (truncated)")}
+	}
+	req, err := http.NewRequest(rec.Request.Method, r.target+rec.Request.URL, bytes.NewReader(body))
+	if err != nil {
+		return result{Err: err}
+	}
+	for name, values := range rec.Request.Header {
+		for _, v := range values {
+			if v != recording.Redacted {
+				req.Header.Add(name, v)
+			}
+		}
+	}
+	for name, values := range r.headers {
+		req.Header[name] = values
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return result{Err: err}
+	}
+	defer resp.Body.Close()
+	got, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return result{Err: err}
+	}
+
+	res := result{Status: resp.StatusCode}
+	if resp.StatusCode != rec.Response.Status {
+		res.Diffs = append(res.Diffs, fmt.Sprintf("status: recorded %d, got %d", rec.Response.Status, resp.StatusCode))
+	}
+	if !rec.Response.Truncated {
+		want, _ := rec.Response.BodyBytes()
+		res.Diffs = append(res.Diffs, r.diffBodies(want, got)...)
+	}
+	return res
+}
+
+// diffBodies compares JSON bodies field by field and other bodies byte for byte.
+func (r *replayer) diffBodies(want, got []byte) []string {
+	var wantJSON, gotJSON interface{}
+	if json.Unmarshal(want, &wantJSON) != nil || json.Unmarshal(got, &gotJSON) != nil {
+		if !bytes.Equal(want, got) {
+			return []string{fmt.Sprintf("body: recorded %d bytes, got %d bytes", len(want), len(got))}
+		}
+		return nil
+	}
+	var diffs []string
+	r.diffJSON("$", wantJSON, gotJSON, &diffs)
+	return diffs
+}
+
+func (r *replayer) diffJSON(path string, want, got interface{}, diffs *[]string) {
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			*diffs = append(*diffs, fmt.Sprintf("%s: recorded object, got %s", path, jsonType(got)))
+			return
+		}
+		keys := map[string]bool{}
+		for k := range w {
+			keys[k] = true
+		}
+		for k := range g {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		for _, k := range sorted {
+			if r.ignore[k] {
+				continue
+			}
+			wv, wok := w[k]
+			gv, gok := g[k]
+			switch {
+			case !gok:
+				*diffs = append(*diffs, fmt.Sprintf("%s.%s: missing", path, k))
+			case !wok:
+				*diffs = append(*diffs, fmt.Sprintf("%s.%s: unexpected", path, k))
+			case wv == recording.Redacted:
+				// 기록 시 마스킹된 값은 비교하지 않음
+			default:
+				r.diffJSON(path+"."+k, wv, gv, diffs)
+			}
+		}
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok {
+			*diffs = append(*diffs, fmt.Sprintf("%s: recorded array, got %s", path, jsonType(got)))
+			return
+		}
+		if len(w) != len(g) {
+			*diffs = append(*diffs, fmt.Sprintf("%s: recorded %d items, got %d", path, len(w), len(g)))
+			return
+		}
+		for i := range w {
+			r.diffJSON(fmt.Sprintf("%s[%d]", path, i), w[i], g[i], diffs)
+		}
+	default:
+		if want != got {
+			*diffs = append(*diffs, fmt.Sprintf("%s: recorded %v, got %v", path, want, got))
+		}
+	}
+}
+
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// report prints one line per record and reports whether it matched.
+func (r *replayer) report(rec *recording.Record, res result) bool {
+	name := rec.Request.Method + " " + rec.Request.URL
+	switch {
+	case res.Err != nil:
+		fmt.Fprintf(r.out, "ERROR %s: %v\n", name, res.Err)
+		return false
+	case len(res.Diffs) > 0:
+		fmt.Fprintf(r.out, "DIFF  %s\n", name)
+		for _, d := range res.Diffs {
+			fmt.Fprintf(r.out, "      %s\n", d)
+		}
+		return false
+	default:
+		fmt.Fprintf(r.out, "OK    %s (%d)\n", name, res.Status)
+		return true
+	}
+}
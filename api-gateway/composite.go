@@ -0,0 +1,566 @@
+// api-gateway/composite.go
+// Composite API: 설정된 엔드포인트가 여러 Upstream 호출을 병렬로 실행하고 JSON 응답을 매핑 규칙으로 병합 (필수/선택 Part)
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	compositeRequestsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "composite_requests_total",
+			Help: "Total number of composite endpoint requests by result (ok, partial, failed)",
+		},
+		[]string{"composite", "result"},
+	)
+	compositePartErrorsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "composite_part_errors_total",
+			Help: "Total number of failed composite part calls",
+		},
+		[]string{"composite", "part"},
+	)
+)
+
+const (
+	// compositePartialHeader lists the optional parts that failed
+	compositePartialHeader = "Composite-Partial"
+	// compositeMaxPartBytes caps one part's response body
+	compositeMaxPartBytes = 1 << 20
+	defaultPartTimeout    = 5 * time.Second
+)
+
+var (
+	compositeNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	// pathWildcard matches {name} and {name...} in a composite path
+	pathWildcard = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)(?:\.\.\.)?\}`)
+	// partTemplate matches {param} and {part.field[0]} in a part path
+	partTemplate  = regexp.MustCompile(`\{([^{}]+)\}`)
+	jsonPathToken = regexp.MustCompile(`^(?:\.([A-Za-z0-9_-]+)|\[([0-9]+)\])`)
+)
+
+// CompositePartConfig is one upstream call of a composite endpoint.
+type CompositePartConfig struct {
+	Name     string `json:"name"`
+	Upstream string `json:"upstream"`
+	// Path is the upstream path (and query). {param} is a wildcard of the
+	// composite path; {part.field} is read from another part's response,
+	// which makes this part wait for that one
+	Path string `json:"path"`
+	// Required parts fail the whole response; optional ones become null
+	Required bool         `json:"required,omitempty"`
+	Timeout  jsonDuration `json:"timeout,omitempty"`
+}
+
+// CompositeConfig is one entry of the "composites" section of the config
+// file: a GET endpoint served by the gateway from several upstream calls.
+type CompositeConfig struct {
+	Name string `json:"name"`
+	// Path is a ServeMux pattern under /api/ or /blog/ ("/api/composite/posts/{id}")
+	Path  string                `json:"path"`
+	Parts []CompositePartConfig `json:"parts"`
+	// Response maps output fields (dotted for nesting) to JSONPath-style
+	// expressions over the part responses ("$.post.title"). The "$" field
+	// is an object the other fields are merged into. Empty: one field per part
+	Response map[string]string `json:"response,omitempty"`
+}
+
+// jsonPath is a parsed $.a.b[0] expression.
+type jsonPath []any // string keys and int indexes
+
+func parseJSONPath(expr string) (jsonPath, error) {
+	rest, ok := strings.CutPrefix(expr, "$")
+	if !ok {
+		return nil, fmt.Errorf("expression %q must start with $", expr)
+	}
+	var path jsonPath
+	for rest != "" {
+		m := jsonPathToken.FindStringSubmatch(rest)
+		if m == nil {
+			return nil, fmt.Errorf("invalid expression %q at %q", expr, rest)
+		}
+		if m[1] != "" {
+			path = append(path, m[1])
+		} else {
+			i, _ := strconv.Atoi(m[2])
+			path = append(path, i)
+		}
+		rest = rest[len(m[0]):]
+	}
+	return path, nil
+}
+
+// eval walks v along p.
+func (p jsonPath) eval(v any) (any, bool) {
+	for _, step := range p {
+		switch step := step.(type) {
+		case string:
+			m, ok := v.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+			if v, ok = m[step]; !ok {
+				return nil, false
+			}
+		case int:
+			a, ok := v.([]any)
+			if !ok || step >= len(a) {
+				return nil, false
+			}
+			v = a[step]
+		}
+	}
+	return v, true
+}
+
+// part returns the part name an expression starts from.
+func (p jsonPath) part() string {
+	if len(p) == 0 {
+		return ""
+	}
+	name, _ := p[0].(string)
+	return name
+}
+
+type compositePart struct {
+	CompositePartConfig
+	deps []int
+	// refs are the {part.field} templates of Path
+	refs map[string]jsonPath
+}
+
+type compositeField struct {
+	key  []string
+	expr jsonPath
+}
+
+type compositeEndpoint struct {
+	name   string
+	router *Router
+	parts  []*compositePart
+	base   jsonPath
+	fields []compositeField
+}
+
+// compositeSet routes composite paths with a ServeMux.
+type compositeSet struct {
+	mux *http.ServeMux
+}
+
+func (rt *Router) newCompositeSet(configs []CompositeConfig) (*compositeSet, error) {
+	set := &compositeSet{mux: http.NewServeMux()}
+	names := map[string]bool{}
+	for _, cfg := range configs {
+		c, err := rt.newCompositeEndpoint(cfg)
+		if err != nil {
+			return nil, err
+		}
+		if names[cfg.Name] {
+			return nil, fmt.Errorf("composites: duplicate name %q", cfg.Name)
+		}
+		names[cfg.Name] = true
+		if err := handleSafely(set.mux, http.MethodGet+" "+cfg.Path, c); err != nil {
+			return nil, fmt.Errorf("composite %s: %w", cfg.Name, err)
+		}
+	}
+	return set, nil
+}
+
+// handleSafely registers a pattern, turning ServeMux's panic on invalid or
+// conflicting patterns into an error.
+func handleSafely(mux *http.ServeMux, pattern string, h http.Handler) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("%v", p)
+		}
+	}()
+	mux.Handle(pattern, h)
+	return nil
+}
+
+func (rt *Router) newCompositeEndpoint(cfg CompositeConfig) (*compositeEndpoint, error) {
+	if !compositeNamePattern.MatchString(cfg.Name) {
+		return nil, fmt.Errorf("composites: invalid name %q", cfg.Name)
+	}
+	if !strings.HasPrefix(cfg.Path, "/api/") && !strings.HasPrefix(cfg.Path, "/blog/") {
+		return nil, fmt.Errorf("composite %s: path must be under /api/ or /blog/", cfg.Name)
+	}
+	if len(cfg.Parts) == 0 {
+		return nil, fmt.Errorf("composite %s: no parts", cfg.Name)
+	}
+	params := map[string]bool{}
+	for _, m := range pathWildcard.FindAllStringSubmatch(cfg.Path, -1) {
+		params[m[1]] = true
+	}
+
+	c := &compositeEndpoint{name: cfg.Name, router: rt}
+	index := map[string]int{}
+	for i, p := range cfg.Parts {
+		if !compositeNamePattern.MatchString(p.Name) {
+			return nil, fmt.Errorf("composite %s: invalid part name %q", cfg.Name, p.Name)
+		}
+		if _, dup := index[p.Name]; dup {
+			return nil, fmt.Errorf("composite %s: duplicate part %q", cfg.Name, p.Name)
+		}
+		index[p.Name] = i
+	}
+	for _, p := range cfg.Parts {
+		if rt.Upstream(p.Upstream) == nil {
+			return nil, fmt.Errorf("composite %s/%s: unknown upstream %q", cfg.Name, p.Name, p.Upstream)
+		}
+		if !strings.HasPrefix(p.Path, "/") {
+			return nil, fmt.Errorf("composite %s/%s: path must start with /", cfg.Name, p.Name)
+		}
+		if p.Timeout < 0 {
+			return nil, fmt.Errorf("composite %s/%s: timeout must not be negative", cfg.Name, p.Name)
+		}
+		if p.Timeout == 0 {
+			p.Timeout = jsonDuration(defaultPartTimeout)
+		}
+		part := &compositePart{CompositePartConfig: p, refs: map[string]jsonPath{}}
+		for _, m := range partTemplate.FindAllStringSubmatch(p.Path, -1) {
+			if params[m[1]] {
+				continue
+			}
+			ref, err := parseJSONPath("$." + m[1])
+			if err != nil || len(ref) < 2 {
+				return nil, fmt.Errorf("composite %s/%s: {%s} is neither a path wildcard nor a part field", cfg.Name, p.Name, m[1])
+			}
+			dep, ok := index[ref.part()]
+			if !ok {
+				return nil, fmt.Errorf("composite %s/%s: {%s} refers to an unknown part", cfg.Name, p.Name, m[1])
+			}
+			part.refs[m[1]] = ref
+			if !slices.Contains(part.deps, dep) {
+				part.deps = append(part.deps, dep)
+			}
+		}
+		c.parts = append(c.parts, part)
+	}
+	if err := c.checkCycles(); err != nil {
+		return nil, err
+	}
+
+	for key, expr := range cfg.Response {
+		path, err := parseJSONPath(expr)
+		if err != nil {
+			return nil, fmt.Errorf("composite %s response %s: %w", cfg.Name, key, err)
+		}
+		if _, ok := index[path.part()]; !ok {
+			return nil, fmt.Errorf("composite %s response %s: %q does not start with a part name", cfg.Name, key, expr)
+		}
+		if key == "$" {
+			c.base = path
+			continue
+		}
+		keys := strings.Split(key, ".")
+		if slices.Contains(keys, "") {
+			return nil, fmt.Errorf("composite %s: invalid response field %q", cfg.Name, key)
+		}
+		c.fields = append(c.fields, compositeField{key: keys, expr: path})
+	}
+	// 중첩 필드가 상위 필드를 덮어쓰도록 짧은 Key부터 적용
+	slices.SortFunc(c.fields, func(a, b compositeField) int {
+		return strings.Compare(strings.Join(a.key, "."), strings.Join(b.key, "."))
+	})
+	return c, nil
+}
+
+// checkCycles rejects parts that (indirectly) wait for themselves.
+func (c *compositeEndpoint) checkCycles() error {
+	state := make([]int, len(c.parts)) // 0 unvisited, 1 visiting, 2 done
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case 1:
+			return fmt.Errorf("composite %s: part %s depends on itself", c.name, c.parts[i].Name)
+		case 2:
+			return nil
+		}
+		state[i] = 1
+		for _, dep := range c.parts[i].deps {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		state[i] = 2
+		return nil
+	}
+	for i := range c.parts {
+		if err := visit(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetComposites replaces the composite endpoints.
+func (rt *Router) SetComposites(configs []CompositeConfig) error {
+	set, err := rt.newCompositeSet(configs)
+	if err != nil {
+		return err
+	}
+	rt.composites.Store(set)
+	return nil
+}
+
+// serveComposite serves r if it matches a composite endpoint.
+func (rt *Router) serveComposite(w http.ResponseWriter, r *http.Request) bool {
+	set := rt.composites.Load()
+	if set == nil {
+		return false
+	}
+	if _, pattern := set.mux.Handler(r); pattern == "" {
+		return false
+	}
+	// Upstream별 점검은 Part 단위로 getUpstreamJSON에서 처리
+	if m := currentMaintenance.Load(); m.config.Enabled && !m.bypassed(r) {
+		m.serve(w, r, nil)
+		return true
+	}
+	set.mux.ServeHTTP(w, r)
+	return true
+}
+
+type partResult struct {
+	body   any
+	status int
+	err    error
+}
+
+func (c *compositeEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	results := make([]partResult, len(c.parts))
+	done := make([]chan struct{}, len(c.parts))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	var wg sync.WaitGroup
+	for i, p := range c.parts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(done[i])
+			for _, dep := range p.deps {
+				<-done[dep]
+				if results[dep].err != nil {
+					results[i].err = fmt.Errorf("depends on failed part %s", c.parts[dep].Name)
+					return
+				}
+			}
+			results[i] = c.call(r, p, results)
+		}()
+	}
+	wg.Wait()
+
+	data := map[string]any{}
+	var partial []string
+	for i, p := range c.parts {
+		res := results[i]
+		if res.err == nil {
+			data[p.Name] = res.body
+			continue
+		}
+		compositePartErrorsTotal.WithLabelValues(c.name, p.Name).Inc()
+		if !p.Required {
+			partial = append(partial, p.Name)
+			continue
+		}
+		compositeRequestsTotal.WithLabelValues(c.name, "failed").Inc()
+		if errors.Is(res.err, errUpstreamMaintenance) {
+			currentMaintenance.Load().serve(w, r, nil)
+			return
+		}
+		status := http.StatusBadGateway
+		if res.status >= 400 && res.status < 500 {
+			// 필수 Part의 404/401/403은 클라이언트에게 그대로 전달
+			status = res.status
+		}
+		writeError(w, r, status, fmt.Sprintf("Composite part %s failed: %v", p.Name, res.err))
+		return
+	}
+
+	result := "ok"
+	if len(partial) > 0 {
+		result = "partial"
+		w.Header().Set(compositePartialHeader, strings.Join(partial, ", "))
+	}
+	compositeRequestsTotal.WithLabelValues(c.name, result).Inc()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c.render(data))
+}
+
+// render builds the response from the part bodies; fields whose part failed
+// or whose path is missing are null.
+func (c *compositeEndpoint) render(data map[string]any) map[string]any {
+	out := map[string]any{}
+	if c.fields == nil && c.base == nil {
+		for _, p := range c.parts {
+			out[p.Name] = data[p.Name]
+		}
+		return out
+	}
+	if c.base != nil {
+		if base, ok := c.base.eval(data); ok {
+			if m, ok := base.(map[string]any); ok {
+				maps.Copy(out, m)
+			}
+		}
+	}
+	for _, f := range c.fields {
+		v, _ := f.expr.eval(data)
+		m := out
+		for _, k := range f.key[:len(f.key)-1] {
+			next, ok := m[k].(map[string]any)
+			if !ok {
+				next = map[string]any{}
+				m[k] = next
+			}
+			m = next
+		}
+		m[f.key[len(f.key)-1]] = v
+	}
+	return out
+}
+
+// call makes one part's upstream request with the client's credentials.
+func (c *compositeEndpoint) call(r *http.Request, p *compositePart, results []partResult) partResult {
+	deps := map[string]any{}
+	for _, d := range p.deps {
+		deps[c.parts[d].Name] = results[d].body
+	}
+	var expandErr error
+	target := partTemplate.ReplaceAllStringFunc(p.Path, func(m string) string {
+		name := m[1 : len(m)-1]
+		ref, ok := p.refs[name]
+		if !ok {
+			return url.PathEscape(r.PathValue(name))
+		}
+		v, ok := ref.eval(deps)
+		s, scalar := templateScalar(v)
+		if !ok || !scalar {
+			expandErr = fmt.Errorf("{%s} is missing from the response", name)
+		}
+		return url.PathEscape(s)
+	})
+	if expandErr != nil {
+		return partResult{err: expandErr}
+	}
+	body, status, err := getUpstreamJSON(r, c.router.Upstream(p.Upstream), target, time.Duration(p.Timeout))
+	return partResult{body: body, status: status, err: err}
+}
+
+// getUpstreamJSON makes a GET request for target (path and query) to
+// upstream on behalf of r, keeping the client's credentials, and decodes the
+// JSON response. Non-2xx responses are errors carrying the status; an
+// upstream in maintenance is not called and fails with errUpstreamMaintenance.
+func getUpstreamJSON(r *http.Request, upstream *Upstream, target string, timeout time.Duration) (any, int, error) {
+	if m := currentMaintenance.Load(); m.appliesToUpstream(upstream.Name) && !m.bypassed(r) {
+		return nil, http.StatusServiceUnavailable, fmt.Errorf("%s: %w", upstream.Name, errUpstreamMaintenance)
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	req := r.Clone(ctx)
+	req.Method = http.MethodGet
+	req.URL.Path, req.URL.RawPath, req.URL.RawQuery = u.Path, "", u.RawQuery
+	req.Body, req.ContentLength, req.RequestURI = http.NoBody, 0, ""
+	// 응답을 직접 파싱하므로 압축/조건부 요청 헤더는 전달하지 않음
+	for _, h := range []string{"Accept-Encoding", "If-None-Match", "If-Modified-Since", "Range", "Content-Type"} {
+		req.Header.Del(h)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	buf := &partResponse{bufferedResponse: bufferedResponse{header: http.Header{}}, limit: compositeMaxPartBytes}
+	if err := serveBuffered(upstream, buf, req); err != nil {
+		return nil, buf.status, err
+	}
+	if buf.status == 0 {
+		buf.status = http.StatusOK
+	}
+	if buf.status < 200 || buf.status >= 300 {
+		return nil, buf.status, fmt.Errorf("%s returned %d", upstream.Name, buf.status)
+	}
+	var body any
+	if err := json.Unmarshal(buf.body.Bytes(), &body); err != nil {
+		return nil, buf.status, fmt.Errorf("invalid JSON from %s: %w", upstream.Name, err)
+	}
+	return body, buf.status, nil
+}
+
+var (
+	// errResponseTooLarge is returned by partResponse.Write past its limit.
+	errResponseTooLarge = errors.New("response too large")
+	// errUpstreamMaintenance fails a part whose upstream is in maintenance.
+	errUpstreamMaintenance = errors.New("upstream under maintenance")
+)
+
+// partResponse buffers one part's response up to limit bytes; writes past it
+// fail and set tooLarge.
+type partResponse struct {
+	bufferedResponse
+	limit    int
+	tooLarge bool
+}
+
+func (b *partResponse) Write(p []byte) (int, error) {
+	b.WriteHeader(http.StatusOK)
+	if b.body.Len()+len(p) > b.limit {
+		b.tooLarge = true
+		return 0, errResponseTooLarge
+	}
+	return b.body.Write(p)
+}
+
+// serveBuffered proxies req into buf. ReverseProxy aborts with
+// http.ErrAbortHandler when the body cannot be copied (over buf's limit, or
+// the upstream cut it short); that becomes an error here, since the callers
+// run in their own goroutines where the panic would take down the process.
+func serveBuffered(upstream *Upstream, buf *partResponse, req *http.Request) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			if p != http.ErrAbortHandler {
+				panic(p)
+			}
+			err = fmt.Errorf("response from %s aborted", upstream.Name)
+		}
+		if buf.tooLarge {
+			err = errResponseTooLarge
+		}
+	}()
+	upstream.ServeHTTP(buf, req)
+	return nil
+}
+
+// templateScalar formats a JSON scalar for a path segment.
+func templateScalar(v any) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, v != ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	}
+	return "", false
+}
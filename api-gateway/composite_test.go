@@ -0,0 +1,315 @@
+// api-gateway/composite_test.go
+// 단위 테스트: Composite API 병렬 호출, Part 간 의존, 응답 매핑, 필수/선택 Part 실패 처리
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type compositeTestEnv struct {
+	router   *Router
+	userDown atomic.Bool
+	auth     atomic.Value
+}
+
+// newCompositeTestEnv serves post 1 by alice and post 2 by ghost (an unknown
+// user) from blog-service, and users from user-service
+func newCompositeTestEnv(t *testing.T, configs []CompositeConfig) *compositeTestEnv {
+	t.Helper()
+	env := &compositeTestEnv{}
+	blog := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		env.auth.Store(r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/blog/api/posts/1":
+			fmt.Fprint(w, `{"id": 1, "title": "Hello", "author": "alice", "tags": ["go", "k8s"]}`)
+		case "/blog/api/posts/2":
+			fmt.Fprint(w, `{"id": 2, "title": "Orphan", "author": "ghost"}`)
+		case "/blog/api/categories":
+			fmt.Fprint(w, `[{"id": 1, "name": "Tech"}]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"detail": "Post not found"}`)
+		}
+	}))
+	users := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if env.userDown.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if r.URL.Path != "/users/alice" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `{"username": "alice", "email": "alice@example.com"}`)
+	}))
+	t.Cleanup(blog.Close)
+	t.Cleanup(users.Close)
+
+	upstreams := map[string]*Upstream{
+		"blog-service": newTestUpstream(t, "blog-service", blog),
+		"user-service": newTestUpstream(t, "user-service", users),
+		"auth-service": newTestUpstream(t, "auth-service", users),
+	}
+	env.router = NewRouter(defaultRoutes(), upstreams, nil)
+	if err := env.router.SetComposites(configs); err != nil {
+		t.Fatalf("SetComposites error: %v", err)
+	}
+	return env
+}
+
+func (e *compositeTestEnv) get(t *testing.T, path string) (*httptest.ResponseRecorder, map[string]any) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("Authorization", "Bearer token-1")
+	rr := httptest.NewRecorder()
+	e.router.ServeHTTP(rr, req)
+	var body map[string]any
+	json.Unmarshal(rr.Body.Bytes(), &body)
+	return rr, body
+}
+
+var postPageComposite = CompositeConfig{
+	Name: "post-page",
+	Path: "/api/composite/posts/{id}",
+	Parts: []CompositePartConfig{
+		{Name: "post", Upstream: "blog-service", Path: "/blog/api/posts/{id}", Required: true},
+		{Name: "author", Upstream: "user-service", Path: "/users/{post.author}"},
+		{Name: "categories", Upstream: "blog-service", Path: "/blog/api/categories"},
+	},
+	Response: map[string]string{
+		"$":                "$.post",
+		"author_profile":   "$.author",
+		"meta.first_tag":   "$.post.tags[0]",
+		"meta.category":    "$.categories[0].name",
+		"meta.author_mail": "$.author.email",
+	},
+}
+
+// TestCompositeEndpoint tests fan-out, dependent parts and the response mapping
+func TestCompositeEndpoint(t *testing.T) {
+	env := newCompositeTestEnv(t, []CompositeConfig{postPageComposite})
+
+	rr, body := env.get(t, "/api/composite/posts/1")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Status = %d; want 200 (%s)", rr.Code, rr.Body.String())
+	}
+	want := `{"author":"alice","author_profile":{"email":"alice@example.com","username":"alice"},"id":1,"meta":{"author_mail":"alice@example.com","category":"Tech","first_tag":"go"},"tags":["go","k8s"],"title":"Hello"}`
+	if got, _ := json.Marshal(body); string(got) != want {
+		t.Errorf("body = %s; want %s", got, want)
+	}
+	if rr.Header().Get(compositePartialHeader) != "" {
+		t.Errorf("unexpected %s: %s", compositePartialHeader, rr.Header().Get(compositePartialHeader))
+	}
+	if got, _ := env.auth.Load().(string); got != "Bearer token-1" {
+		t.Errorf("part Authorization = %q; want the client's", got)
+	}
+
+	t.Run("매핑 없으면 Part별 필드", func(t *testing.T) {
+		cfg := postPageComposite
+		cfg.Response = nil
+		env := newCompositeTestEnv(t, []CompositeConfig{cfg})
+		_, body := env.get(t, "/api/composite/posts/1")
+		if post, _ := body["post"].(map[string]any); post["title"] != "Hello" || body["author"] == nil || body["categories"] == nil {
+			t.Errorf("body = %v; want post, author and categories", body)
+		}
+	})
+
+	t.Run("Composite가 아닌 경로는 Route로", func(t *testing.T) {
+		rr, _ := env.get(t, "/blog/api/posts/1")
+		if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"author": "alice"`) {
+			t.Errorf("route = %d %s", rr.Code, rr.Body.String())
+		}
+	})
+}
+
+// TestCompositePartialFailures tests required and optional part failures
+func TestCompositePartialFailures(t *testing.T) {
+	tests := []struct {
+		name          string
+		path          string
+		userDown      bool
+		expectCode    int
+		expectPartial string
+	}{
+		{"선택 Part 5xx는 null", "/api/composite/posts/1", true, http.StatusOK, "author"},
+		{"선택 Part 404는 null", "/api/composite/posts/2", false, http.StatusOK, "author"},
+		{"필수 Part 404는 그대로", "/api/composite/posts/9", false, http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newCompositeTestEnv(t, []CompositeConfig{postPageComposite})
+			env.userDown.Store(tt.userDown)
+			rr, body := env.get(t, tt.path)
+			if rr.Code != tt.expectCode {
+				t.Fatalf("Status = %d; want %d (%s)", rr.Code, tt.expectCode, rr.Body.String())
+			}
+			if got := rr.Header().Get(compositePartialHeader); got != tt.expectPartial {
+				t.Errorf("%s = %q; want %q", compositePartialHeader, got, tt.expectPartial)
+			}
+			if tt.expectCode == http.StatusOK {
+				if v, ok := body["author_profile"]; !ok || v != nil {
+					t.Errorf("author_profile = %v; want null", v)
+				}
+				meta, _ := body["meta"].(map[string]any)
+				if meta["category"] != "Tech" {
+					t.Errorf("meta = %v; other parts should still be merged", meta)
+				}
+			}
+		})
+	}
+
+	t.Run("필수 Part 5xx는 502", func(t *testing.T) {
+		cfg := postPageComposite
+		cfg.Parts = append([]CompositePartConfig(nil), cfg.Parts...)
+		cfg.Parts[1].Required = true
+		env := newCompositeTestEnv(t, []CompositeConfig{cfg})
+		env.userDown.Store(true)
+		if rr, _ := env.get(t, "/api/composite/posts/1"); rr.Code != http.StatusBadGateway {
+			t.Errorf("Status = %d; want 502", rr.Code)
+		}
+	})
+
+	t.Run("Part Timeout", func(t *testing.T) {
+		slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+		}))
+		t.Cleanup(slow.Close)
+		env := newCompositeTestEnv(t, nil)
+		env.router.upstreams["slow-service"] = newTestUpstream(t, "slow-service", slow)
+		err := env.router.SetComposites([]CompositeConfig{{
+			Name: "slow",
+			Path: "/api/composite/slow",
+			Parts: []CompositePartConfig{
+				{Name: "categories", Upstream: "blog-service", Path: "/blog/api/categories", Required: true},
+				{Name: "slow", Upstream: "slow-service", Path: "/", Timeout: jsonDuration(20 * time.Millisecond)},
+			},
+		}})
+		if err != nil {
+			t.Fatalf("SetComposites error: %v", err)
+		}
+		start := time.Now()
+		rr, _ := env.get(t, "/api/composite/slow")
+		if rr.Code != http.StatusOK || rr.Header().Get(compositePartialHeader) != "slow" || time.Since(start) > 500*time.Millisecond {
+			t.Errorf("slow part = %d partial %q after %v", rr.Code, rr.Header().Get(compositePartialHeader), time.Since(start))
+		}
+	})
+}
+
+// TestCompositePartTooLarge tests that an oversized part stops being
+// buffered at the limit and fails as optional
+func TestCompositePartTooLarge(t *testing.T) {
+	huge := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		chunk := `"` + strings.Repeat("x", 64<<10) + `",`
+		io.WriteString(w, "[")
+		for i := 0; i < 4*compositeMaxPartBytes/len(chunk); i++ {
+			if _, err := io.WriteString(w, chunk); err != nil {
+				return
+			}
+		}
+		io.WriteString(w, `""]`)
+	}))
+	t.Cleanup(huge.Close)
+	env := newCompositeTestEnv(t, nil)
+	env.router.upstreams["huge-service"] = newTestUpstream(t, "huge-service", huge)
+	err := env.router.SetComposites([]CompositeConfig{{
+		Name: "huge",
+		Path: "/api/composite/huge",
+		Parts: []CompositePartConfig{
+			{Name: "categories", Upstream: "blog-service", Path: "/blog/api/categories", Required: true},
+			{Name: "huge", Upstream: "huge-service", Path: "/"},
+		},
+	}})
+	if err != nil {
+		t.Fatalf("SetComposites error: %v", err)
+	}
+
+	// http.Server 아래에서는 ReverseProxy가 복사 실패 시 ErrAbortHandler로 panic
+	req := httptest.NewRequest(http.MethodGet, "/api/composite/huge", nil)
+	req = req.WithContext(context.WithValue(req.Context(), http.ServerContextKey, &http.Server{}))
+	rr := httptest.NewRecorder()
+	env.router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || rr.Header().Get(compositePartialHeader) != "huge" {
+		t.Errorf("huge part = %d partial %q; want 200 with huge failed", rr.Code, rr.Header().Get(compositePartialHeader))
+	}
+
+	buf := &partResponse{bufferedResponse: bufferedResponse{header: http.Header{}}, limit: 4}
+	if _, err := buf.Write([]byte("12345")); err != errResponseTooLarge || !buf.tooLarge || buf.body.Len() != 0 {
+		t.Errorf("Write over limit = %v, buffered %d; want errResponseTooLarge and nothing buffered", err, buf.body.Len())
+	}
+}
+
+// TestCompositeMaintenance tests global maintenance and parts whose upstream
+// is in maintenance
+func TestCompositeMaintenance(t *testing.T) {
+	tests := []struct {
+		name          string
+		cfg           MaintenanceConfig
+		expectCode    int
+		expectPartial string
+	}{
+		{"전체 점검은 503", MaintenanceConfig{Enabled: true}, http.StatusServiceUnavailable, ""},
+		{"필수 Part의 Upstream 점검은 503", MaintenanceConfig{Upstreams: []string{"blog-service"}}, http.StatusServiceUnavailable, ""},
+		{"선택 Part의 Upstream 점검은 null", MaintenanceConfig{Upstreams: []string{"user-service"}}, http.StatusOK, "author"},
+		{"점검 Route는 Composite와 무관", MaintenanceConfig{Routes: []string{"blog-api"}}, http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newCompositeTestEnv(t, []CompositeConfig{postPageComposite})
+			useMaintenance(t, env.router, tt.cfg)
+			rr, _ := env.get(t, "/api/composite/posts/1")
+			if rr.Code != tt.expectCode {
+				t.Fatalf("Status = %d; want %d (%s)", rr.Code, tt.expectCode, rr.Body.String())
+			}
+			if got := rr.Header().Get(compositePartialHeader); got != tt.expectPartial {
+				t.Errorf("%s = %q; want %q", compositePartialHeader, got, tt.expectPartial)
+			}
+			if tt.expectCode == http.StatusServiceUnavailable && rr.Header().Get("Retry-After") == "" {
+				t.Error("maintenance response without Retry-After")
+			}
+		})
+	}
+}
+
+// TestCompositeConfigErrors tests rejected composites sections
+func TestCompositeConfigErrors(t *testing.T) {
+	router := newTestRouter(t)
+	part := CompositePartConfig{Name: "post", Upstream: "blog-service", Path: "/blog/api/posts/{id}"}
+	for _, cfg := range []CompositeConfig{
+		{Name: "outside", Path: "/composite/{id}", Parts: []CompositePartConfig{part}},
+		{Name: "empty", Path: "/api/c/{id}"},
+		{Name: "upstream", Path: "/api/c/{id}", Parts: []CompositePartConfig{{Name: "p", Upstream: "nope", Path: "/"}}},
+		{Name: "param", Path: "/api/c/{slug}", Parts: []CompositePartConfig{part}},
+		{Name: "unknown-ref", Path: "/api/c/{id}", Parts: []CompositePartConfig{part, {Name: "a", Upstream: "user-service", Path: "/users/{nope.author}"}}},
+		{Name: "cycle", Path: "/api/c", Parts: []CompositePartConfig{
+			{Name: "a", Upstream: "user-service", Path: "/users/{b.x}"},
+			{Name: "b", Upstream: "user-service", Path: "/users/{a.x}"},
+		}},
+		{Name: "mapping", Path: "/api/c/{id}", Parts: []CompositePartConfig{part}, Response: map[string]string{"title": "post.title"}},
+		{Name: "mapping-part", Path: "/api/c/{id}", Parts: []CompositePartConfig{part}, Response: map[string]string{"title": "$.author.name"}},
+		{Name: "bad-pattern", Path: "/api/c/{id", Parts: []CompositePartConfig{part}},
+	} {
+		if err := router.SetComposites([]CompositeConfig{cfg}); err == nil {
+			t.Errorf("SetComposites(%s) should fail", cfg.Name)
+		}
+	}
+
+	dup := CompositeConfig{Name: "dup", Path: "/api/c/{id}", Parts: []CompositePartConfig{part}}
+	if err := router.SetComposites([]CompositeConfig{dup, dup}); err == nil {
+		t.Error("duplicate composites should fail")
+	}
+}
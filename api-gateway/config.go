@@ -0,0 +1,338 @@
+// api-gateway/config.go
+// 게이트웨이 설정: 환경 변수에서 읽은 유효 설정 (Admin API에 노출)
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// GatewayConfig is the effective configuration. Secrets (tokens, passwords)
+// are never stored here because the admin API returns it as-is.
+type GatewayConfig struct {
+	Port          string            `json:"port"`
+	ConfigFile    string            `json:"config_file"`
+	AdminPort     string            `json:"admin_port"`
+	Upstreams     map[string]string `json:"upstreams"`
+	BlogStaticDir string            `json:"blog_static_dir"`
+
+	RateLimitRPS       float64  `json:"rate_limit_rps"`
+	RateLimitBurst     int      `json:"rate_limit_burst"`
+	MaxRequestBodySize int64    `json:"max_request_body_size"`
+	AllowedOrigins     []string `json:"allowed_origins"`
+	AccessLog          bool     `json:"access_log"`
+	APIKeyMode         string   `json:"api_key_mode"`
+	Store              string   `json:"store"`
+	TrustedProxyHops   int      `json:"trusted_proxy_hops"`
+
+	CircuitBreakerThreshold int          `json:"circuit_breaker_threshold"`
+	CircuitBreakerOpen      jsonDuration `json:"circuit_breaker_open_timeout"`
+	HealthCheckInterval     jsonDuration `json:"health_check_interval"`
+}
+
+// jsonDuration encodes a time.Duration as a Go duration string ("30s").
+type jsonDuration time.Duration
+
+func (d jsonDuration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *jsonDuration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = jsonDuration(parsed)
+	return nil
+}
+
+// FileConfig is the reloadable part of the configuration, read from
+// GATEWAY_CONFIG_FILE at startup and again on SIGHUP or POST /admin/reload.
+type FileConfig struct {
+	Maintenance   *MaintenanceConfig             `json:"maintenance,omitempty"`
+	TrafficSplits map[string]TrafficSplitConfig  `json:"traffic_splits,omitempty"`
+	Shadow        map[string]ShadowConfig        `json:"shadow,omitempty"`
+	Faults        []FaultRule                    `json:"faults,omitempty"`
+	Recording     *RecordingConfig               `json:"recording,omitempty"`
+	Validation    *ValidationConfig              `json:"validation,omitempty"`
+	BodyLimits    map[string]BodyLimitConfig     `json:"body_limits,omitempty"`
+	Streams       map[string]StreamConfig        `json:"streams,omitempty"`
+	IPFilter      *IPFilterConfig                `json:"ip_filter,omitempty"`
+	LoginGuard    *LoginGuardConfig              `json:"login_guard,omitempty"`
+	CSRF          *CSRFConfig                    `json:"csrf,omitempty"`
+	BFF           *BFFConfig                     `json:"bff,omitempty"`
+	Idempotency   map[string]IdempotencyConfig   `json:"idempotency,omitempty"`
+	Headers       map[string]HeaderRulesConfig   `json:"headers,omitempty"`
+	Forwarding    *ForwardingConfig              `json:"forwarding,omitempty"`
+	Versions      map[string]RouteVersionsConfig `json:"versions,omitempty"`
+	Composites    []CompositeConfig              `json:"composites,omitempty"`
+	GraphQL       *GraphQLConfig                 `json:"graphql,omitempty"`
+	GRPC          map[string]GRPCServiceConfig   `json:"grpc,omitempty"`
+}
+
+func loadConfigFile(path string) (*FileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fc FileConfig
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&fc); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return &fc, nil
+}
+
+// reloadConfigFile validates every section of the file before applying any of
+// them, so on error the running configuration is left untouched.
+func reloadConfigFile(path string, router *Router) error {
+	fc, err := loadConfigFile(path)
+	if err != nil {
+		return err
+	}
+	maintenance := envMaintenance()
+	if fc.Maintenance != nil {
+		maintenance = *fc.Maintenance
+	}
+	maintenanceState, err := newMaintenanceState(router, maintenance)
+	if err != nil {
+		return err
+	}
+
+	splits := map[string]*trafficSplit{}
+	for name, splitCfg := range fc.TrafficSplits {
+		u := router.Upstream(name)
+		if u == nil {
+			return fmt.Errorf("traffic_splits: unknown upstream %q", name)
+		}
+		if splits[name], err = u.newTrafficSplit(splitCfg); err != nil {
+			return err
+		}
+	}
+
+	shadows, err := router.newShadows(fc.Shadow)
+	if err != nil {
+		return err
+	}
+
+	for i := range fc.Faults {
+		if err := fc.Faults[i].validate(); err != nil {
+			return err
+		}
+	}
+
+	validationConfig := ValidationConfig{}
+	if fc.Validation != nil {
+		validationConfig = *fc.Validation
+	}
+	validator, err := newRequestValidator(router, validationConfig)
+	if err != nil {
+		return err
+	}
+
+	bodyLimits, err := router.newBodyLimits(fc.BodyLimits)
+	if err != nil {
+		return err
+	}
+
+	streams, err := router.newStreamConfigs(fc.Streams)
+	if err != nil {
+		return err
+	}
+
+	ipFilterConfig := IPFilterConfig{}
+	if fc.IPFilter != nil {
+		ipFilterConfig = *fc.IPFilter
+	}
+	ipFilterState, err := newIPFilterState(router, ipFilterConfig)
+	if err != nil {
+		return err
+	}
+
+	loginGuardConfig := LoginGuardConfig{}
+	if fc.LoginGuard != nil {
+		loginGuardConfig = *fc.LoginGuard
+	}
+	loginGuard, err := newLoginGuardConfig(loginGuardConfig)
+	if err != nil {
+		return err
+	}
+
+	csrfConfig := CSRFConfig{}
+	if fc.CSRF != nil {
+		csrfConfig = *fc.CSRF
+	}
+	csrf, err := newCSRFState(router, csrfConfig)
+	if err != nil {
+		return err
+	}
+
+	bffConfig := BFFConfig{}
+	if fc.BFF != nil {
+		bffConfig = *fc.BFF
+	}
+	bff, err := newBFFConfig(bffConfig)
+	if err != nil {
+		return err
+	}
+
+	idempotency, err := router.newIdempotencyConfigs(fc.Idempotency)
+	if err != nil {
+		return err
+	}
+
+	headerRules, err := newHeaderRuleSet(router, fc.Headers)
+	if err != nil {
+		return err
+	}
+
+	forwardingConfig := ForwardingConfig{}
+	if fc.Forwarding != nil {
+		forwardingConfig = *fc.Forwarding
+	}
+	forwarding, err := newForwardingState(router, forwardingConfig)
+	if err != nil {
+		return err
+	}
+
+	versions, err := router.newVersionConfigs(fc.Versions)
+	if err != nil {
+		return err
+	}
+
+	composites, err := router.newCompositeSet(fc.Composites)
+	if err != nil {
+		return err
+	}
+
+	graphQLConfig := GraphQLConfig{}
+	if fc.GraphQL != nil {
+		graphQLConfig = *fc.GraphQL
+	}
+	graphQL, err := newGraphQLConfig(graphQLConfig)
+	if err != nil {
+		return err
+	}
+
+	grpcServices, err := router.newGRPCConfigs(fc.GRPC)
+	if err != nil {
+		return err
+	}
+
+	recordingConfig := RecordingConfig{}
+	if fc.Recording != nil {
+		recordingConfig = *fc.Recording
+	}
+	rec, err := newRecorder(recordingConfig)
+	if err != nil {
+		return err
+	}
+
+	currentMaintenance.Store(maintenanceState)
+	router.shadows.Store(&shadows)
+	router.faults.Store(&fc.Faults)
+	router.validator.Store(validator)
+	router.bodyLimits.Store(&bodyLimits)
+	router.streams.Store(&streams)
+	router.versions.Store(&versions)
+	router.composites.Store(composites)
+	router.graphql.Store(graphQL)
+	router.grpc.Store(&grpcServices)
+	globalIPFilter.state.Store(ipFilterState)
+	globalLoginGuard.config.Store(loginGuard)
+	globalCSRF.state.Store(csrf)
+	globalBFF.config.Store(bff)
+	globalIdempotency.routes.Store(&idempotency)
+	currentHeaderRules.Store(headerRules)
+	currentForwarding.Store(forwarding)
+	router.swapRecorder(rec)
+	for name, u := range router.upstreams {
+		// 설정에서 제거된 split은 nil로 되돌려 primary로 복귀
+		u.split.Store(splits[name])
+	}
+	log.Printf("Config reloaded from %s", path)
+	return nil
+}
+
+// watchConfigReload reloads the config file whenever the process receives SIGHUP.
+func watchConfigReload(path string, router *Router) {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	go func() {
+		for range sighup {
+			if err := reloadConfigFile(path, router); err != nil {
+				log.Printf("Config reload failed, keeping previous config: %v", err)
+			}
+		}
+	}()
+}
+
+// sortedKeys returns map keys in a stable order for admin/stats output.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func getEnvInt(key string, fallback int) int {
+	if n, err := strconv.Atoi(getEnv(key, "")); err == nil {
+		return n
+	}
+	return fallback
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	if d, err := time.ParseDuration(getEnv(key, "")); err == nil {
+		return d
+	}
+	return fallback
+}
+
+func loadConfigFromEnv() *GatewayConfig {
+	cfg := &GatewayConfig{
+		Port:       getEnv("API_GATEWAY_PORT", "8000"),
+		ConfigFile: getEnv("GATEWAY_CONFIG_FILE", ""),
+		AdminPort:  getEnv("ADMIN_PORT", "9000"),
+		Upstreams: map[string]string{
+			"user-service": getEnv("USER_SERVICE_URL", "http://user-service:8001"),
+			"auth-service": getEnv("AUTH_SERVICE_URL", "http://auth-service:8002"),
+			"blog-service": getEnv("BLOG_SERVICE_URL", "http://blog-service:8005"),
+		},
+		BlogStaticDir: getEnv("BLOG_STATIC_DIR", ""),
+
+		RateLimitRPS:       float64(globalLimiter.r),
+		RateLimitBurst:     globalLimiter.burst,
+		MaxRequestBodySize: MaxRequestBodySize,
+		AllowedOrigins:     allowedOrigins,
+		AccessLog:          accessLogEnabled,
+		APIKeyMode:         globalAPIKeyAuth.mode,
+		Store:              getEnv("GATEWAY_STORE", "memory"),
+		TrustedProxyHops:   trustedProxyHops,
+
+		CircuitBreakerThreshold: getEnvInt("CIRCUIT_BREAKER_THRESHOLD", 0),
+		CircuitBreakerOpen:      jsonDuration(getEnvDuration("CIRCUIT_BREAKER_OPEN_TIMEOUT", 30*time.Second)),
+		HealthCheckInterval:     jsonDuration(getEnvDuration("HEALTH_CHECK_INTERVAL", 10*time.Second)),
+	}
+	// gRPC 서비스는 설정된 경우에만 Upstream으로 추가 (h2c://notification-service:50051)
+	if u := getEnv("NOTIFICATION_SERVICE_URL", ""); u != "" {
+		cfg.Upstreams["notification-service"] = u
+	}
+	return cfg
+}
@@ -0,0 +1,262 @@
+// api-gateway/csrf.go
+// CSRF 방어: Cookie 기반 세션 요청에 대한 Origin/Referer 검사와 Signed Double-Submit Cookie 토큰
+
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var csrfRejectionsTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "csrf_rejections_total",
+		Help: "Total number of state-changing requests rejected by CSRF checks by reason (origin, missing_token, invalid_token)",
+	},
+	[]string{"reason"},
+)
+
+const (
+	csrfCookieName = "csrf_token"
+	csrfHeaderName = "X-CSRF-Token"
+
+	problemTypeCSRF = "/problems/csrf-failed"
+)
+
+// CSRFConfig is the "csrf" section of the config file.
+type CSRFConfig struct {
+	Disabled bool `json:"disabled,omitempty"`
+	// ExemptRoutes are route names that skip the checks (e.g. server-to-server
+	// webhooks that carry cookies for unrelated reasons)
+	ExemptRoutes []string `json:"exempt_routes,omitempty"`
+	// TrustedOrigins are accepted in addition to the gateway's own host and
+	// ALLOWED_ORIGINS ("https://admin.example.com")
+	TrustedOrigins []string `json:"trusted_origins,omitempty"`
+}
+
+type csrfState struct {
+	disabled bool
+	exempt   map[string]bool
+	trusted  map[string]bool
+}
+
+// CSRFProtection implements the double-submit cookie pattern: safe requests
+// receive a readable csrf_token cookie, and state-changing requests that ride
+// on cookies must echo it in X-CSRF-Token. With CSRF_SECRET the token is
+// HMAC-signed so that a cookie planted from a sibling subdomain is rejected.
+type CSRFProtection struct {
+	secret []byte
+	state  atomic.Pointer[csrfState]
+}
+
+func NewCSRFProtection(secret string) *CSRFProtection {
+	c := &CSRFProtection{}
+	if secret != "" {
+		c.secret = []byte(secret)
+	}
+	c.state.Store(&csrfState{})
+	return c
+}
+
+var globalCSRF = NewCSRFProtection(getEnv("CSRF_SECRET", ""))
+
+func newCSRFState(router *Router, cfg CSRFConfig) (*csrfState, error) {
+	state := &csrfState{disabled: cfg.Disabled, exempt: map[string]bool{}, trusted: map[string]bool{}}
+	for _, name := range cfg.ExemptRoutes {
+		if router.route(name) == nil {
+			return nil, fmt.Errorf("csrf: unknown route %q", name)
+		}
+		state.exempt[name] = true
+	}
+	for _, origin := range cfg.TrustedOrigins {
+		u, err := url.Parse(origin)
+		if err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			return nil, fmt.Errorf("csrf: invalid trusted origin %q", origin)
+		}
+		state.trusted[u.Scheme+"://"+u.Host] = true
+	}
+	return state, nil
+}
+
+// SetConfig replaces the exemptions and trusted origins.
+func (c *CSRFProtection) SetConfig(router *Router, cfg CSRFConfig) error {
+	state, err := newCSRFState(router, cfg)
+	if err != nil {
+		return err
+	}
+	c.state.Store(state)
+	return nil
+}
+
+func (c *CSRFProtection) sign(nonce string) string {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte(nonce))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (c *CSRFProtection) newToken() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	nonce := base64.RawURLEncoding.EncodeToString(b)
+	if c.secret == nil {
+		return nonce
+	}
+	return nonce + "." + c.sign(nonce)
+}
+
+// validToken reports whether token was issued by this gateway (with a
+// secret) or is at least well-formed (without one).
+func (c *CSRFProtection) validToken(token string) bool {
+	if c.secret == nil {
+		return len(token) >= 32 && !strings.Contains(token, ".")
+	}
+	nonce, sig, ok := strings.Cut(token, ".")
+	return ok && nonce != "" && hmac.Equal([]byte(sig), []byte(c.sign(nonce)))
+}
+
+func csrfSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// cookieAuthenticated reports whether the browser attached credentials on its
+// own. Requests authenticated with Authorization or an API key cannot be
+// forged cross-site, so they are not checked.
+func cookieAuthenticated(r *http.Request) bool {
+	return r.Header.Get("Cookie") != "" && r.Header.Get("Authorization") == "" && r.Header.Get(apiKeyHeader) == ""
+}
+
+// originAllowed checks the Origin header, or the Referer when a browser
+// omitted Origin, against the request host, ALLOWED_ORIGINS and the trusted
+// origins. Requests carrying neither are allowed and rely on the token.
+func (c *CSRFProtection) originAllowed(r *http.Request, state *csrfState) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		referer := r.Header.Get("Referer")
+		if referer == "" {
+			return true
+		}
+		u, err := url.Parse(referer)
+		if err != nil || u.Host == "" {
+			return false
+		}
+		origin = u.Scheme + "://" + u.Host
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		// "null" origin (sandboxed iframe, file://) 포함
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) || state.trusted[origin] {
+		return true
+	}
+	for _, o := range allowedOrigins {
+		if strings.TrimSpace(o) == origin {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *CSRFProtection) setCookie(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     csrfCookieName,
+		Value:    c.newToken(),
+		Path:     "/",
+		Secure:   requestIsHTTPS(r),
+		HttpOnly: false, // Frontend JS가 읽어 X-CSRF-Token 헤더로 전송
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func csrfProtectedPath(path string) bool {
+	return strings.HasPrefix(path, "/api/") || strings.HasPrefix(path, "/blog/api/")
+}
+
+// csrfIssuesToken reports whether a safe request receives the token cookie:
+// API calls and page navigations, whose scripts send the token back. Static
+// assets are skipped so that no Set-Cookie lands in a response that shared
+// caches keep (blog assets are "public, immutable").
+func csrfIssuesToken(r *http.Request) bool {
+	if csrfProtectedPath(r.URL.Path) {
+		return true
+	}
+	if strings.HasPrefix(r.URL.Path, blogStaticPrefix) {
+		return false
+	}
+	if dest := r.Header.Get("Sec-Fetch-Dest"); dest != "" {
+		return dest == "document"
+	}
+	return strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+// csrfMiddleware issues the token cookie on safe API and page requests and
+// verifies Origin and the double-submitted token on cookie-authenticated state-changing
+// requests under /api/ and /blog/api/.
+func csrfMiddleware(c *CSRFProtection, router *Router, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state := c.state.Load()
+		if state.disabled || r.URL.Path == "/health" || r.URL.Path == "/metrics" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cookie, err := r.Cookie(csrfCookieName)
+		hasToken := err == nil && c.validToken(cookie.Value)
+		if csrfSafeMethod(r.Method) {
+			if !hasToken && csrfIssuesToken(r) {
+				c.setCookie(w, r)
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !csrfProtectedPath(r.URL.Path) || !cookieAuthenticated(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if route := router.Resolve(r.URL.Path); route != nil && state.exempt[route.Name] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		reject := func(reason, detail string) {
+			csrfRejectionsTotal.WithLabelValues(reason).Inc()
+			log.Printf("CSRF check failed (%s): %s %s origin=%q", reason, r.Method, r.URL.Path, r.Header.Get("Origin"))
+			writeProblem(w, r, &problem{Type: problemTypeCSRF, Status: http.StatusForbidden, Detail: detail})
+		}
+		if !c.originAllowed(r, state) {
+			reject("origin", "Cross-origin request is not allowed")
+			return
+		}
+		header := r.Header.Get(csrfHeaderName)
+		if !hasToken || header == "" {
+			if !hasToken {
+				// 다음 요청을 위해 토큰 재발급
+				c.setCookie(w, r)
+			}
+			reject("missing_token", "Missing CSRF token; send the csrf_token cookie value in "+csrfHeaderName)
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(header), []byte(cookie.Value)) != 1 {
+			reject("invalid_token", "CSRF token does not match")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,192 @@
+// api-gateway/csrf_test.go
+// 단위 테스트: CSRF 토큰 발급/검증, Origin/Referer 교차 출처 검사, Route 예외
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// issueCSRFToken performs a safe request and returns the issued cookie value
+func issueCSRFToken(t *testing.T, handler http.Handler) string {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "https://gateway.example.com/blog/", nil)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,*/*;q=0.8")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	for _, c := range rr.Result().Cookies() {
+		if c.Name == csrfCookieName {
+			if c.HttpOnly || c.SameSite != http.SameSiteLaxMode {
+				t.Errorf("cookie = %+v; want readable SameSite=Lax", c)
+			}
+			return c.Value
+		}
+	}
+	t.Fatal("no csrf_token cookie issued")
+	return ""
+}
+
+// TestCSRFMiddleware tests the origin and token checks for cookie sessions
+func TestCSRFMiddleware(t *testing.T) {
+	originalOrigins := allowedOrigins
+	allowedOrigins = []string{"https://app.example.com"}
+	defer func() { allowedOrigins = originalOrigins }()
+
+	router := newTestRouter(t)
+	c := NewCSRFProtection("test-secret")
+	if err := c.SetConfig(router, CSRFConfig{
+		ExemptRoutes:   []string{"auth-login"},
+		TrustedOrigins: []string{"https://admin.example.com"},
+	}); err != nil {
+		t.Fatalf("SetConfig error: %v", err)
+	}
+	handler := csrfMiddleware(c, router, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	token := issueCSRFToken(t, handler)
+	forged := NewCSRFProtection("other-secret").newToken()
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		headers    map[string]string
+		expectCode int
+		reason     string
+	}{
+		{"같은 출처 + 토큰 일치", http.MethodPost, "/blog/api/posts",
+			map[string]string{"Origin": "https://gateway.example.com", "Cookie": "csrf_token=" + token, csrfHeaderName: token}, http.StatusOK, ""},
+		{"ALLOWED_ORIGINS 출처", http.MethodDelete, "/blog/api/posts/1",
+			map[string]string{"Origin": "https://app.example.com", "Cookie": "csrf_token=" + token, csrfHeaderName: token}, http.StatusOK, ""},
+		{"trusted_origins 출처", http.MethodPatch, "/api/users/alice",
+			map[string]string{"Origin": "https://admin.example.com", "Cookie": "csrf_token=" + token, csrfHeaderName: token}, http.StatusOK, ""},
+		{"교차 출처 Origin", http.MethodPost, "/blog/api/posts",
+			map[string]string{"Origin": "https://evil.example.net", "Cookie": "csrf_token=" + token, csrfHeaderName: token}, http.StatusForbidden, "origin"},
+		{"교차 출처 Referer (Origin 없음)", http.MethodPost, "/blog/api/posts",
+			map[string]string{"Referer": "https://evil.example.net/page", "Cookie": "csrf_token=" + token, csrfHeaderName: token}, http.StatusForbidden, "origin"},
+		{"null Origin", http.MethodPost, "/blog/api/posts",
+			map[string]string{"Origin": "null", "Cookie": "csrf_token=" + token, csrfHeaderName: token}, http.StatusForbidden, "origin"},
+		{"같은 출처 Referer", http.MethodPost, "/blog/api/posts",
+			map[string]string{"Referer": "https://gateway.example.com/blog/", "Cookie": "session=abc; csrf_token=" + token, csrfHeaderName: token}, http.StatusOK, ""},
+		{"헤더 토큰 없음", http.MethodPost, "/blog/api/posts",
+			map[string]string{"Origin": "https://gateway.example.com", "Cookie": "session=abc; csrf_token=" + token}, http.StatusForbidden, "missing_token"},
+		{"Cookie 토큰 없음", http.MethodPost, "/blog/api/posts",
+			map[string]string{"Cookie": "session=abc", csrfHeaderName: token}, http.StatusForbidden, "missing_token"},
+		{"토큰 불일치", http.MethodPut, "/api/users/alice",
+			map[string]string{"Cookie": "csrf_token=" + token, csrfHeaderName: token + "x"}, http.StatusForbidden, "invalid_token"},
+		{"다른 secret으로 서명된 Cookie", http.MethodPost, "/blog/api/posts",
+			map[string]string{"Cookie": "csrf_token=" + forged, csrfHeaderName: forged}, http.StatusForbidden, "missing_token"},
+		{"Cookie 없는 Bearer 요청", http.MethodPost, "/blog/api/posts",
+			map[string]string{"Origin": "https://evil.example.net", "Authorization": "Bearer t"}, http.StatusOK, ""},
+		{"Cookie + Authorization", http.MethodPost, "/blog/api/posts",
+			map[string]string{"Cookie": "session=abc", "Authorization": "Bearer t"}, http.StatusOK, ""},
+		{"예외 Route", http.MethodPost, "/api/login",
+			map[string]string{"Origin": "https://evil.example.net", "Cookie": "session=abc"}, http.StatusOK, ""},
+		{"API 외 경로", http.MethodPost, "/blog/",
+			map[string]string{"Cookie": "session=abc"}, http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "https://gateway.example.com"+tt.path, strings.NewReader("{}"))
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			before := testutil.ToFloat64(csrfRejectionsTotal.WithLabelValues(tt.reason))
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tt.expectCode {
+				t.Fatalf("Status = %d; want %d (%s)", rr.Code, tt.expectCode, rr.Body.String())
+			}
+			if tt.reason != "" {
+				if p := decodeProblem(t, rr); p.Type != problemTypeCSRF {
+					t.Errorf("problem type = %q; want %q", p.Type, problemTypeCSRF)
+				}
+				if got := testutil.ToFloat64(csrfRejectionsTotal.WithLabelValues(tt.reason)) - before; got != 1 {
+					t.Errorf("csrf_rejections_total{reason=%q} increased by %v; want 1", tt.reason, got)
+				}
+			}
+		})
+	}
+}
+
+// TestCSRFTokenIssue tests when the cookie is (re)issued
+func TestCSRFTokenIssue(t *testing.T) {
+	router := newTestRouter(t)
+	c := NewCSRFProtection("")
+	handler := csrfMiddleware(c, router, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	token := issueCSRFToken(t, handler)
+	if !c.validToken(token) {
+		t.Fatalf("issued token %q is not valid", token)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/blog/api/posts", nil)
+	req.AddCookie(&http.Cookie{Name: csrfCookieName, Value: token})
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if len(rr.Result().Cookies()) != 0 {
+		t.Error("valid cookie should not be reissued")
+	}
+
+	issueTests := []struct {
+		name    string
+		url     string
+		headers map[string]string
+		expect  bool
+	}{
+		{"API 요청", "/blog/api/posts", nil, true},
+		{"페이지 이동 (Sec-Fetch-Dest)", "/blog/posts/1", map[string]string{"Sec-Fetch-Dest": "document"}, true},
+		{"캐시되는 정적 자산", "/blog/static/js/app.3f9a1c2b.js", map[string]string{"Accept": "text/html"}, false},
+		{"페이지가 불러오는 스크립트", "/blog/posts/1", map[string]string{"Sec-Fetch-Dest": "script", "Accept": "*/*"}, false},
+		{"HTML이 아닌 Gateway 경로", "/stats", map[string]string{"Accept": "application/json"}, false},
+		{"Health Check", "/health", map[string]string{"Accept": "text/html"}, false},
+	}
+	for _, tt := range issueTests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if got := len(rr.Result().Cookies()) > 0; got != tt.expect {
+				t.Errorf("cookie issued = %v; want %v", got, tt.expect)
+			}
+		})
+	}
+
+	t.Run("클라이언트가 보낸 X-Forwarded-Proto로 Secure 설정 안 함", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "http://gateway.example.com/blog/api/posts", nil)
+		req.Header.Set("X-Forwarded-Proto", "https")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if cookies := rr.Result().Cookies(); len(cookies) != 1 || cookies[0].Secure {
+			t.Errorf("cookies = %+v; want one without Secure", cookies)
+		}
+	})
+
+	c.SetConfig(router, CSRFConfig{Disabled: true})
+	req = httptest.NewRequest(http.MethodPost, "/blog/api/posts", nil)
+	req.Header.Set("Cookie", "session=abc")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("disabled Status = %d; want 200", rr.Code)
+	}
+}
+
+// TestCSRFConfigErrors tests rejected csrf sections
+func TestCSRFConfigErrors(t *testing.T) {
+	router := newTestRouter(t)
+	for _, cfg := range []CSRFConfig{
+		{ExemptRoutes: []string{"nope"}},
+		{TrustedOrigins: []string{"admin.example.com"}},
+		{TrustedOrigins: []string{"https://admin.example.com/path"}},
+	} {
+		if err := NewCSRFProtection("").SetConfig(router, cfg); err == nil {
+			t.Errorf("SetConfig(%+v) should fail", cfg)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+// api-gateway/faults.go
+// Fault Injection: Route/Upstream/헤더 단위로 지연, 에러 응답, 연결 리셋, 본문 잘림을 주입
+
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var faultsInjectedTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "faults_injected_total",
+		Help: "Total number of injected faults by type (delay, abort, reset, truncate)",
+	},
+	[]string{"route", "fault"},
+)
+
+// FaultRule is one entry of the "faults" section of the config file. A rule
+// applies to a request when every configured selector matches; the first
+// matching rule wins.
+type FaultRule struct {
+	Routes    []string `json:"routes,omitempty"`
+	Upstreams []string `json:"upstreams,omitempty"`
+	// Header limits the rule to test traffic carrying this header
+	// (with HeaderValue, only that exact value)
+	Header      string `json:"header,omitempty"`
+	HeaderValue string `json:"header_value,omitempty"`
+
+	Delay    *FaultDelay    `json:"delay,omitempty"`
+	Abort    *FaultAbort    `json:"abort,omitempty"`
+	Reset    *FaultPercent  `json:"reset,omitempty"`
+	Truncate *FaultTruncate `json:"truncate,omitempty"`
+}
+
+type FaultPercent struct {
+	// Percent of matching requests affected (0-100)
+	Percent float64 `json:"percent"`
+}
+
+// FaultDelay waits Fixed, or a random duration in [Fixed, Max] when Max is set.
+type FaultDelay struct {
+	FaultPercent
+	Fixed jsonDuration `json:"fixed,omitempty"`
+	Max   jsonDuration `json:"max,omitempty"`
+}
+
+type FaultAbort struct {
+	FaultPercent
+	Status int `json:"status"`
+}
+
+// FaultTruncate cuts the response body after Bytes and closes the connection.
+type FaultTruncate struct {
+	FaultPercent
+	Bytes int64 `json:"bytes"`
+}
+
+func (p *FaultPercent) hit() bool {
+	return p != nil && rand.Float64()*100 < p.Percent
+}
+
+func (d *FaultDelay) hit() bool    { return d != nil && d.FaultPercent.hit() }
+func (a *FaultAbort) hit() bool    { return a != nil && a.FaultPercent.hit() }
+func (t *FaultTruncate) hit() bool { return t != nil && t.FaultPercent.hit() }
+
+func (p *FaultPercent) validate(name string) error {
+	if p.Percent < 0 || p.Percent > 100 {
+		return fmt.Errorf("faults: %s percent must be between 0 and 100", name)
+	}
+	return nil
+}
+
+func (f *FaultRule) validate() error {
+	if f.HeaderValue != "" && f.Header == "" {
+		return fmt.Errorf("faults: header_value requires header")
+	}
+	if f.Delay != nil {
+		if err := f.Delay.validate("delay"); err != nil {
+			return err
+		}
+		if f.Delay.Fixed < 0 || (f.Delay.Max != 0 && f.Delay.Max < f.Delay.Fixed) {
+			return fmt.Errorf("faults: delay max must not be less than fixed")
+		}
+	}
+	if f.Abort != nil {
+		if err := f.Abort.validate("abort"); err != nil {
+			return err
+		}
+		if f.Abort.Status < 400 || f.Abort.Status > 599 {
+			return fmt.Errorf("faults: abort status %d is not an error status", f.Abort.Status)
+		}
+	}
+	if f.Reset != nil {
+		if err := f.Reset.validate("reset"); err != nil {
+			return err
+		}
+	}
+	if f.Truncate != nil {
+		if err := f.Truncate.validate("truncate"); err != nil {
+			return err
+		}
+		if f.Truncate.Bytes < 0 {
+			return fmt.Errorf("faults: truncate bytes must not be negative")
+		}
+	}
+	return nil
+}
+
+func (f *FaultRule) matches(r *http.Request, route *Route) bool {
+	if len(f.Routes) > 0 && !slices.Contains(f.Routes, route.Name) {
+		return false
+	}
+	if len(f.Upstreams) > 0 && !slices.Contains(f.Upstreams, route.Upstream) {
+		return false
+	}
+	if f.Header != "" {
+		values, ok := r.Header[http.CanonicalHeaderKey(f.Header)]
+		if !ok || (f.HeaderValue != "" && !slices.Contains(values, f.HeaderValue)) {
+			return false
+		}
+	}
+	return true
+}
+
+// SetFaults replaces the fault rules; an empty list disables injection.
+func (rt *Router) SetFaults(rules []FaultRule) error {
+	for i := range rules {
+		if err := rules[i].validate(); err != nil {
+			return err
+		}
+	}
+	rt.faults.Store(&rules)
+	return nil
+}
+
+func (rt *Router) faultRule(r *http.Request, route *Route) *FaultRule {
+	rules := rt.faults.Load()
+	if rules == nil {
+		return nil
+	}
+	for i := range *rules {
+		if (*rules)[i].matches(r, route) {
+			return &(*rules)[i]
+		}
+	}
+	return nil
+}
+
+// inject applies the rule around next. Delay runs first and may be combined
+// with one of abort, reset or truncate.
+func (f *FaultRule) inject(w http.ResponseWriter, r *http.Request, route *Route, next http.Handler) {
+	if f.Delay.hit() {
+		faultsInjectedTotal.WithLabelValues(route.Name, "delay").Inc()
+		delay := time.Duration(f.Delay.Fixed)
+		if f.Delay.Max > f.Delay.Fixed {
+			delay += rand.N(time.Duration(f.Delay.Max - f.Delay.Fixed))
+		}
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	switch {
+	case f.Abort.hit():
+		faultsInjectedTotal.WithLabelValues(route.Name, "abort").Inc()
+		writeError(w, r, f.Abort.Status, "Injected fault")
+	case f.Reset.hit():
+		faultsInjectedTotal.WithLabelValues(route.Name, "reset").Inc()
+		resetConnection(w)
+	case f.Truncate.hit():
+		faultsInjectedTotal.WithLabelValues(route.Name, "truncate").Inc()
+		tw := &truncatingWriter{ResponseWriter: w, remaining: f.Truncate.Bytes}
+		next.ServeHTTP(tw, r)
+		if tw.truncated {
+			// 잘린 본문까지 전송한 뒤 응답을 완료하지 않고 연결을 끊음
+			http.NewResponseController(w).Flush()
+			panic(http.ErrAbortHandler)
+		}
+	default:
+		next.ServeHTTP(w, r)
+	}
+}
+
+// resetConnection closes the client connection with a TCP RST when the
+// connection can be hijacked, and otherwise aborts the response.
+func resetConnection(w http.ResponseWriter) {
+	conn, _, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		panic(http.ErrAbortHandler)
+	}
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		tcp.SetLinger(0)
+	}
+	conn.Close()
+}
+
+// truncatingWriter passes through the first remaining bytes of the body.
+type truncatingWriter struct {
+	http.ResponseWriter
+	remaining int64
+	truncated bool
+}
+
+func (t *truncatingWriter) Write(b []byte) (int, error) {
+	if int64(len(b)) > t.remaining {
+		t.truncated = true
+		n, err := t.ResponseWriter.Write(b[:t.remaining])
+		t.remaining -= int64(n)
+		if err != nil {
+			return n, err
+		}
+		// 나머지는 버리되 프록시 복사는 계속되도록 전체 길이를 보고
+		return len(b), nil
+	}
+	n, err := t.ResponseWriter.Write(b)
+	t.remaining -= int64(n)
+	return n, err
+}
+
+func (t *truncatingWriter) Unwrap() http.ResponseWriter {
+	return t.ResponseWriter
+}
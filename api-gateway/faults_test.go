@@ -0,0 +1,125 @@
+// api-gateway/faults_test.go
+// 단위 테스트: Fault Injection (지연, 에러 응답, 연결 리셋, 본문 잘림, 헤더 조건)
+
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func useFaults(t *testing.T, router *Router, rules ...FaultRule) {
+	t.Helper()
+	if err := router.SetFaults(rules); err != nil {
+		t.Fatalf("SetFaults error: %v", err)
+	}
+}
+
+// TestFaultAbort tests injected error responses and rule selectors
+func TestFaultAbort(t *testing.T) {
+	router := newTestRouter(t)
+	useFaults(t, router, FaultRule{
+		Upstreams: []string{"user-service"},
+		Header:    "X-Fault-Test",
+		Abort:     &FaultAbort{FaultPercent: FaultPercent{Percent: 100}, Status: http.StatusServiceUnavailable},
+	})
+
+	tests := []struct {
+		name       string
+		path       string
+		header     bool
+		expectCode int
+	}{
+		{"대상 Upstream + 테스트 헤더", "/api/users/a", true, http.StatusServiceUnavailable},
+		{"테스트 헤더 없음", "/api/users/a", false, http.StatusOK},
+		{"다른 Upstream", "/api/login", true, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header {
+				req.Header.Set("X-Fault-Test", "1")
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if rr.Code != tt.expectCode {
+				t.Errorf("Status = %d; want %d", rr.Code, tt.expectCode)
+			}
+		})
+	}
+
+	t.Run("percent 0은 주입하지 않음", func(t *testing.T) {
+		useFaults(t, router, FaultRule{Abort: &FaultAbort{Status: http.StatusInternalServerError}})
+		for i := 0; i < 50; i++ {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/users/a", nil))
+			if rr.Code != http.StatusOK {
+				t.Fatalf("Status = %d; want 200", rr.Code)
+			}
+		}
+	})
+}
+
+// TestFaultDelay tests fixed and random delays
+func TestFaultDelay(t *testing.T) {
+	router := newTestRouter(t)
+	useFaults(t, router, FaultRule{
+		Routes: []string{"users"},
+		Delay:  &FaultDelay{FaultPercent: FaultPercent{Percent: 100}, Fixed: jsonDuration(50 * time.Millisecond), Max: jsonDuration(80 * time.Millisecond)},
+	})
+
+	start := time.Now()
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/users/a", nil))
+	elapsed := time.Since(start)
+	if rr.Code != http.StatusOK || elapsed < 50*time.Millisecond {
+		t.Errorf("Status = %d after %s; want 200 after at least 50ms", rr.Code, elapsed)
+	}
+}
+
+// TestFaultConnectionFaults tests resets and truncated bodies over a real connection
+func TestFaultConnectionFaults(t *testing.T) {
+	router := newTestRouter(t)
+	gateway := httptest.NewServer(router)
+	defer gateway.Close()
+
+	t.Run("연결 리셋", func(t *testing.T) {
+		useFaults(t, router, FaultRule{Reset: &FaultPercent{Percent: 100}})
+		if resp, err := http.Get(gateway.URL + "/api/users/a"); err == nil {
+			resp.Body.Close()
+			t.Error("expected connection error")
+		}
+	})
+
+	t.Run("본문 잘림", func(t *testing.T) {
+		useFaults(t, router, FaultRule{Truncate: &FaultTruncate{FaultPercent: FaultPercent{Percent: 100}, Bytes: 4}})
+		resp, err := http.Get(gateway.URL + "/api/users/alice")
+		if err != nil {
+			t.Fatalf("request error: %v", err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err == nil || string(body) != "/use" {
+			t.Errorf("body = %q, err = %v; want truncated /use with read error", body, err)
+		}
+	})
+}
+
+// TestFaultValidation tests rejected fault rules
+func TestFaultValidation(t *testing.T) {
+	router := newTestRouter(t)
+	rules := []FaultRule{
+		{Abort: &FaultAbort{FaultPercent: FaultPercent{Percent: 100}, Status: http.StatusOK}},
+		{Reset: &FaultPercent{Percent: 150}},
+		{Delay: &FaultDelay{Fixed: jsonDuration(time.Second), Max: jsonDuration(time.Millisecond)}},
+		{HeaderValue: "1"},
+	}
+	for _, rule := range rules {
+		if err := router.SetFaults([]FaultRule{rule}); err == nil {
+			t.Errorf("rule %+v should be rejected", rule)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+// api-gateway/forwarded.go
+// Upstream 요청의 Host와 X-Forwarded-*/Forwarded(RFC 7239) 헤더: 원래 클라이언트, Host, Scheme, 경로 Prefix 전달
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync/atomic"
+)
+
+// ForwardingConfig is the "forwarding" section of the config file.
+type ForwardingConfig struct {
+	// PreserveHost lists upstreams (or "*") that receive the client's Host
+	// header. The others get their own host, which Istio routes on
+	PreserveHost []string `json:"preserve_host,omitempty"`
+}
+
+type forwardingState struct {
+	preserveHost map[string]bool
+}
+
+var currentForwarding atomic.Pointer[forwardingState]
+
+func init() {
+	currentForwarding.Store(&forwardingState{})
+}
+
+func newForwardingState(router *Router, cfg ForwardingConfig) (*forwardingState, error) {
+	s := &forwardingState{preserveHost: map[string]bool{}}
+	for _, name := range cfg.PreserveHost {
+		if name != allRoutesRule && router.Upstream(name) == nil {
+			return nil, fmt.Errorf("forwarding: unknown upstream %q", name)
+		}
+		s.preserveHost[name] = true
+	}
+	return s, nil
+}
+
+// SetForwarding replaces the forwarding settings.
+func SetForwarding(router *Router, cfg ForwardingConfig) error {
+	s, err := newForwardingState(router, cfg)
+	if err != nil {
+		return err
+	}
+	currentForwarding.Store(s)
+	return nil
+}
+
+func (s *forwardingState) preservesHost(upstream string) bool {
+	return s.preserveHost[allRoutesRule] || s.preserveHost[upstream]
+}
+
+// rewriteForwarded points pr.Out at target and sets the forwarding headers.
+// ReverseProxy has already removed X-Forwarded-* and Forwarded from pr.Out.
+// When the gateway runs behind TRUSTED_PROXY_HOPS proxies (ingress, load
+// balancer), their X-Forwarded-For and Forwarded chains are carried over and
+// the direct peer appended, and the host and scheme they saw are passed on.
+// Otherwise these headers came from the client and the gateway's own view
+// (r.Host, r.TLS) is used.
+func rewriteForwarded(pr *httputil.ProxyRequest, upstream string, target *url.URL) {
+	pr.Out.URL.Scheme = target.Scheme
+	pr.Out.URL.Host = target.Host
+	if !currentForwarding.Load().preservesHost(upstream) {
+		pr.Out.Host = target.Host
+	}
+
+	trusted := trustedProxyHops > 0
+	if prior := pr.In.Header["X-Forwarded-For"]; trusted && len(prior) > 0 {
+		pr.Out.Header["X-Forwarded-For"] = prior
+	}
+	pr.SetXForwarded()
+
+	// TLS는 Ingress에서 종료되므로 신뢰 Proxy가 알려준 Host/Scheme을 사용
+	host := pr.In.Host
+	if h := trustedHopValue(pr.In.Header, "X-Forwarded-Host"); h != "" {
+		host = h
+	}
+	proto := requestScheme(pr.In)
+	pr.Out.Header.Set("X-Forwarded-Host", host)
+	pr.Out.Header.Set("X-Forwarded-Proto", proto)
+	if prefix := forwardedPrefix(pr.In.RequestURI, pr.Out.URL.Path); prefix != "" {
+		pr.Out.Header.Set("X-Forwarded-Prefix", prefix)
+	}
+
+	element := "host=" + forwardedValue(host) + ";proto=" + proto
+	if peer, _, err := net.SplitHostPort(pr.In.RemoteAddr); err == nil {
+		element = "for=" + forwardedNode(peer) + ";" + element
+	}
+	if prior := pr.In.Header.Values("Forwarded"); trusted && len(prior) > 0 {
+		element = strings.Join(prior, ", ") + ", " + element
+	}
+	pr.Out.Header.Set("Forwarded", element)
+}
+
+// forwardedPrefix returns the part of the client's path that the route
+// stripped before proxying ("/api" for /api/users/alice → /users/alice), or
+// "" when the path was kept or replaced entirely.
+func forwardedPrefix(requestURI, upstreamPath string) string {
+	u, err := url.ParseRequestURI(requestURI)
+	if err != nil || u.Path == upstreamPath || !strings.HasSuffix(u.Path, upstreamPath) {
+		return ""
+	}
+	return strings.TrimSuffix(u.Path, upstreamPath)
+}
+
+// forwardedNode formats an IP for the for= parameter; IPv6 addresses are
+// bracketed and therefore quoted (RFC 7239 section 6).
+func forwardedNode(ip string) string {
+	if strings.Contains(ip, ":") {
+		return `"[` + ip + `]"`
+	}
+	return ip
+}
+
+// forwardedValue quotes v unless it is a token.
+func forwardedValue(v string) string {
+	isToken := v != "" && !strings.ContainsFunc(v, func(c rune) bool {
+		return c <= ' ' || c >= 0x7f || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, c)
+	})
+	if isToken {
+		return v
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(v) + `"`
+}
+
+// requestScheme is the scheme the client used to reach the gateway.
+func requestScheme(r *http.Request) string {
+	if requestIsHTTPS(r) {
+		return "https"
+	}
+	return "http"
+}
@@ -0,0 +1,175 @@
+// api-gateway/forwarded_test.go
+// 단위 테스트: Upstream에 전달되는 Host, X-Forwarded-*, Forwarded 헤더
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newForwardingTestRouter routes every service to a backend that echoes the
+// request headers it received, with the Host header as "Host"
+func newForwardingTestRouter(t *testing.T, cfg ForwardingConfig) (*Router, string) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := r.Header.Clone()
+		h.Set("Host", r.Host)
+		json.NewEncoder(w).Encode(h)
+	}))
+	t.Cleanup(srv.Close)
+
+	upstreams := map[string]*Upstream{}
+	for _, name := range []string{"user-service", "auth-service", "blog-service"} {
+		upstreams[name] = newTestUpstream(t, name, srv)
+	}
+	router := NewRouter(defaultRoutes(), upstreams, nil)
+
+	previous := currentForwarding.Load()
+	t.Cleanup(func() { currentForwarding.Store(previous) })
+	if err := SetForwarding(router, cfg); err != nil {
+		t.Fatalf("SetForwarding error: %v", err)
+	}
+	return router, upstreams["blog-service"].Target.Host
+}
+
+// TestForwardedHeaders tests the headers describing the original request
+func TestForwardedHeaders(t *testing.T) {
+	router, upstreamHost := newForwardingTestRouter(t, ForwardingConfig{})
+
+	tests := []struct {
+		name     string
+		url      string
+		remote   string
+		hops     int
+		headers  map[string]string
+		expected map[string]string
+	}{
+		{
+			name:   "직접 접속",
+			url:    "http://gateway.example.com/blog/api/posts",
+			remote: "203.0.113.7:4000",
+			expected: map[string]string{
+				"Host":               upstreamHost,
+				"X-Forwarded-For":    "203.0.113.7",
+				"X-Forwarded-Host":   "gateway.example.com",
+				"X-Forwarded-Proto":  "http",
+				"X-Forwarded-Prefix": "",
+				"Forwarded":          "for=203.0.113.7;host=gateway.example.com;proto=http",
+			},
+		},
+		{
+			name:   "Ingress 뒤 (TLS 종료)",
+			url:    "http://gateway.internal/api/users/alice",
+			remote: "10.0.0.5:4000",
+			hops:   1,
+			headers: map[string]string{
+				"X-Forwarded-For":   "198.51.100.9",
+				"X-Forwarded-Host":  "titanium.example.com",
+				"X-Forwarded-Proto": "https",
+				"Forwarded":         `for=198.51.100.9;proto=https`,
+			},
+			expected: map[string]string{
+				"X-Forwarded-For":    "198.51.100.9, 10.0.0.5",
+				"X-Forwarded-Host":   "titanium.example.com",
+				"X-Forwarded-Proto":  "https",
+				"X-Forwarded-Prefix": "/api",
+				"Forwarded":          "for=198.51.100.9;proto=https, for=10.0.0.5;host=titanium.example.com;proto=https",
+			},
+		},
+		{
+			name:   "신뢰 Proxy 없이 클라이언트가 보낸 헤더는 무시",
+			url:    "http://gateway.example.com/blog/api/posts",
+			remote: "203.0.113.7:4000",
+			headers: map[string]string{
+				"X-Forwarded-For":   "198.51.100.9",
+				"X-Forwarded-Host":  "evil.example.com",
+				"X-Forwarded-Proto": "https",
+				"Forwarded":         `for=198.51.100.9;host=evil.example.com;proto=https`,
+			},
+			expected: map[string]string{
+				"X-Forwarded-For":   "203.0.113.7",
+				"X-Forwarded-Host":  "gateway.example.com",
+				"X-Forwarded-Proto": "http",
+				"Forwarded":         "for=203.0.113.7;host=gateway.example.com;proto=http",
+			},
+		},
+		{
+			name:   "hop 수보다 왼쪽의 Host는 무시",
+			url:    "http://gateway.internal/blog/api/posts",
+			remote: "10.0.0.5:4000",
+			hops:   1,
+			headers: map[string]string{
+				"X-Forwarded-Host":  "evil.example.com, titanium.example.com",
+				"X-Forwarded-Proto": "https",
+			},
+			expected: map[string]string{
+				"X-Forwarded-Host":  "titanium.example.com",
+				"X-Forwarded-Proto": "https",
+			},
+		},
+		{
+			name:   "IPv6 클라이언트와 포트가 있는 Host",
+			url:    "http://gateway.example.com:8443/api/register",
+			remote: "[2001:db8::1]:4000",
+			expected: map[string]string{
+				"X-Forwarded-For":    "2001:db8::1",
+				"X-Forwarded-Prefix": "",
+				"Forwarded":          `for="[2001:db8::1]";host="gateway.example.com:8443";proto=http`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setTrustedProxyHops(t, tt.hops)
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			req.RemoteAddr = tt.remote
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			var got http.Header
+			if err := json.NewDecoder(rr.Body).Decode(&got); err != nil {
+				t.Fatalf("decode upstream headers: %v", err)
+			}
+			for k, want := range tt.expected {
+				if got.Get(k) != want {
+					t.Errorf("upstream %s = %q; want %q", k, got.Get(k), want)
+				}
+			}
+		})
+	}
+}
+
+// TestForwardingPreserveHost tests the per-upstream Host choice
+func TestForwardingPreserveHost(t *testing.T) {
+	router, upstreamHost := newForwardingTestRouter(t, ForwardingConfig{PreserveHost: []string{"blog-service"}})
+
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{"원래 Host 유지", "/blog/api/posts", "gateway.example.com"},
+		{"다른 Upstream은 Upstream Host", "/api/users/alice", upstreamHost},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "http://gateway.example.com"+tt.path, nil))
+			var got http.Header
+			json.NewDecoder(rr.Body).Decode(&got)
+			if got.Get("Host") != tt.expected {
+				t.Errorf("upstream Host = %q; want %q", got.Get("Host"), tt.expected)
+			}
+		})
+	}
+
+	if err := SetForwarding(router, ForwardingConfig{PreserveHost: []string{"nope"}}); err == nil {
+		t.Error("SetForwarding with unknown upstream should fail")
+	}
+}
@@ -0,0 +1,637 @@
+// api-gateway/graphql.go
+// GraphQL Facade: /graphql에서 users/posts/categories를 기존 REST Upstream으로 해석 (요청별 DataLoader, Depth/Complexity 제한)
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	graphQLRequestsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "graphql_requests_total",
+			Help: "Total number of GraphQL requests by result (ok, error, rejected)",
+		},
+		[]string{"result"},
+	)
+	graphQLUpstreamCallsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "graphql_upstream_calls_total",
+			Help: "Total number of REST calls made to resolve GraphQL queries",
+		},
+		[]string{"upstream"},
+	)
+)
+
+const (
+	graphQLPath                       = "/graphql"
+	graphQLContextKey      contextKey = "graphql"
+	graphQLMaxBodyBytes               = 64 << 10
+	graphQLLoaderParallels            = 8
+	// graphQLMaxSelections caps the fields and spreads written in a query
+	graphQLMaxSelections = 500
+
+	// defaultPostsLimit and maxPostsLimit mirror blog-service's pagination
+	defaultPostsLimit = 20
+	maxPostsLimit     = 100
+	// defaultExpectedCategories is the assumed length of the unpaginated
+	// categories list when estimating complexity
+	defaultExpectedCategories = 20
+)
+
+// GraphQLConfig is the "graphql" section of the config file.
+type GraphQLConfig struct {
+	Enabled bool `json:"enabled"`
+	// MaxDepth limits field nesting (default 6)
+	MaxDepth int `json:"max_depth,omitempty"`
+	// MaxComplexity limits the estimated number of resolved fields, with
+	// list fields counted limit times (default 1000)
+	MaxComplexity int `json:"max_complexity,omitempty"`
+	// ExpectedCategories is how many times the children of categories count,
+	// since that list has no limit (default 20)
+	ExpectedCategories int `json:"expected_categories,omitempty"`
+	// Timeout bounds each upstream call (default 5s)
+	Timeout jsonDuration `json:"timeout,omitempty"`
+}
+
+func newGraphQLConfig(cfg GraphQLConfig) (*GraphQLConfig, error) {
+	if cfg.MaxDepth < 0 || cfg.MaxComplexity < 0 || cfg.ExpectedCategories < 0 || cfg.Timeout < 0 {
+		return nil, fmt.Errorf("graphql: values must not be negative")
+	}
+	if cfg.MaxDepth == 0 {
+		cfg.MaxDepth = 6
+	}
+	if cfg.MaxComplexity == 0 {
+		cfg.MaxComplexity = 1000
+	}
+	if cfg.ExpectedCategories == 0 {
+		cfg.ExpectedCategories = defaultExpectedCategories
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = jsonDuration(defaultPartTimeout)
+	}
+	return &cfg, nil
+}
+
+// SetGraphQL replaces the GraphQL endpoint settings.
+func (rt *Router) SetGraphQL(cfg GraphQLConfig) error {
+	c, err := newGraphQLConfig(cfg)
+	if err != nil {
+		return err
+	}
+	rt.graphql.Store(c)
+	return nil
+}
+
+// === DataLoader ===
+
+// dataLoader collects the keys requested while one level of the query is
+// resolved and fetches each key once per request. The REST services have no
+// batch endpoints, so a batch is fetched with parallel calls.
+type dataLoader struct {
+	fetch   func(key string) (any, error)
+	mu      sync.Mutex
+	entries map[string]*loaderEntry
+	pending []*loaderEntry
+}
+
+type loaderEntry struct {
+	key   string
+	done  chan struct{}
+	value any
+	err   error
+}
+
+func newDataLoader(fetch func(key string) (any, error)) *dataLoader {
+	return &dataLoader{fetch: fetch, entries: map[string]*loaderEntry{}}
+}
+
+// load queues key and returns a thunk; graphql-go calls thunks after the
+// whole level has been resolved, so the first call dispatches every key.
+func (l *dataLoader) load(key string) func() (any, error) {
+	l.mu.Lock()
+	e, ok := l.entries[key]
+	if !ok {
+		e = &loaderEntry{key: key, done: make(chan struct{})}
+		l.entries[key] = e
+		l.pending = append(l.pending, e)
+	}
+	l.mu.Unlock()
+	return func() (any, error) {
+		l.dispatch()
+		<-e.done
+		return e.value, e.err
+	}
+}
+
+func (l *dataLoader) dispatch() {
+	l.mu.Lock()
+	batch := l.pending
+	l.pending = nil
+	l.mu.Unlock()
+
+	slots := make(chan struct{}, graphQLLoaderParallels)
+	for _, e := range batch {
+		go func() {
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			e.value, e.err = l.fetch(e.key)
+			close(e.done)
+		}()
+	}
+}
+
+// === Request ===
+
+// graphQLRequest is the per-request state shared by the resolvers.
+type graphQLRequest struct {
+	r          *http.Request
+	router     *Router
+	timeout    time.Duration
+	users      *dataLoader
+	posts      *dataLoader
+	categories *dataLoader
+}
+
+func newGraphQLRequest(r *http.Request, rt *Router, cfg *GraphQLConfig) *graphQLRequest {
+	g := &graphQLRequest{r: r, router: rt, timeout: time.Duration(cfg.Timeout)}
+	g.users = newDataLoader(func(username string) (any, error) {
+		return g.getOptional("user-service", "/users/"+url.PathEscape(username))
+	})
+	g.posts = newDataLoader(func(id string) (any, error) {
+		return g.getOptional("blog-service", "/blog/api/posts/"+url.PathEscape(id))
+	})
+	g.categories = newDataLoader(func(string) (any, error) {
+		return g.get("blog-service", "/blog/api/categories")
+	})
+	return g
+}
+
+func graphQLRequestFrom(ctx context.Context) *graphQLRequest {
+	g, _ := ctx.Value(graphQLContextKey).(*graphQLRequest)
+	return g
+}
+
+// graphQLError carries the upstream status to the client as an extension.
+type graphQLError struct {
+	status int
+	err    error
+}
+
+func (e *graphQLError) Error() string { return e.err.Error() }
+
+func (e *graphQLError) Extensions() map[string]any {
+	code := "UPSTREAM_ERROR"
+	switch e.status {
+	case http.StatusUnauthorized:
+		code = "UNAUTHENTICATED"
+	case http.StatusForbidden:
+		code = "FORBIDDEN"
+	}
+	return map[string]any{"code": code, "status": e.status}
+}
+
+// get calls upstream with the client's Authorization, so the services make
+// the same authorization decisions as for REST calls.
+func (g *graphQLRequest) get(upstream, target string) (any, error) {
+	graphQLUpstreamCallsTotal.WithLabelValues(upstream).Inc()
+	body, status, err := getUpstreamJSON(g.r, g.router.Upstream(upstream), target, g.timeout)
+	if err != nil {
+		return nil, &graphQLError{status: status, err: err}
+	}
+	return body, nil
+}
+
+// getOptional is get with 404 resolved as null.
+func (g *graphQLRequest) getOptional(upstream, target string) (any, error) {
+	body, err := g.get(upstream, target)
+	var gerr *graphQLError
+	if errors.As(err, &gerr) && gerr.status == http.StatusNotFound {
+		return nil, nil
+	}
+	return body, err
+}
+
+func (g *graphQLRequest) listPosts(limit, offset int, category string) (any, error) {
+	if limit < 1 || limit > maxPostsLimit || offset < 0 {
+		return nil, fmt.Errorf("limit must be between 1 and %d and offset not negative", maxPostsLimit)
+	}
+	q := url.Values{"limit": {strconv.Itoa(limit)}, "offset": {strconv.Itoa(offset)}}
+	if category != "" {
+		q.Set("category", category)
+	}
+	return g.get("blog-service", "/blog/api/posts?"+q.Encode())
+}
+
+// === Schema ===
+
+var graphQLSchema = mustGraphQLSchema()
+
+func sourceMap(p graphql.ResolveParams) map[string]any {
+	m, _ := p.Source.(map[string]any)
+	return m
+}
+
+// sourceField resolves key from the source, or from the record load returns
+// when the source (e.g. a list summary) does not have it.
+func sourceField(key string, load func(g *graphQLRequest, src map[string]any) func() (any, error)) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		src := sourceMap(p)
+		if v, ok := src[key]; ok {
+			return v, nil
+		}
+		thunk := load(graphQLRequestFrom(p.Context), src)
+		return func() (any, error) {
+			full, err := thunk()
+			m, _ := full.(map[string]any)
+			return m[key], err
+		}, nil
+	}
+}
+
+// postExcerpt matches the excerpt blog-service puts in post lists; a single
+// post has only the content.
+func postExcerpt(p graphql.ResolveParams) (any, error) {
+	src := sourceMap(p)
+	if v, ok := src["excerpt"]; ok {
+		return v, nil
+	}
+	content, _ := src["content"].(string)
+	content = strings.NewReplacer("\r", " ", "\n", " ").Replace(content)
+	if runes := []rune(content); len(runes) > 120 {
+		return string(runes[:120]) + "...", nil
+	}
+	return content, nil
+}
+
+func postDetail(g *graphQLRequest, src map[string]any) func() (any, error) {
+	id, _ := templateScalar(src["id"])
+	return g.posts.load(id)
+}
+
+func categoryDetail(g *graphQLRequest, src map[string]any) func() (any, error) {
+	thunk := g.categories.load("")
+	return func() (any, error) {
+		all, err := thunk()
+		list, _ := all.([]any)
+		for _, c := range list {
+			if m, _ := c.(map[string]any); m != nil && m["id"] == src["id"] {
+				return m, err
+			}
+		}
+		return nil, err
+	}
+}
+
+func mustGraphQLSchema() graphql.Schema {
+	paging := graphql.FieldConfigArgument{
+		"limit":  &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultPostsLimit},
+		"offset": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0},
+	}
+	user := graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.Fields{
+			"id":       &graphql.Field{Type: graphql.Int},
+			"username": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"email":    &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	var post *graphql.Object
+	category := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Category",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":        &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+				"name":      &graphql.Field{Type: graphql.String},
+				"slug":      &graphql.Field{Type: graphql.String},
+				"color":     &graphql.Field{Type: graphql.String, Resolve: sourceField("color", categoryDetail)},
+				"postCount": &graphql.Field{Type: graphql.Int, Resolve: sourceField("post_count", categoryDetail)},
+				"posts": &graphql.Field{
+					Type: graphql.NewList(graphql.NewNonNull(post)),
+					Args: paging,
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						slug, _ := sourceMap(p)["slug"].(string)
+						return graphQLRequestFrom(p.Context).listPosts(p.Args["limit"].(int), p.Args["offset"].(int), slug)
+					},
+				},
+			}
+		}),
+	})
+
+	post = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Post",
+		Fields: graphql.Fields{
+			"id":         &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"title":      &graphql.Field{Type: graphql.String},
+			"excerpt":    &graphql.Field{Type: graphql.String, Resolve: postExcerpt},
+			"content":    &graphql.Field{Type: graphql.String, Resolve: sourceField("content", postDetail)},
+			"createdAt":  &graphql.Field{Type: graphql.String, Resolve: sourceField("created_at", postDetail)},
+			"updatedAt":  &graphql.Field{Type: graphql.String, Resolve: sourceField("updated_at", postDetail)},
+			"authorName": &graphql.Field{Type: graphql.String, Resolve: sourceField("author", postDetail)},
+			"author": &graphql.Field{
+				Type: user,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					name, _ := sourceMap(p)["author"].(string)
+					if name == "" {
+						return nil, nil
+					}
+					return graphQLRequestFrom(p.Context).users.load(name), nil
+				},
+			},
+			"category": &graphql.Field{Type: category},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"user": &graphql.Field{
+				Type: user,
+				Args: graphql.FieldConfigArgument{"username": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)}},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return graphQLRequestFrom(p.Context).users.load(p.Args["username"].(string)), nil
+				},
+			},
+			"post": &graphql.Field{
+				Type: post,
+				Args: graphql.FieldConfigArgument{"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)}},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return graphQLRequestFrom(p.Context).posts.load(strconv.Itoa(p.Args["id"].(int))), nil
+				},
+			},
+			"posts": &graphql.Field{
+				Type: graphql.NewList(graphql.NewNonNull(post)),
+				Args: graphql.FieldConfigArgument{
+					"limit":    paging["limit"],
+					"offset":   paging["offset"],
+					"category": &graphql.ArgumentConfig{Type: graphql.String, Description: "category slug"},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					slug, _ := p.Args["category"].(string)
+					return graphQLRequestFrom(p.Context).listPosts(p.Args["limit"].(int), p.Args["offset"].(int), slug)
+				},
+			},
+			"categories": &graphql.Field{
+				Type: graphql.NewList(graphql.NewNonNull(category)),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return graphQLRequestFrom(p.Context).categories.load(""), nil
+				},
+			},
+		},
+	})
+
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{Query: query})
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}
+
+// === Limits ===
+
+// queryCost returns the depth and complexity of the operation that will run.
+// Every field costs 1 plus its children, which count limit times (posts
+// default to defaultPostsLimit) or, for categories, the expected number of
+// categories. Introspection fields are free. Each fragment is costed once,
+// and the cost stops growing past cfg.MaxComplexity, so nested fragment
+// spreads cannot make the walk itself expensive.
+func queryCost(doc *ast.Document, operationName string, variables map[string]any, cfg *GraphQLConfig) (depth, complexity int) {
+	fragments := map[string]*ast.FragmentDefinition{}
+	var op *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.FragmentDefinition:
+			fragments[def.Name.Value] = def
+		case *ast.OperationDefinition:
+			if op == nil && (operationName == "" || def.Name != nil && def.Name.Value == operationName) {
+				op = def
+			}
+		}
+	}
+	if op == nil {
+		return 0, 0
+	}
+
+	ceiling := cfg.MaxComplexity + 1
+	type fragmentCost struct{ depth, cost int }
+	costed := map[string]fragmentCost{}
+	visiting := map[string]bool{}
+	// walk returns the nesting depth of set's fields and their cost
+	var walk func(set *ast.SelectionSet) (int, int)
+	walk = func(set *ast.SelectionSet) (depth, cost int) {
+		if set == nil {
+			return 0, 0
+		}
+		for _, sel := range set.Selections {
+			var d, c int
+			switch sel := sel.(type) {
+			case *ast.Field:
+				if strings.HasPrefix(sel.Name.Value, "__") {
+					continue
+				}
+				childDepth, childCost := walk(sel.SelectionSet)
+				d, c = 1+childDepth, 1+listMultiplier(sel, variables, cfg.ExpectedCategories)*childCost
+			case *ast.InlineFragment:
+				d, c = walk(sel.SelectionSet)
+			case *ast.FragmentSpread:
+				name := sel.Name.Value
+				if fc, ok := costed[name]; ok {
+					d, c = fc.depth, fc.cost
+				} else if def := fragments[name]; def != nil && !visiting[name] {
+					visiting[name] = true
+					d, c = walk(def.SelectionSet)
+					delete(visiting, name)
+					costed[name] = fragmentCost{d, c}
+				}
+			}
+			depth, cost = max(depth, d), min(cost+c, ceiling)
+		}
+		return depth, cost
+	}
+	return walk(op.SelectionSet)
+}
+
+// countSelections returns the number of fields, fragment spreads and inline
+// fragments written in doc, before any fragment is expanded.
+func countSelections(doc *ast.Document) int {
+	n := 0
+	var count func(set *ast.SelectionSet)
+	count = func(set *ast.SelectionSet) {
+		if set == nil {
+			return
+		}
+		for _, sel := range set.Selections {
+			n++
+			switch sel := sel.(type) {
+			case *ast.Field:
+				count(sel.SelectionSet)
+			case *ast.InlineFragment:
+				count(sel.SelectionSet)
+			}
+		}
+	}
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.OperationDefinition:
+			count(def.SelectionSet)
+		case *ast.FragmentDefinition:
+			count(def.SelectionSet)
+		}
+	}
+	return n
+}
+
+func listMultiplier(f *ast.Field, variables map[string]any, categories int) int {
+	if f.Name.Value == "categories" {
+		return categories
+	}
+	n := 1
+	if f.Name.Value == "posts" {
+		n = defaultPostsLimit
+	}
+	for _, arg := range f.Arguments {
+		if arg.Name.Value != "limit" {
+			continue
+		}
+		switch v := arg.Value.(type) {
+		case *ast.IntValue:
+			n, _ = strconv.Atoi(v.Value)
+		case *ast.Variable:
+			if f, ok := variables[v.Name.Value].(float64); ok {
+				n = int(f)
+			}
+		}
+	}
+	return min(max(n, 1), maxPostsLimit)
+}
+
+// === Handler ===
+
+// restoreErrorExtensions sets the extensions of errors returned by thunks,
+// which graphql-go formats before wrapping them with the field location.
+func restoreErrorExtensions(errs []gqlerrors.FormattedError) {
+	for i := range errs {
+		var err error = errs[i]
+		for err != nil && errs[i].Extensions == nil {
+			switch e := err.(type) {
+			case gqlerrors.FormattedError:
+				err = e.OriginalError()
+			case *gqlerrors.Error:
+				err = e.OriginalError
+			case gqlerrors.ExtendedError:
+				errs[i].Extensions = e.Extensions()
+			default:
+				err = nil
+			}
+		}
+	}
+}
+
+type graphQLParams struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// graphQLHandler serves GET and POST /graphql when the graphql section is
+// enabled.
+func graphQLHandler(rt *Router) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg := rt.graphql.Load()
+		if cfg == nil || !cfg.Enabled {
+			notFound(w, r)
+			return
+		}
+
+		var params graphQLParams
+		switch r.Method {
+		case http.MethodGet:
+			q := r.URL.Query()
+			params.Query, params.OperationName = q.Get("query"), q.Get("operationName")
+			if v := q.Get("variables"); v != "" && json.Unmarshal([]byte(v), &params.Variables) != nil {
+				writeError(w, r, http.StatusBadRequest, "variables must be a JSON object")
+				return
+			}
+		case http.MethodPost:
+			// JSON만 허용: 단순 요청(form)으로는 교차 출처에서 보낼 수 없도록 (CORS preflight 강제)
+			if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt != "application/json" {
+				writeError(w, r, http.StatusUnsupportedMediaType, "Content-Type must be application/json")
+				return
+			}
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, graphQLMaxBodyBytes)).Decode(&params); err != nil {
+				writeError(w, r, http.StatusBadRequest, "Invalid GraphQL request body")
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			writeError(w, r, http.StatusMethodNotAllowed, "GraphQL supports GET and POST")
+			return
+		}
+		if params.Query == "" {
+			writeError(w, r, http.StatusBadRequest, "query is required")
+			return
+		}
+
+		// 구문 오류는 graphql.Do가 GraphQL 형식으로 응답
+		if doc, err := parser.Parse(parser.ParseParams{Source: params.Query}); err == nil {
+			var reason string
+			if n := countSelections(doc); n > graphQLMaxSelections {
+				reason = fmt.Sprintf("query has %d selections, more than the limit of %d", n, graphQLMaxSelections)
+			} else {
+				depth, complexity := queryCost(doc, params.OperationName, params.Variables, cfg)
+				switch {
+				case depth > cfg.MaxDepth:
+					reason = fmt.Sprintf("query depth %d exceeds the limit of %d", depth, cfg.MaxDepth)
+				case complexity > cfg.MaxComplexity:
+					reason = fmt.Sprintf("query complexity exceeds the limit of %d", cfg.MaxComplexity)
+				}
+			}
+			if reason != "" {
+				graphQLRequestsTotal.WithLabelValues("rejected").Inc()
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]any{"errors": []map[string]any{{
+					"message":    reason,
+					"extensions": map[string]any{"code": "QUERY_TOO_COMPLEX"},
+				}}})
+				return
+			}
+		}
+
+		result := graphql.Do(graphql.Params{
+			Schema:         graphQLSchema,
+			RequestString:  params.Query,
+			VariableValues: params.Variables,
+			OperationName:  params.OperationName,
+			Context:        context.WithValue(r.Context(), graphQLContextKey, newGraphQLRequest(r, rt, cfg)),
+		})
+		outcome := "ok"
+		if len(result.Errors) > 0 {
+			outcome = "error"
+			restoreErrorExtensions(result.Errors)
+		}
+		graphQLRequestsTotal.WithLabelValues(outcome).Inc()
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		json.NewEncoder(w).Encode(result)
+	})
+}
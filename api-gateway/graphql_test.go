@@ -0,0 +1,341 @@
+// api-gateway/graphql_test.go
+// 단위 테스트: GraphQL 쿼리 해석, DataLoader 배치/중복 제거, Depth/Complexity 제한, 토큰 전달과 오류 매핑
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type graphQLTestEnv struct {
+	router *Router
+	mu     sync.Mutex
+	calls  map[string]int
+	auth   []string
+}
+
+func (e *graphQLTestEnv) record(r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.calls[r.URL.Path]++
+	e.auth = append(e.auth, r.Header.Get("Authorization"))
+}
+
+func (e *graphQLTestEnv) callCount(path string) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.calls[path]
+}
+
+// newGraphQLTestEnv serves posts 1-3 (by alice, bob, alice) and two
+// categories from blog-service; user-service knows alice and bob and answers
+// 401 to the token "expired"
+func newGraphQLTestEnv(t *testing.T) *graphQLTestEnv {
+	t.Helper()
+	env := &graphQLTestEnv{calls: map[string]int{}}
+	posts := []string{
+		`{"id": 1, "title": "Hello", "author": "alice", "created_at": "2026-01-01T00:00:00", "excerpt": "Hello world", "category": {"id": 1, "name": "Tech", "slug": "tech"}}`,
+		`{"id": 2, "title": "Travel", "author": "bob", "created_at": "2026-01-02T00:00:00", "excerpt": "Trip", "category": {"id": 2, "name": "Life", "slug": "life"}}`,
+		`{"id": 3, "title": "Go", "author": "alice", "created_at": "2026-01-03T00:00:00", "excerpt": "Go go", "category": {"id": 1, "name": "Tech", "slug": "tech"}}`,
+	}
+	blog := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		env.record(r)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/blog/api/posts":
+			if r.URL.Query().Get("category") == "life" {
+				fmt.Fprintf(w, "[%s]", posts[1])
+				return
+			}
+			fmt.Fprintf(w, "[%s]", strings.Join(posts, ","))
+		case "/blog/api/posts/1", "/blog/api/posts/2", "/blog/api/posts/3":
+			id := strings.TrimPrefix(r.URL.Path, "/blog/api/posts/")
+			fmt.Fprintf(w, `{"id": %s, "title": "Post %s", "content": "Body of %s", "author": "alice", "updated_at": "2026-02-0%sT00:00:00", "category": {"id": 1, "name": "Tech", "slug": "tech"}}`, id, id, id, id)
+		case "/blog/api/categories":
+			fmt.Fprint(w, `[{"id": 1, "name": "Tech", "slug": "tech", "color": "#3B82F6", "post_count": 2}, {"id": 2, "name": "Life", "slug": "life", "color": "#22C55E", "post_count": 1}]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"detail": {"error": "Post not found"}}`)
+		}
+	}))
+	users := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		env.record(r)
+		if r.Header.Get("Authorization") == "Bearer expired" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		name := strings.TrimPrefix(r.URL.Path, "/users/")
+		if name != "alice" && name != "bob" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"id": %d, "username": %q, "email": "%s@example.com"}`, len(name), name, name)
+	}))
+	t.Cleanup(blog.Close)
+	t.Cleanup(users.Close)
+
+	upstreams := map[string]*Upstream{
+		"blog-service": newTestUpstream(t, "blog-service", blog),
+		"user-service": newTestUpstream(t, "user-service", users),
+		"auth-service": newTestUpstream(t, "auth-service", users),
+	}
+	env.router = NewRouter(defaultRoutes(), upstreams, nil)
+	if err := env.router.SetGraphQL(GraphQLConfig{Enabled: true}); err != nil {
+		t.Fatalf("SetGraphQL error: %v", err)
+	}
+	return env
+}
+
+type graphQLTestResponse struct {
+	Data   map[string]any `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+func (e *graphQLTestEnv) query(t *testing.T, token, query string, variables map[string]any) (*httptest.ResponseRecorder, graphQLTestResponse) {
+	t.Helper()
+	body, _ := json.Marshal(map[string]any{"query": query, "variables": variables})
+	req := httptest.NewRequest(http.MethodPost, graphQLPath, strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rr := httptest.NewRecorder()
+	graphQLHandler(e.router).ServeHTTP(rr, req)
+	var resp graphQLTestResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response %q: %v", rr.Body.String(), err)
+	}
+	return rr, resp
+}
+
+func jsonString(v any) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// TestGraphQLQuery tests resolving nested fields against the REST services
+func TestGraphQLQuery(t *testing.T) {
+	env := newGraphQLTestEnv(t)
+	tests := []struct {
+		name   string
+		query  string
+		expect string
+	}{
+		{
+			"게시물 목록과 작성자/카테고리",
+			`{ posts { id title author { username email } category { slug color postCount } } }`,
+			`{"posts":[{"author":{"email":"alice@example.com","username":"alice"},"category":{"color":"#3B82F6","postCount":2,"slug":"tech"},"id":1,"title":"Hello"},{"author":{"email":"bob@example.com","username":"bob"},"category":{"color":"#22C55E","postCount":1,"slug":"life"},"id":2,"title":"Travel"},{"author":{"email":"alice@example.com","username":"alice"},"category":{"color":"#3B82F6","postCount":2,"slug":"tech"},"id":3,"title":"Go"}]}`,
+		},
+		{
+			"목록에 없는 필드는 상세 조회",
+			`{ posts { id content updatedAt } }`,
+			`{"posts":[{"content":"Body of 1","id":1,"updatedAt":"2026-02-01T00:00:00"},{"content":"Body of 2","id":2,"updatedAt":"2026-02-02T00:00:00"},{"content":"Body of 3","id":3,"updatedAt":"2026-02-03T00:00:00"}]}`,
+		},
+		{
+			"단일 게시물 발췌",
+			`{ post(id: 2) { title excerpt authorName } }`,
+			`{"post":{"authorName":"alice","excerpt":"Body of 2","title":"Post 2"}}`,
+		},
+		{
+			"카테고리별 게시물",
+			`{ categories { slug posts { title } } }`,
+			`{"categories":[{"posts":[{"title":"Hello"},{"title":"Travel"},{"title":"Go"}],"slug":"tech"},{"posts":[{"title":"Travel"}],"slug":"life"}]}`,
+		},
+		{
+			"없는 게시물과 사용자는 null",
+			`{ post(id: 9) { id } user(username: "ghost") { id } }`,
+			`{"post":null,"user":null}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr, resp := env.query(t, "", tt.query, nil)
+			if rr.Code != http.StatusOK || len(resp.Errors) > 0 {
+				t.Fatalf("Status = %d, errors = %+v", rr.Code, resp.Errors)
+			}
+			if got := jsonString(resp.Data); got != tt.expect {
+				t.Errorf("data = %s; want %s", got, tt.expect)
+			}
+		})
+	}
+}
+
+// TestGraphQLDataLoader tests that each user and post is fetched once per request
+func TestGraphQLDataLoader(t *testing.T) {
+	env := newGraphQLTestEnv(t)
+	_, resp := env.query(t, "", `{
+		posts { author { username } content category { color } }
+		post(id: 1) { author { email } content }
+		user(username: "alice") { email }
+	}`, nil)
+	if len(resp.Errors) > 0 {
+		t.Fatalf("errors = %+v", resp.Errors)
+	}
+	for path, want := range map[string]int{
+		"/users/alice":         1,
+		"/users/bob":           1,
+		"/blog/api/posts/1":    1,
+		"/blog/api/posts/2":    1,
+		"/blog/api/posts/3":    1,
+		"/blog/api/categories": 1,
+		"/blog/api/posts":      1,
+	} {
+		if got := env.callCount(path); got != want {
+			t.Errorf("%s called %d times; want %d", path, got, want)
+		}
+	}
+
+	// 캐시는 요청 단위
+	env.query(t, "", `{ user(username: "alice") { email } }`, nil)
+	if got := env.callCount("/users/alice"); got != 2 {
+		t.Errorf("/users/alice called %d times after a second request; want 2", got)
+	}
+}
+
+// TestGraphQLLimits tests depth and complexity rejection before any upstream call
+func TestGraphQLLimits(t *testing.T) {
+	env := newGraphQLTestEnv(t)
+	if err := env.router.SetGraphQL(GraphQLConfig{Enabled: true, MaxDepth: 4, MaxComplexity: 100}); err != nil {
+		t.Fatal(err)
+	}
+	// Fragment마다 다음 Fragment를 40번 spread: 펼치면 40^6번 방문
+	nested := `{ categories { ...F0 } } fragment F6 on Category { name }`
+	for i := range 6 {
+		nested += fmt.Sprintf(" fragment F%d on Category {%s }", i, strings.Repeat(fmt.Sprintf(" ...F%d", i+1), 40))
+	}
+
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]any
+		rejected  bool
+	}{
+		{"제한 이내", `{ posts(limit: 10) { title author { username } } }`, nil, false},
+		{"깊이 초과", `{ posts(limit: 1) { category { posts(limit: 1) { category { slug } } } } }`, nil, true},
+		{"Fragment를 통한 깊이 초과", `query { posts(limit: 1) { ...P } } fragment P on Post { category { posts(limit: 1) { category { slug } } } }`, nil, true},
+		{"기본 limit 적용 복잡도 초과", `{ categories { posts { title excerpt author { username email } } } }`, nil, true},
+		{"categories 목록", `{ categories { name slug } }`, nil, false},
+		{"categories 하위 목록 복잡도 초과", `{ categories { posts(limit: 5) { author { username } } } }`, nil, true},
+		{"변수 limit 복잡도 초과", `query($n: Int) { posts(limit: $n) { title author { username } } }`, map[string]any{"n": 50}, true},
+		{"중첩 Fragment spread", nested, nil, true},
+		{"Selection 수 초과", "{ categories { " + strings.Repeat("name ", graphQLMaxSelections) + "} }", nil, true},
+		{"Introspection은 제외", `{ __schema { types { name fields { name type { name ofType { name ofType { name } } } } } } }`, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := env.callCount("/blog/api/posts")
+			start := time.Now()
+			rr, resp := env.query(t, "", tt.query, tt.variables)
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("query took %v", elapsed)
+			}
+			if !tt.rejected {
+				if rr.Code != http.StatusOK || len(resp.Errors) > 0 {
+					t.Fatalf("Status = %d, errors = %+v", rr.Code, resp.Errors)
+				}
+				return
+			}
+			if rr.Code != http.StatusBadRequest || len(resp.Errors) != 1 || resp.Errors[0].Extensions["code"] != "QUERY_TOO_COMPLEX" {
+				t.Fatalf("Status = %d, errors = %+v; want QUERY_TOO_COMPLEX", rr.Code, resp.Errors)
+			}
+			if env.callCount("/blog/api/posts") != before {
+				t.Error("rejected query should not reach upstreams")
+			}
+		})
+	}
+}
+
+// TestGraphQLAuthorization tests bearer token forwarding and upstream auth errors
+func TestGraphQLAuthorization(t *testing.T) {
+	env := newGraphQLTestEnv(t)
+	env.query(t, "token-1", `{ posts { author { username } } }`, nil)
+	for _, got := range env.auth {
+		if got != "Bearer token-1" {
+			t.Errorf("upstream Authorization = %q; want Bearer token-1", got)
+		}
+	}
+
+	_, resp := env.query(t, "expired", `{ user(username: "alice") { email } }`, nil)
+	if len(resp.Errors) != 1 {
+		t.Fatalf("errors = %+v; want one", resp.Errors)
+	}
+	if got := resp.Errors[0].Extensions["code"]; got != "UNAUTHENTICATED" {
+		t.Errorf("extensions.code = %v; want UNAUTHENTICATED", got)
+	}
+	if resp.Data["user"] != nil {
+		t.Errorf("user = %v; want null", resp.Data["user"])
+	}
+}
+
+// TestGraphQLHTTP tests the transport: methods, content types and the enabled flag
+func TestGraphQLHTTP(t *testing.T) {
+	env := newGraphQLTestEnv(t)
+	handler := graphQLHandler(env.router)
+	query := url.QueryEscape(`{ user(username: "bob") { email } }`)
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		contentType string
+		body        string
+		expectCode  int
+	}{
+		{"GET 쿼리", http.MethodGet, graphQLPath + "?query=" + query, "", "", http.StatusOK},
+		{"POST JSON", http.MethodPost, graphQLPath, "application/json; charset=utf-8", `{"query": "{ categories { slug } }"}`, http.StatusOK},
+		{"JSON이 아닌 POST", http.MethodPost, graphQLPath, "application/x-www-form-urlencoded", "query=x", http.StatusUnsupportedMediaType},
+		{"잘못된 JSON", http.MethodPost, graphQLPath, "application/json", "{", http.StatusBadRequest},
+		{"쿼리 없음", http.MethodGet, graphQLPath, "", "", http.StatusBadRequest},
+		{"허용되지 않는 메서드", http.MethodPut, graphQLPath, "application/json", "{}", http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tt.expectCode {
+				t.Errorf("Status = %d; want %d (%s)", rr.Code, tt.expectCode, rr.Body.String())
+			}
+		})
+	}
+
+	if err := env.router.SetGraphQL(GraphQLConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, graphQLPath+"?query="+query, nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("disabled endpoint Status = %d; want 404", rr.Code)
+	}
+}
+
+// TestGraphQLConfigErrors tests rejected graphql sections
+func TestGraphQLConfigErrors(t *testing.T) {
+	router := newTestRouter(t)
+	for _, cfg := range []GraphQLConfig{
+		{Enabled: true, MaxDepth: -1},
+		{Enabled: true, MaxComplexity: -5},
+		{Enabled: true, ExpectedCategories: -1},
+		{Enabled: true, Timeout: -1},
+	} {
+		if err := router.SetGraphQL(cfg); err == nil {
+			t.Errorf("SetGraphQL(%+v) should fail", cfg)
+		}
+	}
+}
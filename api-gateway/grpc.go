@@ -0,0 +1,506 @@
+// api-gateway/grpc.go
+// gRPC 프록시: 서비스 경로(/package.Service/Method)별 h2c Upstream 라우팅, gRPC-Web → gRPC 변환, gRPC status 메트릭
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	grpcRequestsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "grpc_requests_total",
+			Help: "Total number of gRPC calls by service, method, protocol (grpc, grpc-web, grpc-web-text) and gRPC status code",
+		},
+		[]string{"service", "method", "protocol", "code"},
+	)
+	grpcRequestDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "grpc_request_duration_seconds",
+			Help:    "Duration of gRPC calls until the status was sent",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"service", "method"},
+	)
+)
+
+const (
+	// h2cScheme marks an upstream URL that speaks HTTP/2 without TLS
+	// (h2c://notification-service:50051)
+	h2cScheme = "h2c"
+
+	grpcNative  = "grpc"
+	grpcWeb     = "grpc-web"
+	grpcWebText = "grpc-web-text"
+
+	// grpcTrailerFlag marks the gRPC-Web frame that carries the trailers
+	grpcTrailerFlag = 0x80
+)
+
+var grpcName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+// h2cTransport sends requests to h2c:// upstreams as HTTP/2 with prior
+// knowledge, which gRPC needs for trailers and bidirectional streams.
+var h2cTransport = newH2CTransport()
+
+func newH2CTransport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Protocols = new(http.Protocols)
+	t.Protocols.SetUnencryptedHTTP2(true)
+	return t
+}
+
+// grpcCode is a gRPC status code.
+type grpcCode int
+
+const (
+	grpcOK                grpcCode = 0
+	grpcCanceled          grpcCode = 1
+	grpcUnknown           grpcCode = 2
+	grpcInvalidArgument   grpcCode = 3
+	grpcDeadlineExceeded  grpcCode = 4
+	grpcPermissionDenied  grpcCode = 7
+	grpcResourceExhausted grpcCode = 8
+	grpcUnimplemented     grpcCode = 12
+	grpcInternal          grpcCode = 13
+	grpcUnavailable       grpcCode = 14
+	grpcUnauthenticated   grpcCode = 16
+)
+
+var grpcCodeNames = []string{
+	"OK", "Canceled", "Unknown", "InvalidArgument", "DeadlineExceeded", "NotFound",
+	"AlreadyExists", "PermissionDenied", "ResourceExhausted", "FailedPrecondition",
+	"Aborted", "OutOfRange", "Unimplemented", "Internal", "Unavailable", "DataLoss",
+	"Unauthenticated",
+}
+
+func (c grpcCode) String() string {
+	return grpcCodeNames[c]
+}
+
+// parseGRPCCode reads a grpc-status value; anything invalid is Unknown.
+func parseGRPCCode(v string) grpcCode {
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 || n >= len(grpcCodeNames) {
+		return grpcUnknown
+	}
+	return grpcCode(n)
+}
+
+// grpcCodeFromHTTP maps an HTTP status to a gRPC code following the gRPC
+// HTTP-to-status table, plus the statuses the gateway itself produces (413,
+// 499, 504).
+func grpcCodeFromHTTP(status int) grpcCode {
+	switch status {
+	case http.StatusOK:
+		return grpcOK
+	case http.StatusBadRequest:
+		return grpcInternal
+	case http.StatusUnauthorized:
+		return grpcUnauthenticated
+	case http.StatusForbidden:
+		return grpcPermissionDenied
+	case http.StatusNotFound:
+		return grpcUnimplemented
+	case http.StatusRequestEntityTooLarge:
+		return grpcResourceExhausted
+	case statusClientClosedRequest:
+		return grpcCanceled
+	case http.StatusGatewayTimeout:
+		return grpcDeadlineExceeded
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable:
+		return grpcUnavailable
+	default:
+		return grpcUnknown
+	}
+}
+
+// GRPCServiceConfig routes one gRPC service ("grpc" section of the config
+// file, keyed by the fully-qualified service name).
+type GRPCServiceConfig struct {
+	// Upstream must use an h2c:// or https:// URL
+	Upstream string `json:"upstream"`
+	// Methods limits the callable methods; empty allows all
+	Methods []string `json:"methods,omitempty"`
+	// Streaming lists the streaming methods. Only these are exempt from the
+	// server's read/write timeouts; they are closed after IdleTimeout without
+	// a message in either direction instead.
+	Streaming   []string     `json:"streaming,omitempty"`
+	IdleTimeout jsonDuration `json:"idle_timeout,omitempty"`
+}
+
+func (rt *Router) newGRPCConfigs(configs map[string]GRPCServiceConfig) (map[string]GRPCServiceConfig, error) {
+	for service, cfg := range configs {
+		if !grpcName.MatchString(service) {
+			return nil, fmt.Errorf("grpc: invalid service name %q", service)
+		}
+		u := rt.Upstream(cfg.Upstream)
+		if u == nil {
+			return nil, fmt.Errorf("grpc %s: unknown upstream %q", service, cfg.Upstream)
+		}
+		if u.Target.Scheme != h2cScheme && u.Target.Scheme != "https" {
+			return nil, fmt.Errorf("grpc %s: upstream %s must use an h2c:// or https:// URL", service, cfg.Upstream)
+		}
+		for _, m := range slices.Concat(cfg.Methods, cfg.Streaming) {
+			if !grpcName.MatchString(m) || strings.Contains(m, ".") {
+				return nil, fmt.Errorf("grpc %s: invalid method %q", service, m)
+			}
+		}
+		if cfg.IdleTimeout < 0 {
+			return nil, fmt.Errorf("grpc %s: idle_timeout must not be negative", service)
+		}
+		if cfg.IdleTimeout == 0 {
+			cfg.IdleTimeout = jsonDuration(defaultStreamIdleTimeout)
+		}
+		configs[service] = cfg
+	}
+	return configs, nil
+}
+
+// SetGRPCServices replaces the gRPC service routes.
+func (rt *Router) SetGRPCServices(configs map[string]GRPCServiceConfig) error {
+	services, err := rt.newGRPCConfigs(configs)
+	if err != nil {
+		return err
+	}
+	rt.grpc.Store(&services)
+	return nil
+}
+
+func (rt *Router) grpcService(name string) (GRPCServiceConfig, bool) {
+	services := rt.grpc.Load()
+	if services == nil {
+		return GRPCServiceConfig{}, false
+	}
+	cfg, ok := (*services)[name]
+	return cfg, ok
+}
+
+// grpcProtocol reports whether r is a gRPC, gRPC-Web or gRPC-Web text call,
+// by its Content-Type (application/grpc, application/grpc-web+proto, ...).
+func grpcProtocol(r *http.Request) string {
+	ct := r.Header.Get("Content-Type")
+	for _, protocol := range []string{grpcWebText, grpcWeb, grpcNative} {
+		if rest, ok := strings.CutPrefix(ct, "application/"+protocol); ok && (rest == "" || rest[0] == '+' || rest[0] == ';') {
+			return protocol
+		}
+	}
+	return ""
+}
+
+// splitGRPCPath splits "/package.Service/Method".
+func splitGRPCPath(path string) (service, method string, ok bool) {
+	service, method, ok = strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	return service, method, ok && service != "" && method != "" && !strings.Contains(method, "/")
+}
+
+// writeGRPCStatus answers with a trailers-only response: HTTP 200 with the
+// status in the headers, which gRPC and gRPC-Web clients both read.
+func writeGRPCStatus(w http.ResponseWriter, r *http.Request, code grpcCode, message string) {
+	h := w.Header()
+	h.Set("Content-Type", r.Header.Get("Content-Type"))
+	h.Del("Content-Length")
+	h.Set("Grpc-Status", strconv.Itoa(int(code)))
+	if message != "" {
+		h.Set("Grpc-Message", encodeGRPCMessage(message))
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// encodeGRPCMessage percent-encodes grpc-message as the gRPC spec requires.
+func encodeGRPCMessage(msg string) string {
+	var b strings.Builder
+	for i := 0; i < len(msg); i++ {
+		c := msg[i]
+		if c < ' ' || c > '~' || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// grpcStatusOf returns the status of a finished response: grpc-status from
+// the trailers (or the headers of a trailers-only response), or the mapped
+// HTTP status when the upstream did not answer 200.
+func grpcStatusOf(status int, h http.Header) grpcCode {
+	if status != http.StatusOK {
+		return grpcCodeFromHTTP(status)
+	}
+	for _, key := range []string{http.TrailerPrefix + "Grpc-Status", "Grpc-Status"} {
+		if v := h[key]; len(v) > 0 {
+			return parseGRPCCode(v[0])
+		}
+	}
+	return grpcUnknown
+}
+
+// grpcHandler proxies gRPC and gRPC-Web calls to the upstream configured for
+// their service and passes every other request to next.
+func grpcHandler(rt *Router, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		protocol := grpcProtocol(r)
+		if protocol == "" || r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+		start := time.Now()
+		service, method, ok := splitGRPCPath(r.URL.Path)
+		cfg, known := rt.grpcService(service)
+		if !ok || !known {
+			writeGRPCStatus(w, r, grpcUnimplemented, "unknown service "+service)
+			recordGRPCCall("unknown", "unknown", protocol, grpcUnimplemented, start)
+			return
+		}
+		if len(cfg.Methods) > 0 && !slices.Contains(cfg.Methods, method) {
+			writeGRPCStatus(w, r, grpcUnimplemented, "unknown method "+method+" for service "+service)
+			recordGRPCCall(service, "unknown", protocol, grpcUnimplemented, start)
+			return
+		}
+
+		if slices.Contains(cfg.Streaming, method) {
+			// 스트리밍 RPC는 Server의 Read/WriteTimeout보다 오래 열려 있으므로 SSE처럼 deadline을 해제하고 idle timeout 적용
+			rc := http.NewResponseController(w)
+			rc.SetReadDeadline(time.Time{})
+			rc.SetWriteDeadline(time.Time{})
+			ctx, cancel := context.WithCancel(r.Context())
+			defer cancel()
+			idle := newIdleWatch(time.Duration(cfg.IdleTimeout), cancel)
+			defer idle.stop()
+			w = &streamWriter{ResponseWriter: w, idle: idle}
+			r = r.WithContext(ctx)
+			r.Body = &idleBody{ReadCloser: r.Body, idle: idle}
+		}
+
+		rw := wrapResponseWriter(w)
+		var out http.ResponseWriter = rw
+		var web *grpcWebWriter
+		if protocol != grpcNative {
+			translated, err := grpcWebRequest(r, protocol)
+			if err != nil {
+				code := grpcInvalidArgument
+				if errors.As(err, new(*http.MaxBytesError)) {
+					code = grpcResourceExhausted
+				}
+				writeGRPCStatus(rw, r, code, "invalid gRPC-Web request body")
+				recordGRPCCall(service, method, protocol, code, start)
+				return
+			}
+			r = translated
+			web = &grpcWebWriter{ResponseWriter: rw, protocol: protocol}
+			out = web
+		}
+
+		rt.Upstream(cfg.Upstream).ServeHTTP(out, r)
+		code := grpcStatusOf(rw.status, rw.Header())
+		if web != nil {
+			web.finish()
+		}
+		// 존재하지 않는 메서드 이름으로 label이 늘어나지 않도록
+		if code == grpcUnimplemented && len(cfg.Methods) == 0 {
+			method = "unknown"
+		}
+		recordGRPCCall(service, method, protocol, code, start)
+	})
+}
+
+func recordGRPCCall(service, method, protocol string, code grpcCode, start time.Time) {
+	grpcRequestsTotal.WithLabelValues(service, method, protocol, code.String()).Inc()
+	grpcRequestDuration.WithLabelValues(service, method).Observe(time.Since(start).Seconds())
+}
+
+// === gRPC-Web ===
+
+// grpcWebRequest turns a gRPC-Web request into the gRPC request the upstream
+// expects. The framing is the same; text requests are base64 encoded.
+func grpcWebRequest(r *http.Request, protocol string) (*http.Request, error) {
+	out := r.Clone(r.Context())
+	suffix := strings.TrimPrefix(r.Header.Get("Content-Type"), "application/"+protocol)
+	out.Header.Set("Content-Type", "application/grpc"+suffix)
+	out.Header.Set("Te", "trailers")
+	out.Header.Del("X-Grpc-Web")
+	if protocol == grpcWebText {
+		// gRPC-Web은 unary와 server streaming만 지원하므로 요청 본문은 메시지 하나
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		decoded, err := decodeGRPCWebText(body)
+		if err != nil {
+			return nil, err
+		}
+		out.Body = io.NopCloser(bytes.NewReader(decoded))
+		out.ContentLength = int64(len(decoded))
+		out.Header.Del("Content-Length")
+	}
+	return out, nil
+}
+
+// decodeGRPCWebText decodes a grpc-web-text body, which may be several
+// base64 chunks each with its own padding.
+func decodeGRPCWebText(b []byte) ([]byte, error) {
+	b = bytes.Join(bytes.Fields(b), nil)
+	var out []byte
+	for len(b) > 0 {
+		n := len(b)
+		if i := bytes.IndexByte(b, '='); i >= 0 {
+			n = min(n, (i/4+1)*4)
+		}
+		chunk, err := base64.StdEncoding.DecodeString(string(b[:n]))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, chunk...)
+		b = b[n:]
+	}
+	return out, nil
+}
+
+// grpcWebWriter turns the upstream's gRPC response into gRPC-Web: the
+// Content-Type is renamed, and the trailers are sent at the end of the body
+// as a frame flagged 0x80, since browsers cannot read HTTP trailers. Text
+// responses encode every write as its own base64 chunk (as Envoy does).
+type grpcWebWriter struct {
+	http.ResponseWriter
+	protocol    string
+	wroteHeader bool
+	announced   []string
+}
+
+func (w *grpcWebWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	h := w.Header()
+	for _, v := range h.Values("Trailer") {
+		for _, k := range strings.Split(v, ",") {
+			w.announced = append(w.announced, http.CanonicalHeaderKey(strings.TrimSpace(k)))
+		}
+	}
+	h.Del("Trailer")
+	h.Del("Content-Length")
+	if rest, ok := strings.CutPrefix(h.Get("Content-Type"), "application/grpc"); ok {
+		rest = strings.TrimPrefix(strings.TrimPrefix(rest, "-web-text"), "-web")
+		h.Set("Content-Type", "application/"+w.protocol+rest)
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *grpcWebWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.protocol != grpcWebText {
+		return w.ResponseWriter.Write(b)
+	}
+	if _, err := io.WriteString(w.ResponseWriter, base64.StdEncoding.EncodeToString(b)); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (w *grpcWebWriter) FlushError() error {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+func (w *grpcWebWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// finish moves the trailers out of the header map into the trailer frame.
+// Trailers-only responses already carry the status in the headers.
+func (w *grpcWebWriter) finish() {
+	h := w.Header()
+	var trailer bytes.Buffer
+	writeTrailer := func(key string, values []string) {
+		for _, v := range values {
+			trailer.WriteString(strings.ToLower(key) + ": " + v + "\r\n")
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(h)) {
+		if name, ok := strings.CutPrefix(key, http.TrailerPrefix); ok {
+			writeTrailer(name, h[key])
+			delete(h, key)
+		}
+	}
+	for _, key := range w.announced {
+		writeTrailer(key, h[key])
+		delete(h, key)
+	}
+	if !w.wroteHeader || trailer.Len() == 0 {
+		return
+	}
+	frame := make([]byte, 5, 5+trailer.Len())
+	frame[0] = grpcTrailerFlag
+	length := trailer.Len()
+	frame[1], frame[2], frame[3], frame[4] = byte(length>>24), byte(length>>16), byte(length>>8), byte(length)
+	w.Write(append(frame, trailer.Bytes()...))
+}
+
+// === Health Check ===
+
+// grpcHealthServing is the framed grpc.health.v1.HealthCheckResponse
+// {status: SERVING}.
+var grpcHealthServing = []byte{0, 0, 0, 0, 2, 0x08, 0x01}
+
+// checkGRPCHealth calls grpc.health.v1.Health/Check for the whole server,
+// since gRPC upstreams do not serve GET /health.
+func checkGRPCHealth(client *http.Client, target *url.URL) error {
+	u := *target
+	u.Scheme, u.Path = "http", "/grpc.health.v1.Health/Check"
+	if target.Scheme != h2cScheme {
+		u.Scheme = target.Scheme
+	}
+	// 빈 HealthCheckRequest (service = "" 는 서버 전체)
+	req, err := http.NewRequest(http.MethodPost, u.String(), bytes.NewReader(make([]byte, 5)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("Te", "trailers")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64))
+	if err != nil {
+		return err
+	}
+	code := grpcCodeFromHTTP(resp.StatusCode)
+	if resp.StatusCode == http.StatusOK {
+		code = parseGRPCCode(resp.Trailer.Get("Grpc-Status"))
+		if v := resp.Header.Get("Grpc-Status"); v != "" {
+			code = parseGRPCCode(v)
+		}
+	}
+	if code != grpcOK {
+		return fmt.Errorf("grpc-status %s", code)
+	}
+	if !bytes.Equal(body, grpcHealthServing) {
+		return errors.New("not serving")
+	}
+	return nil
+}
@@ -0,0 +1,394 @@
+// api-gateway/grpc_test.go
+// 단위 테스트: h2c gRPC 프록시와 Trailer 전달, gRPC-Web(binary/text) 변환, gRPC 오류 응답과 메트릭, gRPC 헬스 체크
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+const testGRPCService = "notification.v1.Notifier"
+
+// grpcFrame frames msg as a length-prefixed gRPC message.
+func grpcFrame(flag byte, msg string) []byte {
+	frame := []byte{flag, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(msg)))
+	return append(frame, msg...)
+}
+
+// newH2CServer starts handler accepting HTTP/1.1 and HTTP/2 with prior knowledge
+func newH2CServer(t *testing.T, handler http.Handler) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewUnstartedServer(handler)
+	srv.Config.Protocols = new(http.Protocols)
+	srv.Config.Protocols.SetHTTP1(true)
+	srv.Config.Protocols.SetUnencryptedHTTP2(true)
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// newFakeGRPCServer echoes Send requests with grpc-status 0 in the trailers,
+// answers Lookup trailers-only with NotFound, streams five Subscribe events,
+// leaves Watch open after one event and serves the health service
+func newFakeGRPCServer(t *testing.T, protoMajor *atomic.Int32) *httptest.Server {
+	t.Helper()
+	return newH2CServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		protoMajor.Store(int32(r.ProtoMajor))
+		if r.Header.Get("Content-Type") != "application/grpc+proto" && r.Header.Get("Content-Type") != "application/grpc" || r.Header.Get("Te") != "trailers" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		w.Header().Set("Content-Type", "application/grpc+proto")
+		switch r.URL.Path {
+		case "/" + testGRPCService + "/Send":
+			body, _ := io.ReadAll(r.Body)
+			w.Write(body)
+			w.Header().Set(http.TrailerPrefix+"Grpc-Status", "0")
+			w.Header().Set(http.TrailerPrefix+"Grpc-Message", "")
+		case "/" + testGRPCService + "/Lookup":
+			w.Header().Set("Grpc-Status", "5")
+			w.Header().Set("Grpc-Message", "no such user")
+		case "/" + testGRPCService + "/Subscribe":
+			for i := range 5 {
+				time.Sleep(30 * time.Millisecond)
+				w.Write(grpcFrame(0, fmt.Sprintf("event-%d", i)))
+				http.NewResponseController(w).Flush()
+			}
+			w.Header().Set(http.TrailerPrefix+"Grpc-Status", "0")
+		case "/" + testGRPCService + "/Watch":
+			w.Write(grpcFrame(0, "event-0"))
+			http.NewResponseController(w).Flush()
+			<-r.Context().Done()
+		case "/grpc.health.v1.Health/Check":
+			w.Write(grpcHealthServing)
+			w.Header().Set(http.TrailerPrefix+"Grpc-Status", "0")
+		default:
+			w.Header().Set("Grpc-Status", "12")
+		}
+	}))
+}
+
+type grpcTestEnv struct {
+	gateway    *httptest.Server
+	router     *Router
+	upstream   *Upstream
+	protoMajor atomic.Int32
+}
+
+func newGRPCTestEnv(t *testing.T) *grpcTestEnv {
+	t.Helper()
+	env := &grpcTestEnv{}
+	backend := newFakeGRPCServer(t, &env.protoMajor)
+	target, _ := url.Parse(strings.Replace(backend.URL, "http://", "h2c://", 1))
+	env.upstream = NewUpstream("notification-service", target, newCircuitBreaker("notification-service", 3, time.Minute), nil)
+	upstreams := map[string]*Upstream{"notification-service": env.upstream}
+	for _, name := range []string{"user-service", "auth-service", "blog-service"} {
+		upstreams[name] = newTestUpstream(t, name, newTestBackend(t, name))
+	}
+	env.router = NewRouter(defaultRoutes(), upstreams, nil)
+	err := env.router.SetGRPCServices(map[string]GRPCServiceConfig{
+		testGRPCService: {
+			Upstream:    "notification-service",
+			Methods:     []string{"Send", "Lookup", "Subscribe", "Watch"},
+			Streaming:   []string{"Subscribe", "Watch"},
+			IdleTimeout: jsonDuration(200 * time.Millisecond),
+		},
+		"grpc.health.v1.Health": {Upstream: "notification-service"},
+	})
+	if err != nil {
+		t.Fatalf("SetGRPCServices error: %v", err)
+	}
+	env.gateway = newH2CServer(t, newGatewayMux(env.router))
+	return env
+}
+
+// call sends a gRPC request over h2c and returns the response with its body read
+func (e *grpcTestEnv) call(t *testing.T, method, contentType string, body []byte) (*http.Response, []byte) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, e.gateway.URL+method, bytes.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Te", "trailers")
+	resp, err := (&http.Client{Transport: newH2CTransport()}).Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp, data
+}
+
+// TestGRPCProxy tests gRPC over h2c end to end, including trailers and status metrics
+func TestGRPCProxy(t *testing.T) {
+	env := newGRPCTestEnv(t)
+	send := grpcRequestsTotal.WithLabelValues(testGRPCService, "Send", grpcNative, "OK")
+	lookup := grpcRequestsTotal.WithLabelValues(testGRPCService, "Lookup", grpcNative, "NotFound")
+	beforeSend, beforeLookup := testutil.ToFloat64(send), testutil.ToFloat64(lookup)
+
+	msg := grpcFrame(0, "hello")
+	resp, body := env.call(t, "/"+testGRPCService+"/Send", "application/grpc+proto", msg)
+	if resp.ProtoMajor != 2 || env.protoMajor.Load() != 2 {
+		t.Errorf("protocol client=%d upstream=%d; want HTTP/2 on both sides", resp.ProtoMajor, env.protoMajor.Load())
+	}
+	if !bytes.Equal(body, msg) {
+		t.Errorf("body = %q; want %q", body, msg)
+	}
+	if got := resp.Trailer.Get("Grpc-Status"); got != "0" {
+		t.Errorf("trailer grpc-status = %q; want 0", got)
+	}
+
+	resp, _ = env.call(t, "/"+testGRPCService+"/Lookup", "application/grpc", grpcFrame(0, "ghost"))
+	if resp.Header.Get("Grpc-Status") != "5" || resp.Header.Get("Grpc-Message") != "no such user" {
+		t.Errorf("trailers-only response headers = %v", resp.Header)
+	}
+
+	if got := testutil.ToFloat64(send) - beforeSend; got != 1 {
+		t.Errorf("grpc_requests_total{Send, OK} increase = %v; want 1", got)
+	}
+	if got := testutil.ToFloat64(lookup) - beforeLookup; got != 1 {
+		t.Errorf("grpc_requests_total{Lookup, NotFound} increase = %v; want 1", got)
+	}
+}
+
+// TestGRPCErrors tests gateway errors answered as gRPC statuses
+func TestGRPCErrors(t *testing.T) {
+	env := newGRPCTestEnv(t)
+	tests := []struct {
+		name        string
+		method      string
+		setup       func()
+		expectCode  string
+		expectError string
+	}{
+		{"등록되지 않은 서비스", "/other.v1.Service/Call", nil, "12", "unknown service other.v1.Service"},
+		{"허용되지 않은 메서드", "/" + testGRPCService + "/Delete", nil, "12", "unknown method Delete for service " + testGRPCService},
+		{"Drain 중인 Upstream", "/" + testGRPCService + "/Send", func() { env.upstream.SetDraining(true) }, "14", "Service Unavailable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setup != nil {
+				tt.setup()
+			}
+			resp, body := env.call(t, tt.method, "application/grpc", grpcFrame(0, "x"))
+			if resp.StatusCode != http.StatusOK || len(body) != 0 {
+				t.Errorf("Status = %d, body = %q; want a trailers-only 200", resp.StatusCode, body)
+			}
+			if got := resp.Header.Get("Grpc-Status"); got != tt.expectCode {
+				t.Errorf("grpc-status = %q; want %s", got, tt.expectCode)
+			}
+			if got := resp.Header.Get("Grpc-Message"); got != tt.expectError {
+				t.Errorf("grpc-message = %q; want %q", got, tt.expectError)
+			}
+		})
+	}
+
+	// gRPC가 아닌 요청은 기존 404 problem+json
+	resp, err := http.Get(env.gateway.URL + "/" + testGRPCService + "/Send")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound || resp.Header.Get("Content-Type") != problemContentType {
+		t.Errorf("non-gRPC request: Status = %d, Content-Type = %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+}
+
+// TestGRPCUnreachableUpstream tests that transport errors become Unavailable
+func TestGRPCUnreachableUpstream(t *testing.T) {
+	env := newGRPCTestEnv(t)
+	target, _ := url.Parse("h2c://127.0.0.1:1")
+	env.router.upstreams["notification-service"] = NewUpstream("notification-service", target, newCircuitBreaker("notification-service", 3, time.Minute), nil)
+
+	resp, _ := env.call(t, "/"+testGRPCService+"/Send", "application/grpc", grpcFrame(0, "x"))
+	if got := resp.Header.Get("Grpc-Status"); got != "14" {
+		t.Errorf("grpc-status = %q; want 14 (Unavailable)", got)
+	}
+}
+
+// TestGRPCWeb tests gRPC-Web binary and text requests translated to gRPC
+func TestGRPCWeb(t *testing.T) {
+	env := newGRPCTestEnv(t)
+	msg := grpcFrame(0, "hello")
+	trailer := grpcFrame(grpcTrailerFlag, "grpc-message: \r\ngrpc-status: 0\r\n")
+
+	tests := []struct {
+		name              string
+		contentType       string
+		body              []byte
+		expectContentType string
+		decode            func([]byte) []byte
+	}{
+		{"binary", "application/grpc-web+proto", msg, "application/grpc-web+proto", func(b []byte) []byte { return b }},
+		{
+			"text", "application/grpc-web-text+proto", []byte(base64.StdEncoding.EncodeToString(msg)), "application/grpc-web-text+proto",
+			func(b []byte) []byte {
+				decoded, err := decodeGRPCWebText(b)
+				if err != nil {
+					t.Fatalf("invalid grpc-web-text response %q: %v", b, err)
+				}
+				return decoded
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/"+testGRPCService+"/Send", bytes.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			req.Header.Set("X-Grpc-Web", "1")
+			rr := httptest.NewRecorder()
+			newGatewayMux(env.router).ServeHTTP(rr, req)
+
+			if got := rr.Header().Get("Content-Type"); got != tt.expectContentType {
+				t.Errorf("Content-Type = %q; want %q", got, tt.expectContentType)
+			}
+			if got := tt.decode(rr.Body.Bytes()); !bytes.Equal(got, append(msg, trailer...)) {
+				t.Errorf("body = %q; want message and trailer frames %q", got, append(msg, trailer...))
+			}
+			for k := range rr.Header() {
+				if strings.HasPrefix(k, http.TrailerPrefix) || k == "Trailer" || k == "Grpc-Status" {
+					t.Errorf("trailer %s left in the HTTP headers", k)
+				}
+			}
+		})
+	}
+
+	// Trailers-only 응답은 헤더로 전달
+	req := httptest.NewRequest(http.MethodPost, "/"+testGRPCService+"/Lookup", bytes.NewReader(msg))
+	req.Header.Set("Content-Type", "application/grpc-web+proto")
+	rr := httptest.NewRecorder()
+	newGatewayMux(env.router).ServeHTTP(rr, req)
+	if rr.Header().Get("Grpc-Status") != "5" || rr.Body.Len() != 0 {
+		t.Errorf("trailers-only gRPC-Web: headers = %v, body = %q", rr.Header(), rr.Body.String())
+	}
+}
+
+// TestDecodeGRPCWebText tests base64 bodies made of separately padded chunks
+func TestDecodeGRPCWebText(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		expect string
+	}{
+		{"단일 chunk", base64.StdEncoding.EncodeToString([]byte("hello")), "hello"},
+		{"패딩된 chunk 연결", base64.StdEncoding.EncodeToString([]byte("a")) + base64.StdEncoding.EncodeToString([]byte("bc")), "abc"},
+		{"줄바꿈 포함", "aGVs\r\nbG8=", "hello"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeGRPCWebText([]byte(tt.input))
+			if err != nil || string(got) != tt.expect {
+				t.Errorf("decodeGRPCWebText(%q) = %q, %v; want %q", tt.input, got, err, tt.expect)
+			}
+		})
+	}
+	if _, err := decodeGRPCWebText([]byte("not base64!")); err == nil {
+		t.Error("invalid base64 should fail")
+	}
+}
+
+// TestGRPCHealthCheck tests that h2c upstreams are probed with the gRPC health service
+func TestGRPCHealthCheck(t *testing.T) {
+	env := newGRPCTestEnv(t)
+	env.upstream.checkHealth(&http.Client{Timeout: 2 * time.Second})
+	if h := env.upstream.Health(); !h.Healthy {
+		t.Errorf("health = %+v; want healthy", h)
+	}
+
+	target, _ := url.Parse("h2c://127.0.0.1:1")
+	down := NewUpstream("down", target, newCircuitBreaker("down", 3, time.Minute), nil)
+	down.checkHealth(&http.Client{Timeout: 2 * time.Second})
+	if h := down.Health(); h.Healthy || h.Error == "" {
+		t.Errorf("health = %+v; want unhealthy with an error", h)
+	}
+}
+
+// TestGRPCStreaming tests that only streaming methods outlive the server's
+// write timeout and that idle streams are closed
+func TestGRPCStreaming(t *testing.T) {
+	env := newGRPCTestEnv(t)
+	srv := httptest.NewUnstartedServer(newGatewayMux(env.router))
+	srv.Config.Protocols = new(http.Protocols)
+	srv.Config.Protocols.SetUnencryptedHTTP2(true)
+	srv.Config.WriteTimeout = 100 * time.Millisecond
+	srv.Start()
+	t.Cleanup(srv.Close)
+	env.gateway = srv
+
+	t.Run("스트리밍 메서드는 WriteTimeout 이후에도 유지", func(t *testing.T) {
+		resp, body := env.call(t, "/"+testGRPCService+"/Subscribe", "application/grpc", nil)
+		if !bytes.Contains(body, []byte("event-4")) || resp.Trailer.Get("Grpc-Status") != "0" {
+			t.Errorf("body = %q, grpc-status %q; want all events and status 0", body, resp.Trailer.Get("Grpc-Status"))
+		}
+	})
+
+	t.Run("idle_timeout 동안 메시지가 없으면 종료", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodPost, env.gateway.URL+"/"+testGRPCService+"/Watch", nil)
+		req.Header.Set("Content-Type", "application/grpc")
+		req.Header.Set("Te", "trailers")
+		start := time.Now()
+		resp, err := (&http.Client{Transport: newH2CTransport()}).Do(req)
+		if err == nil {
+			io.ReadAll(resp.Body)
+			resp.Body.Close()
+		}
+		if ctx.Err() != nil || time.Since(start) < 200*time.Millisecond {
+			t.Errorf("Watch closed after %v (ctx %v); want closed by the 200ms idle timeout", time.Since(start), ctx.Err())
+		}
+	})
+}
+
+// TestGRPCConfigErrors tests rejected grpc sections
+func TestGRPCConfigErrors(t *testing.T) {
+	env := newGRPCTestEnv(t)
+	for _, cfg := range []map[string]GRPCServiceConfig{
+		{"bad/name": {Upstream: "notification-service"}},
+		{testGRPCService: {Upstream: "nope"}},
+		{testGRPCService: {Upstream: "user-service"}},
+		{testGRPCService: {Upstream: "notification-service", Methods: []string{"pkg.Send"}}},
+		{testGRPCService: {Upstream: "notification-service", Streaming: []string{"a/b"}}},
+		{testGRPCService: {Upstream: "notification-service", IdleTimeout: jsonDuration(-time.Second)}},
+	} {
+		if err := env.router.SetGRPCServices(cfg); err == nil {
+			t.Errorf("SetGRPCServices(%+v) should fail", cfg)
+		}
+	}
+}
+
+// TestGRPCCodeFromHTTP tests the HTTP to gRPC status mapping
+func TestGRPCCodeFromHTTP(t *testing.T) {
+	for status, want := range map[int]grpcCode{
+		http.StatusOK:                    grpcOK,
+		http.StatusBadRequest:            grpcInternal,
+		http.StatusUnauthorized:          grpcUnauthenticated,
+		http.StatusForbidden:             grpcPermissionDenied,
+		http.StatusNotFound:              grpcUnimplemented,
+		http.StatusTooManyRequests:       grpcUnavailable,
+		http.StatusServiceUnavailable:    grpcUnavailable,
+		http.StatusGatewayTimeout:        grpcDeadlineExceeded,
+		statusClientClosedRequest:        grpcCanceled,
+		http.StatusRequestEntityTooLarge: grpcResourceExhausted,
+		http.StatusTeapot:                grpcUnknown,
+	} {
+		if got := grpcCodeFromHTTP(status); got != want {
+			t.Errorf("grpcCodeFromHTTP(%d) = %s; want %s", status, got, want)
+		}
+	}
+}
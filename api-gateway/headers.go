@@ -0,0 +1,276 @@
+// api-gateway/headers.go
+// Route별 헤더 변환 규칙: 요청/응답 헤더 set/add/remove/rename, 템플릿 값, 위조 가능한 클라이언트 헤더 제거
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// HeaderOpsConfig transforms one direction of a request. Operations run in
+// the order remove, rename, set, add. Values of set and add may contain
+// templates: ${request_id}, ${client_ip}, ${route}, ${upstream} and
+// ${jwt.<claim>}.
+type HeaderOpsConfig struct {
+	Set    map[string]string `json:"set,omitempty"`
+	Add    map[string]string `json:"add,omitempty"`
+	Remove []string          `json:"remove,omitempty"`
+	// Rename maps an existing header to its new name, keeping its values
+	Rename map[string]string `json:"rename,omitempty"`
+}
+
+// HeaderRulesConfig is one entry of the "headers" section of the config file,
+// keyed by route name or "*" for every route ("*" runs first).
+type HeaderRulesConfig struct {
+	Request  HeaderOpsConfig `json:"request"`
+	Response HeaderOpsConfig `json:"response"`
+}
+
+// headerTemplate matches ${name} placeholders in header values.
+var headerTemplate = regexp.MustCompile(`\$\{([a-z_]+(?:\.[A-Za-z0-9_]+)?)\}`)
+
+// spoofableHeaderPrefixes and spoofableHeaders carry identity that only the
+// gateway may assert; client-supplied copies are always dropped.
+var (
+	spoofableHeaderPrefixes = []string{"X-User-", "X-Auth-", "X-Authenticated-"}
+	spoofableHeaders        = []string{"X-Forwarded-User", "X-Forwarded-Email", "X-Forwarded-Prefix"}
+	// hopByHopHeaders are removed by ReverseProxy in both directions (before
+	// Rewrite and ModifyResponse); rules may not name them
+	hopByHopHeaders = map[string]bool{
+		"Connection": true, "Proxy-Connection": true, "Keep-Alive": true, "Proxy-Authenticate": true,
+		"Proxy-Authorization": true, "Te": true, "Trailer": true, "Transfer-Encoding": true, "Upgrade": true,
+	}
+)
+
+type headerOps struct {
+	set    http.Header
+	add    http.Header
+	remove []string
+	rename [][2]string
+}
+
+type headerRuleSet struct {
+	routes map[string]*[2]headerOps // [0] request, [1] response
+}
+
+var currentHeaderRules atomic.Pointer[headerRuleSet]
+
+func init() {
+	currentHeaderRules.Store(&headerRuleSet{})
+}
+
+func newHeaderOps(cfg HeaderOpsConfig) (headerOps, error) {
+	ops := headerOps{set: http.Header{}, add: http.Header{}}
+	check := func(name string) (string, error) {
+		if name == "" || strings.ContainsAny(name, " :\r\n") {
+			return "", fmt.Errorf("invalid header name %q", name)
+		}
+		key := textproto.CanonicalMIMEHeaderKey(name)
+		if hopByHopHeaders[key] {
+			return "", fmt.Errorf("hop-by-hop header %s cannot be transformed", key)
+		}
+		return key, nil
+	}
+	values := func(m map[string]string, dst http.Header) error {
+		for name, value := range m {
+			key, err := check(name)
+			if err != nil {
+				return err
+			}
+			if strings.ContainsAny(value, "\r\n") {
+				return fmt.Errorf("invalid value for header %s", name)
+			}
+			for _, match := range headerTemplate.FindAllStringSubmatch(value, -1) {
+				if !validHeaderTemplate(match[1]) {
+					return fmt.Errorf("header %s: unknown template ${%s}", name, match[1])
+				}
+			}
+			dst.Add(key, value)
+		}
+		return nil
+	}
+	if err := values(cfg.Set, ops.set); err != nil {
+		return ops, err
+	}
+	if err := values(cfg.Add, ops.add); err != nil {
+		return ops, err
+	}
+	for _, name := range cfg.Remove {
+		key, err := check(name)
+		if err != nil {
+			return ops, err
+		}
+		ops.remove = append(ops.remove, key)
+	}
+	for from, to := range cfg.Rename {
+		fromKey, err := check(from)
+		if err != nil {
+			return ops, err
+		}
+		toKey, err := check(to)
+		if err != nil {
+			return ops, err
+		}
+		ops.rename = append(ops.rename, [2]string{fromKey, toKey})
+	}
+	return ops, nil
+}
+
+func validHeaderTemplate(name string) bool {
+	switch name {
+	case "request_id", "client_ip", "route", "upstream":
+		return true
+	}
+	return strings.HasPrefix(name, "jwt.")
+}
+
+func newHeaderRuleSet(router *Router, configs map[string]HeaderRulesConfig) (*headerRuleSet, error) {
+	set := &headerRuleSet{routes: map[string]*[2]headerOps{}}
+	for name, cfg := range configs {
+		if name != allRoutesRule && router.route(name) == nil {
+			return nil, fmt.Errorf("headers: unknown route %q", name)
+		}
+		var ops [2]headerOps
+		var err error
+		if ops[0], err = newHeaderOps(cfg.Request); err != nil {
+			return nil, fmt.Errorf("headers %s request: %w", name, err)
+		}
+		if ops[1], err = newHeaderOps(cfg.Response); err != nil {
+			return nil, fmt.Errorf("headers %s response: %w", name, err)
+		}
+		set.routes[name] = &ops
+	}
+	return set, nil
+}
+
+// SetHeaderRules replaces the header rules.
+func SetHeaderRules(router *Router, configs map[string]HeaderRulesConfig) error {
+	set, err := newHeaderRuleSet(router, configs)
+	if err != nil {
+		return err
+	}
+	currentHeaderRules.Store(set)
+	return nil
+}
+
+// expandHeaderValue fills the templates of value from the client request r.
+// jwt claims are read from the bearer token without verifying it, so an
+// upstream must still verify the token before trusting a claim header.
+func expandHeaderValue(value string, r *http.Request) string {
+	if !strings.Contains(value, "${") {
+		return value
+	}
+	var claims map[string]any
+	return headerTemplate.ReplaceAllStringFunc(value, func(m string) string {
+		name := m[2 : len(m)-1]
+		switch name {
+		case "request_id":
+			return requestIDFromContext(r.Context())
+		case "client_ip":
+			return getClientIP(r)
+		case "route", "upstream":
+			route := routeFromContext(r.Context())
+			if route == nil {
+				return ""
+			}
+			if name == "route" {
+				return route.Name
+			}
+			return upstreamName(r.Context(), route)
+		}
+		if claims == nil {
+			claims = bearerClaims(r)
+		}
+		if v, ok := claims[strings.TrimPrefix(name, "jwt.")]; ok && v != nil {
+			return strings.NewReplacer("\r", "", "\n", "").Replace(fmt.Sprint(v))
+		}
+		return ""
+	})
+}
+
+// bearerClaims decodes the payload of the request's bearer JWT, if any.
+func bearerClaims(r *http.Request) map[string]any {
+	claims := map[string]any{}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return claims
+	}
+	decodeJWTPayload(token, &claims)
+	return claims
+}
+
+// apply runs ops on h; r is the client request the templates read from.
+func (ops *headerOps) apply(h http.Header, r *http.Request) {
+	for _, name := range ops.remove {
+		h.Del(name)
+	}
+	for _, rn := range ops.rename {
+		if v, ok := h[rn[0]]; ok {
+			delete(h, rn[0])
+			h[rn[1]] = v
+		}
+	}
+	for name, values := range ops.set {
+		h.Del(name)
+		addExpanded(h, name, values, r)
+	}
+	for name, values := range ops.add {
+		addExpanded(h, name, values, r)
+	}
+}
+
+// addExpanded adds the expanded values, skipping ones that expand to nothing
+// (e.g. ${jwt.sub} on an anonymous request).
+func addExpanded(h http.Header, name string, values []string, r *http.Request) {
+	for _, v := range values {
+		if v = expandHeaderValue(v, r); v != "" {
+			h.Add(name, v)
+		}
+	}
+}
+
+// applyRequest applies the "*" and then the route's request rules to h, the
+// outgoing request's headers. Templates read from in, the request as the
+// gateway received it, so ${client_ip} is not taken from the X-Forwarded-For
+// chain the gateway already extended.
+func (s *headerRuleSet) applyRequest(h http.Header, in *http.Request, route *Route) {
+	s.apply(h, in, route, 0)
+}
+
+// applyResponse applies the "*" and then the route's response rules.
+func (s *headerRuleSet) applyResponse(resp *http.Response, route *Route) {
+	s.apply(resp.Header, resp.Request, route, 1)
+}
+
+func (s *headerRuleSet) apply(h http.Header, r *http.Request, route *Route, dir int) {
+	if ops := s.routes[allRoutesRule]; ops != nil {
+		ops[dir].apply(h, r)
+	}
+	if route == nil {
+		return
+	}
+	if ops := s.routes[route.Name]; ops != nil {
+		ops[dir].apply(h, r)
+	}
+}
+
+// stripUntrustedHeaders drops identity headers that a client could use to
+// impersonate another user or the gateway.
+func stripUntrustedHeaders(h http.Header) {
+	for _, name := range spoofableHeaders {
+		h.Del(name)
+	}
+	for name := range h {
+		for _, prefix := range spoofableHeaderPrefixes {
+			if strings.HasPrefix(name, prefix) {
+				delete(h, name)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,193 @@
+// api-gateway/headers_test.go
+// 단위 테스트: Route별 요청/응답 헤더 변환, 템플릿 값, 위조 가능한 클라이언트 헤더 제거
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+// newHeaderTestRouter routes every service to a backend that echoes the
+// request headers it received and sets Server and X-Powered-By
+func newHeaderTestRouter(t *testing.T, configs map[string]HeaderRulesConfig) *Router {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "gunicorn")
+		w.Header().Set("X-Powered-By", "Flask")
+		w.Header().Set("X-Internal-Version", "1.4.2")
+		json.NewEncoder(w).Encode(r.Header)
+	}))
+	t.Cleanup(srv.Close)
+
+	upstreams := map[string]*Upstream{}
+	for _, name := range []string{"user-service", "auth-service", "blog-service"} {
+		upstreams[name] = newTestUpstream(t, name, srv)
+	}
+	router := NewRouter(defaultRoutes(), upstreams, nil)
+
+	previous := currentHeaderRules.Load()
+	t.Cleanup(func() { currentHeaderRules.Store(previous) })
+	if err := SetHeaderRules(router, configs); err != nil {
+		t.Fatalf("SetHeaderRules error: %v", err)
+	}
+	return router
+}
+
+func proxyHeaders(t *testing.T, router *Router, req *http.Request) (http.Header, http.Header) {
+	t.Helper()
+	req = req.WithContext(context.WithValue(req.Context(), requestIDKey, "req-123"))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	var upstream http.Header
+	if err := json.NewDecoder(rr.Body).Decode(&upstream); err != nil {
+		t.Fatalf("decode upstream headers: %v", err)
+	}
+	return upstream, rr.Header()
+}
+
+// TestHeaderRules tests set/add/remove/rename and templates in both directions
+func TestHeaderRules(t *testing.T) {
+	router := newHeaderTestRouter(t, map[string]HeaderRulesConfig{
+		allRoutesRule: {
+			Request:  HeaderOpsConfig{Set: map[string]string{"X-Request-Id": "${request_id}"}},
+			Response: HeaderOpsConfig{Remove: []string{"Server", "X-Powered-By"}},
+		},
+		"blog-api": {
+			Request: HeaderOpsConfig{
+				Set:    map[string]string{"X-Forwarded-Prefix": "/blog", "X-User-Name": "${jwt.username}", "X-Client": "${client_ip} via ${route}"},
+				Add:    map[string]string{"Accept-Language": "ko"},
+				Remove: []string{"X-Debug"},
+				Rename: map[string]string{"X-Legacy-Token": "X-Session-Token"},
+			},
+			Response: HeaderOpsConfig{
+				Rename: map[string]string{"X-Internal-Version": "X-Blog-Version"},
+				Add:    map[string]string{"X-Upstream": "${upstream}"},
+			},
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/blog/api/posts", nil)
+	req.RemoteAddr = "203.0.113.7:4000"
+	req.Header.Set("Authorization", "Bearer "+testJWT("alice", time.Now().Add(time.Hour)))
+	req.Header.Set("Accept-Language", "en")
+	req.Header.Set("X-Debug", "1")
+	req.Header.Set("X-Legacy-Token", "abc")
+	upstream, resp := proxyHeaders(t, router, req)
+
+	requestTests := []struct {
+		name   string
+		header string
+		expect []string
+	}{
+		{"전체 Route 템플릿", "X-Request-Id", []string{"req-123"}},
+		{"고정 값 set", "X-Forwarded-Prefix", []string{"/blog"}},
+		{"JWT Claim 템플릿", "X-User-Name", []string{"alice"}},
+		{"여러 템플릿 조합", "X-Client", []string{"203.0.113.7 via blog-api"}},
+		{"add는 기존 값 유지", "Accept-Language", []string{"en", "ko"}},
+		{"remove", "X-Debug", nil},
+		{"rename 이전 이름 제거", "X-Legacy-Token", nil},
+		{"rename 새 이름", "X-Session-Token", []string{"abc"}},
+	}
+	for _, tt := range requestTests {
+		t.Run("요청 "+tt.name, func(t *testing.T) {
+			if got := upstream.Values(tt.header); !slices.Equal(got, tt.expect) {
+				t.Errorf("upstream %s = %v; want %v", tt.header, got, tt.expect)
+			}
+		})
+	}
+
+	responseTests := []struct {
+		name   string
+		header string
+		expect string
+	}{
+		{"Server 제거", "Server", ""},
+		{"X-Powered-By 제거", "X-Powered-By", ""},
+		{"rename", "X-Blog-Version", "1.4.2"},
+		{"upstream 템플릿", "X-Upstream", "blog-service"},
+	}
+	for _, tt := range responseTests {
+		t.Run("응답 "+tt.name, func(t *testing.T) {
+			if got := resp.Get(tt.header); got != tt.expect {
+				t.Errorf("response %s = %q; want %q", tt.header, got, tt.expect)
+			}
+		})
+	}
+
+	t.Run("신뢰 Proxy 뒤에서 client_ip는 원래 클라이언트", func(t *testing.T) {
+		setTrustedProxyHops(t, 1)
+		req := httptest.NewRequest(http.MethodGet, "/blog/api/posts", nil)
+		req.RemoteAddr = "10.0.0.5:4000"
+		req.Header.Set("X-Forwarded-For", "198.51.100.1, 203.0.113.7")
+		upstream, _ := proxyHeaders(t, router, req)
+		if got := upstream.Get("X-Client"); got != "203.0.113.7 via blog-api" {
+			t.Errorf("upstream X-Client = %q; want %q", got, "203.0.113.7 via blog-api")
+		}
+	})
+
+	t.Run("다른 Route에는 전체 규칙만 적용", func(t *testing.T) {
+		upstream, resp := proxyHeaders(t, router, httptest.NewRequest(http.MethodGet, "/api/users/alice", nil))
+		if upstream.Get("X-User-Name") != "" || upstream.Get("X-Request-Id") != "req-123" {
+			t.Errorf("upstream headers = %v", upstream)
+		}
+		if resp.Get("Server") != "" || resp.Get("X-Internal-Version") != "1.4.2" {
+			t.Errorf("response headers = %v", resp)
+		}
+	})
+}
+
+// TestStripUntrustedHeaders tests that clients cannot assert identity headers
+func TestStripUntrustedHeaders(t *testing.T) {
+	router := newHeaderTestRouter(t, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users/alice", nil)
+	for _, name := range []string{"X-User-Id", "X-Auth-Role", "X-Authenticated-User", "X-Forwarded-User", "X-Forwarded-Email", "X-Forwarded-Prefix"} {
+		req.Header.Set(name, "admin")
+	}
+	req.Header.Set("X-Username-Hint", "kept")
+	upstream, _ := proxyHeaders(t, router, req)
+
+	for _, name := range []string{"X-User-Id", "X-Auth-Role", "X-Authenticated-User", "X-Forwarded-User", "X-Forwarded-Email", "X-Forwarded-Prefix"} {
+		if got := upstream.Get(name); got == "admin" {
+			t.Errorf("upstream %s = %q; want client value stripped", name, got)
+		}
+	}
+	if upstream.Get("X-Username-Hint") != "kept" {
+		t.Error("unrelated header should be forwarded")
+	}
+
+	t.Run("Gateway 규칙은 제거 후 적용", func(t *testing.T) {
+		router := newHeaderTestRouter(t, map[string]HeaderRulesConfig{
+			"users": {Request: HeaderOpsConfig{Set: map[string]string{"X-User-Name": "${jwt.username}"}}},
+		})
+		req := httptest.NewRequest(http.MethodGet, "/api/users/alice", nil)
+		req.Header.Set("X-User-Name", "admin")
+		upstream, _ := proxyHeaders(t, router, req)
+		if got := upstream.Values("X-User-Name"); got != nil {
+			t.Errorf("X-User-Name without token = %v; want none", got)
+		}
+	})
+}
+
+// TestHeaderRulesConfigErrors tests rejected headers sections
+func TestHeaderRulesConfigErrors(t *testing.T) {
+	router := newTestRouter(t)
+	for _, cfg := range []map[string]HeaderRulesConfig{
+		{"nope": {}},
+		{"blog-api": {Request: HeaderOpsConfig{Set: map[string]string{"X-Bad": "${password}"}}}},
+		{"blog-api": {Request: HeaderOpsConfig{Set: map[string]string{"Bad Name": "x"}}}},
+		{"blog-api": {Response: HeaderOpsConfig{Add: map[string]string{"X-Split": "a\r\nSet-Cookie: x"}}}},
+		{"blog-api": {Request: HeaderOpsConfig{Remove: []string{"Connection"}}}},
+		{"blog-api": {Response: HeaderOpsConfig{Rename: map[string]string{"X-Stream": "Transfer-Encoding"}}}},
+	} {
+		if _, err := newHeaderRuleSet(router, cfg); err == nil {
+			t.Errorf("newHeaderRuleSet(%+v) should fail", cfg)
+		}
+	}
+}
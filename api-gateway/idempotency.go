@@ -0,0 +1,249 @@
+// api-gateway/idempotency.go
+// Idempotency-Key: 설정된 Route의 POST 응답을 저장해 재시도 시 재생 (모바일 클라이언트 재시도로 인한 중복 생성 방지)
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var idempotencyRequestsTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "idempotency_requests_total",
+		Help: "Total number of requests with an Idempotency-Key by result (new, replayed, in_progress, mismatch)",
+	},
+	[]string{"route", "result"},
+)
+
+const (
+	idempotencyKeyHeader  = "Idempotency-Key"
+	idempotentReplayedHdr = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+	// idempotencyLockTTL bounds how long an in-progress marker survives a
+	// replica that died mid-request (server WriteTimeout is 10s)
+	idempotencyLockTTL = time.Minute
+)
+
+// IdempotencyConfig enables Idempotency-Key handling for a route
+// ("idempotency" section of the config file, keyed by route name).
+type IdempotencyConfig struct {
+	// TTL is how long a completed response is replayed (default 24h)
+	TTL jsonDuration `json:"ttl,omitempty"`
+	// MaxResponseBytes skips storing larger responses (default 1MB)
+	MaxResponseBytes int64 `json:"max_response_bytes,omitempty"`
+}
+
+func (rt *Router) newIdempotencyConfigs(configs map[string]IdempotencyConfig) (map[string]IdempotencyConfig, error) {
+	result := make(map[string]IdempotencyConfig, len(configs))
+	for name, cfg := range configs {
+		if rt.route(name) == nil {
+			return nil, fmt.Errorf("idempotency: unknown route %q", name)
+		}
+		if cfg.TTL < 0 || cfg.MaxResponseBytes < 0 {
+			return nil, fmt.Errorf("idempotency %s: values must not be negative", name)
+		}
+		if cfg.TTL == 0 {
+			cfg.TTL = jsonDuration(24 * time.Hour)
+		}
+		if cfg.MaxResponseBytes == 0 {
+			cfg.MaxResponseBytes = 1 << 20
+		}
+		result[name] = cfg
+	}
+	return result, nil
+}
+
+// Idempotency keeps first responses in the gateway store so that a retry
+// landing on another replica is still answered from it (GATEWAY_STORE=redis).
+type Idempotency struct {
+	store  kvStore
+	routes atomic.Pointer[map[string]IdempotencyConfig]
+	now    func() time.Time
+}
+
+func NewIdempotency(store kvStore) *Idempotency {
+	i := &Idempotency{store: store, now: time.Now}
+	i.routes.Store(&map[string]IdempotencyConfig{})
+	return i
+}
+
+var globalIdempotency = NewIdempotency(gatewayStore)
+
+// SetConfig replaces the enabled routes.
+func (i *Idempotency) SetConfig(router *Router, configs map[string]IdempotencyConfig) error {
+	routes, err := router.newIdempotencyConfigs(configs)
+	if err != nil {
+		return err
+	}
+	i.routes.Store(&routes)
+	return nil
+}
+
+// idempotencyRecord is the stored state of one key: in progress until the
+// first response completes, then the response itself.
+type idempotencyRecord struct {
+	Fingerprint string      `json:"fingerprint"`
+	Completed   bool        `json:"completed"`
+	Status      int         `json:"status,omitempty"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+	Created     time.Time   `json:"created"`
+}
+
+// idempotencyCaller scopes keys to the caller so that two users cannot
+// replay each other's responses by guessing keys.
+func idempotencyCaller(r *http.Request) string {
+	if k := apiKeyFromContext(r.Context()); k != nil {
+		return "key:" + k.ID
+	}
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		sum := sha256.Sum256([]byte(auth))
+		return "auth:" + hex.EncodeToString(sum[:16])
+	}
+	return "ip:" + getClientIP(r)
+}
+
+func idempotencyStoreKey(route, caller, key string) string {
+	sum := sha256.Sum256([]byte(route + "\x00" + caller + "\x00" + key))
+	return "idempotency:" + hex.EncodeToString(sum[:])
+}
+
+// storedHeaders keeps the headers the handler added or changed; headers the
+// outer middlewares set (request id, quota, security) are set again on replay.
+func storedHeaders(before, after http.Header) http.Header {
+	stored := http.Header{}
+	for k, v := range after {
+		if k == "Content-Length" || k == "Date" || k == "Set-Cookie" || slices.Equal(before[k], v) {
+			continue
+		}
+		stored[k] = v
+	}
+	return stored
+}
+
+func (i *Idempotency) load(ctx context.Context, key string) (*idempotencyRecord, error) {
+	data, ok, err := i.store.Get(ctx, key)
+	if err != nil || !ok {
+		return nil, err
+	}
+	var rec idempotencyRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+// idempotencyMiddleware claims the Idempotency-Key of POST requests on
+// configured routes, stores the first response and replays it for repeats.
+// A repeat that arrives while the first is still running gets 409, and a key
+// reused with a different body gets 422.
+func idempotencyMiddleware(i *Idempotency, router *Router, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" || r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+		route := router.Resolve(r.URL.Path)
+		if route == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		cfg, ok := (*i.routes.Load())[route.Name]
+		if !ok || router.bodyLimit(route).Streaming {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			writeError(w, r, http.StatusBadRequest, fmt.Sprintf("%s must be at most %d characters", idempotencyKeyHeader, maxIdempotencyKeyLength))
+			return
+		}
+
+		// 본문 크기는 requestSizeLimitMiddleware가 이미 Route 한도로 제한
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, r, http.StatusRequestEntityTooLarge, "Request body could not be read")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(append([]byte(r.Method+" "+r.URL.RequestURI()+"\n"), body...))
+		fingerprint := hex.EncodeToString(sum[:])
+
+		ctx := r.Context()
+		storeKey := idempotencyStoreKey(route.Name, idempotencyCaller(r), key)
+		claim, _ := json.Marshal(idempotencyRecord{Fingerprint: fingerprint, Created: i.now()})
+		claimed, err := i.store.SetNX(ctx, storeKey, claim, idempotencyLockTTL)
+		if err != nil {
+			// 저장소 장애 시 fail-open (중복 방지 없이 처리)
+			log.Printf("Idempotency store unavailable for %s: %v", route.Name, err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !claimed {
+			rec, err := i.load(ctx, storeKey)
+			switch {
+			case err != nil || rec == nil:
+				// 확인 직후 만료된 경우 포함: 클라이언트가 다시 시도하도록 409
+				w.Header().Set("Retry-After", "1")
+				writeError(w, r, http.StatusConflict, "A request with this Idempotency-Key is in progress")
+			case rec.Fingerprint != fingerprint:
+				idempotencyRequestsTotal.WithLabelValues(route.Name, "mismatch").Inc()
+				writeError(w, r, http.StatusUnprocessableEntity, "Idempotency-Key was already used with a different request")
+			case !rec.Completed:
+				idempotencyRequestsTotal.WithLabelValues(route.Name, "in_progress").Inc()
+				w.Header().Set("Retry-After", "1")
+				writeError(w, r, http.StatusConflict, "A request with this Idempotency-Key is in progress")
+			default:
+				idempotencyRequestsTotal.WithLabelValues(route.Name, "replayed").Inc()
+				for k, v := range rec.Header {
+					w.Header()[k] = v
+				}
+				w.Header().Set(idempotentReplayedHdr, "true")
+				w.WriteHeader(rec.Status)
+				w.Write(rec.Body)
+			}
+			return
+		}
+
+		idempotencyRequestsTotal.WithLabelValues(route.Name, "new").Inc()
+		before := w.Header().Clone()
+		cw := newCaptureWriter(w, cfg.MaxResponseBytes)
+		next.ServeHTTP(cw, r)
+
+		// 응답 후 클라이언트가 연결을 끊어도 결과는 저장
+		ctx = context.WithoutCancel(ctx)
+		if cw.status >= http.StatusInternalServerError || cw.truncated {
+			// 5xx는 재시도할 수 있도록, 한도를 넘는 응답은 저장하지 않고 Key 해제
+			i.store.Delete(ctx, storeKey)
+			return
+		}
+		rec, _ := json.Marshal(idempotencyRecord{
+			Fingerprint: fingerprint,
+			Completed:   true,
+			Status:      cw.status,
+			Header:      storedHeaders(before, w.Header()),
+			Body:        cw.body.Bytes(),
+			Created:     i.now(),
+		})
+		if err := i.store.Set(ctx, storeKey, rec, time.Duration(cfg.TTL)); err != nil {
+			log.Printf("Idempotency store write failed for %s: %v", route.Name, err)
+			i.store.Delete(ctx, storeKey)
+		}
+	})
+}
@@ -0,0 +1,154 @@
+// api-gateway/idempotency_test.go
+// 단위 테스트: Idempotency-Key 응답 재생, 진행 중 중복 409, 본문 불일치 422, 사용자/Replica 범위
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+type idempotencyTestEnv struct {
+	handler http.Handler
+	calls   atomic.Int32
+	status  int
+	started chan struct{}
+	release chan struct{}
+}
+
+func newIdempotencyTestEnv(t *testing.T, i *Idempotency) *idempotencyTestEnv {
+	t.Helper()
+	router := newTestRouter(t)
+	if err := i.SetConfig(router, map[string]IdempotencyConfig{"blog-api": {}, "user-register": {}}); err != nil {
+		t.Fatalf("SetConfig error: %v", err)
+	}
+	env := &idempotencyTestEnv{status: http.StatusCreated}
+	env.handler = idempotencyMiddleware(i, router, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := env.calls.Add(1)
+		if env.release != nil {
+			env.started <- struct{}{}
+			<-env.release
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", fmt.Sprintf("/blog/api/posts/%d", n))
+		w.WriteHeader(env.status)
+		fmt.Fprintf(w, `{"id": %d, "echo": %s}`, n, body)
+	}))
+	return env
+}
+
+func (e *idempotencyTestEnv) post(path, key, auth, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(idempotencyKeyHeader, key)
+	}
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	rr := httptest.NewRecorder()
+	e.handler.ServeHTTP(rr, req)
+	return rr
+}
+
+// TestIdempotencyReplay tests storing and replaying the first response
+func TestIdempotencyReplay(t *testing.T) {
+	env := newIdempotencyTestEnv(t, NewIdempotency(newMemoryStore()))
+
+	first := env.post("/blog/api/posts", "k1", "Bearer alice", `{"title": "a"}`)
+	second := env.post("/blog/api/posts", "k1", "Bearer alice", `{"title": "a"}`)
+	if env.calls.Load() != 1 {
+		t.Fatalf("upstream calls = %d; want 1", env.calls.Load())
+	}
+	if second.Code != http.StatusCreated || second.Body.String() != first.Body.String() ||
+		second.Header().Get("Location") != "/blog/api/posts/1" || second.Header().Get(idempotentReplayedHdr) != "true" {
+		t.Errorf("replay = %d %v %s", second.Code, second.Header(), second.Body.String())
+	}
+	if first.Header().Get(idempotentReplayedHdr) != "" {
+		t.Error("first response should not be marked as replayed")
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		key        string
+		auth       string
+		body       string
+		expectCode int
+		expectCall bool
+	}{
+		{"다른 본문으로 Key 재사용", "/blog/api/posts", "k1", "Bearer alice", `{"title": "b"}`, http.StatusUnprocessableEntity, false},
+		{"다른 사용자는 별도 Key 공간", "/blog/api/posts", "k1", "Bearer bob", `{"title": "a"}`, http.StatusCreated, true},
+		{"다른 Route는 별도 Key 공간", "/api/register", "k1", "Bearer alice", `{"title": "a"}`, http.StatusCreated, true},
+		{"Key 없음", "/blog/api/posts", "", "Bearer alice", `{"title": "a"}`, http.StatusCreated, true},
+		{"설정되지 않은 Route", "/api/users", "k1", "Bearer alice", `{"title": "a"}`, http.StatusCreated, true},
+		{"너무 긴 Key", "/blog/api/posts", strings.Repeat("k", 256), "Bearer alice", `{}`, http.StatusBadRequest, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := env.calls.Load()
+			rr := env.post(tt.path, tt.key, tt.auth, tt.body)
+			if rr.Code != tt.expectCode {
+				t.Errorf("Status = %d; want %d", rr.Code, tt.expectCode)
+			}
+			if called := env.calls.Load() > before; called != tt.expectCall {
+				t.Errorf("upstream called = %v; want %v", called, tt.expectCall)
+			}
+		})
+	}
+}
+
+// TestIdempotencyInProgress tests a duplicate that arrives before the first finishes
+func TestIdempotencyInProgress(t *testing.T) {
+	store, _ := newTestRedisStore(t)
+	replicaA := newIdempotencyTestEnv(t, NewIdempotency(store))
+	replicaB := newIdempotencyTestEnv(t, NewIdempotency(store))
+	replicaA.started, replicaA.release = make(chan struct{}), make(chan struct{})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- replicaA.post("/api/register", "signup-1", "", `{"username": "carol"}`) }()
+	<-replicaA.started
+
+	rr := replicaB.post("/api/register", "signup-1", "", `{"username": "carol"}`)
+	if rr.Code != http.StatusConflict || rr.Header().Get("Retry-After") == "" {
+		t.Errorf("concurrent duplicate = %d; want 409 with Retry-After", rr.Code)
+	}
+	close(replicaA.release)
+	if first := <-done; first.Code != http.StatusCreated {
+		t.Fatalf("first = %d; want 201", first.Code)
+	}
+
+	rr = replicaB.post("/api/register", "signup-1", "", `{"username": "carol"}`)
+	if rr.Code != http.StatusCreated || replicaB.calls.Load() != 0 {
+		t.Errorf("retry on other replica = %d, upstream calls %d; want replayed 201", rr.Code, replicaB.calls.Load())
+	}
+}
+
+// TestIdempotencyServerErrorsNotStored tests that 5xx responses can be retried
+func TestIdempotencyServerErrorsNotStored(t *testing.T) {
+	env := newIdempotencyTestEnv(t, NewIdempotency(newMemoryStore()))
+	env.status = http.StatusBadGateway
+	env.post("/blog/api/posts", "k2", "", `{}`)
+	env.status = http.StatusCreated
+	if rr := env.post("/blog/api/posts", "k2", "", `{}`); rr.Code != http.StatusCreated || env.calls.Load() != 2 {
+		t.Errorf("retry after 502 = %d, calls %d; want 201 from upstream", rr.Code, env.calls.Load())
+	}
+}
+
+// TestIdempotencyConfigErrors tests rejected idempotency sections
+func TestIdempotencyConfigErrors(t *testing.T) {
+	router := newTestRouter(t)
+	for _, cfg := range []map[string]IdempotencyConfig{
+		{"nope": {}},
+		{"blog-api": {MaxResponseBytes: -1}},
+	} {
+		if err := NewIdempotency(newMemoryStore()).SetConfig(router, cfg); err == nil {
+			t.Errorf("SetConfig(%+v) should fail", cfg)
+		}
+	}
+}
@@ -0,0 +1,339 @@
+// api-gateway/ipfilter.go
+// IP 접근 제어: Route별 CIDR allow/deny 목록과 Rate Limit 위반/로그인 실패 누적 시 자동 임시 차단
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	ipFilterRejectionsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ip_filter_rejections_total",
+			Help: "Total number of requests rejected by IP rules by reason (denied, not_allowed, banned)",
+		},
+		[]string{"reason"},
+	)
+	ipBansTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ip_bans_total",
+			Help: "Total number of automatic IP bans by trigger (rate_limit, failed_login)",
+		},
+		[]string{"trigger"},
+	)
+)
+
+const (
+	banTriggerRateLimit   = "rate_limit"
+	banTriggerFailedLogin = "failed_login"
+
+	// allRoutesRule is the ip_filter route key that applies to every request
+	allRoutesRule = "*"
+
+	defaultBanWindow   = time.Minute
+	defaultBanDuration = 15 * time.Minute
+)
+
+// IPFilterConfig is the "ip_filter" section of the config file.
+type IPFilterConfig struct {
+	// Routes maps route names, or "*" for every request, to CIDR lists
+	Routes map[string]IPRuleConfig `json:"routes,omitempty"`
+	Ban    *BanConfig              `json:"ban,omitempty"`
+}
+
+// IPRuleConfig lists CIDRs (or single addresses). Deny wins over allow; a
+// non-empty allow list rejects every address not on it.
+type IPRuleConfig struct {
+	Allow []string `json:"allow,omitempty"`
+	Deny  []string `json:"deny,omitempty"`
+}
+
+// BanConfig bans a client IP for Duration once it hits a threshold within
+// Window. A threshold of 0 disables that trigger.
+type BanConfig struct {
+	RateLimitViolations int `json:"rate_limit_violations,omitempty"`
+	// FailedLogins counts 401 responses from auth-service's /login
+	FailedLogins int          `json:"failed_logins,omitempty"`
+	Window       jsonDuration `json:"window,omitempty"`
+	Duration     jsonDuration `json:"duration,omitempty"`
+}
+
+type ipRules struct {
+	allow []netip.Prefix
+	deny  []netip.Prefix
+}
+
+func (r ipRules) permits(addr netip.Addr) (bool, string) {
+	for _, p := range r.deny {
+		if p.Contains(addr) {
+			return false, "denied"
+		}
+	}
+	if len(r.allow) == 0 {
+		return true, ""
+	}
+	for _, p := range r.allow {
+		if p.Contains(addr) {
+			return true, ""
+		}
+	}
+	return false, "not_allowed"
+}
+
+type ipFilterState struct {
+	rules map[string]ipRules
+	ban   BanConfig
+}
+
+// IPFilter enforces the static lists and keeps bans in the gateway store so
+// that every replica sees them when GATEWAY_STORE=redis.
+type IPFilter struct {
+	store kvStore
+	state atomic.Pointer[ipFilterState]
+	now   func() time.Time
+}
+
+func NewIPFilter(store kvStore) *IPFilter {
+	f := &IPFilter{store: store, now: time.Now}
+	f.state.Store(&ipFilterState{})
+	return f
+}
+
+var globalIPFilter = NewIPFilter(gatewayStore)
+
+func newIPFilterState(router *Router, cfg IPFilterConfig) (*ipFilterState, error) {
+	state := &ipFilterState{rules: map[string]ipRules{}}
+	for name, rc := range cfg.Routes {
+		if name != allRoutesRule && router.route(name) == nil {
+			return nil, fmt.Errorf("ip_filter: unknown route %q", name)
+		}
+		var rules ipRules
+		var err error
+		if rules.allow, err = parsePrefixes(rc.Allow); err != nil {
+			return nil, fmt.Errorf("ip_filter %s: %w", name, err)
+		}
+		if rules.deny, err = parsePrefixes(rc.Deny); err != nil {
+			return nil, fmt.Errorf("ip_filter %s: %w", name, err)
+		}
+		state.rules[name] = rules
+	}
+	if cfg.Ban != nil {
+		ban := *cfg.Ban
+		if ban.RateLimitViolations < 0 || ban.FailedLogins < 0 || ban.Window < 0 || ban.Duration < 0 {
+			return nil, fmt.Errorf("ip_filter ban: values must not be negative")
+		}
+		if ban.Window == 0 {
+			ban.Window = jsonDuration(defaultBanWindow)
+		}
+		if ban.Duration == 0 {
+			ban.Duration = jsonDuration(defaultBanDuration)
+		}
+		state.ban = ban
+	}
+	return state, nil
+}
+
+func parsePrefixes(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, v := range values {
+		if !strings.Contains(v, "/") {
+			addr, err := netip.ParseAddr(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid address %q", v)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		p, err := netip.ParsePrefix(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q", v)
+		}
+		prefixes = append(prefixes, p.Masked())
+	}
+	return prefixes, nil
+}
+
+// SetConfig replaces the lists and ban thresholds.
+func (f *IPFilter) SetConfig(router *Router, cfg IPFilterConfig) error {
+	state, err := newIPFilterState(router, cfg)
+	if err != nil {
+		return err
+	}
+	f.state.Store(state)
+	return nil
+}
+
+// permits checks ip against the "*" rules and the rules of route.
+func (f *IPFilter) permits(ip string, route *Route) (bool, string) {
+	state := f.state.Load()
+	if len(state.rules) == 0 {
+		return true, ""
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		// 파싱할 수 없는 주소는 deny/allow 어디에도 속하지 않음
+		addr = netip.Addr{}
+	}
+	addr = addr.Unmap()
+	if ok, reason := state.rules[allRoutesRule].permits(addr); !ok {
+		return false, reason
+	}
+	if route != nil {
+		return state.rules[route.Name].permits(addr)
+	}
+	return true, ""
+}
+
+// === Bans ===
+
+// ipBan is the stored ban record.
+type ipBan struct {
+	IP      string    `json:"ip"`
+	Trigger string    `json:"trigger"`
+	Created time.Time `json:"created"`
+	Until   time.Time `json:"until"`
+}
+
+func banKey(ip string) string {
+	return "bans:" + ip
+}
+
+func (f *IPFilter) ban(ctx context.Context, ip string) (*ipBan, error) {
+	data, ok, err := f.store.Get(ctx, banKey(ip))
+	if err != nil || !ok {
+		return nil, err
+	}
+	var b ipBan
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// recordViolation counts one violation of trigger for ip and bans it once
+// the threshold is reached within the window.
+func (f *IPFilter) recordViolation(ctx context.Context, ip, trigger string) {
+	ban := f.state.Load().ban
+	threshold := ban.RateLimitViolations
+	if trigger == banTriggerFailedLogin {
+		threshold = ban.FailedLogins
+	}
+	if threshold <= 0 || ip == "" {
+		return
+	}
+
+	counterKey := "banwatch:" + trigger + ":" + ip
+	n, err := f.store.Incr(ctx, counterKey, time.Duration(ban.Window))
+	if err != nil {
+		log.Printf("IP ban tracking failed for %s: %v", ip, err)
+		return
+	}
+	if n < int64(threshold) {
+		return
+	}
+
+	now := f.now()
+	record, _ := json.Marshal(ipBan{IP: ip, Trigger: trigger, Created: now, Until: now.Add(time.Duration(ban.Duration))})
+	// 이미 차단된 IP는 만료 시각을 연장하지 않음
+	created, err := f.store.SetNX(ctx, banKey(ip), record, time.Duration(ban.Duration))
+	if err != nil {
+		log.Printf("IP ban failed for %s: %v", ip, err)
+		return
+	}
+	f.store.Delete(ctx, counterKey)
+	if created {
+		ipBansTotal.WithLabelValues(trigger).Inc()
+		log.Printf("[BAN] %s banned for %s after %d %s violations", ip, time.Duration(ban.Duration), n, trigger)
+	}
+}
+
+// Bans lists the active bans, soonest expiry first.
+func (f *IPFilter) Bans(ctx context.Context) ([]ipBan, error) {
+	keys, err := f.store.Keys(ctx, "bans:")
+	if err != nil {
+		return nil, err
+	}
+	bans := []ipBan{}
+	for _, key := range keys {
+		b, err := f.ban(ctx, strings.TrimPrefix(key, "bans:"))
+		if err != nil {
+			return nil, err
+		}
+		if b != nil {
+			bans = append(bans, *b)
+		}
+	}
+	sort.Slice(bans, func(i, j int) bool { return bans[i].Until.Before(bans[j].Until) })
+	return bans, nil
+}
+
+// Unban lifts a ban and reports whether there was one.
+func (f *IPFilter) Unban(ctx context.Context, ip string) (bool, error) {
+	b, err := f.ban(ctx, ip)
+	if err != nil || b == nil {
+		return false, err
+	}
+	return true, f.store.Delete(ctx, banKey(ip))
+}
+
+// ipFilterMiddleware rejects banned and unlisted clients with 403 and watches
+// login responses for failed attempts.
+func ipFilterMiddleware(f *IPFilter, router *Router, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Health/Metrics endpoint bypass
+		if r.URL.Path == "/health" || r.URL.Path == "/metrics" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ip := getClientIP(r)
+		b, err := f.ban(r.Context(), ip)
+		if err != nil {
+			// 저장소 장애 시 fail-open (차단 목록보다 가용성 우선)
+			log.Printf("IP ban lookup failed for %s: %v", ip, err)
+		}
+		if b != nil {
+			ipFilterRejectionsTotal.WithLabelValues("banned").Inc()
+			retry := int(b.Until.Sub(f.now()).Seconds()) + 1
+			w.Header().Set("Retry-After", strconv.Itoa(retry))
+			writeProblem(w, r, &problem{
+				Type:       "/problems/client-banned",
+				Status:     http.StatusForbidden,
+				Detail:     "Client is temporarily banned",
+				RetryAfter: retry,
+			})
+			return
+		}
+
+		route := router.Resolve(r.URL.Path)
+		if ok, reason := f.permits(ip, route); !ok {
+			ipFilterRejectionsTotal.WithLabelValues(reason).Inc()
+			writeError(w, r, http.StatusForbidden, "Client address is not allowed")
+			return
+		}
+
+		if route == nil || route.Name != "auth-login" || f.state.Load().ban.FailedLogins <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		rw := wrapResponseWriter(w)
+		next.ServeHTTP(rw, r)
+		if rw.status == http.StatusUnauthorized {
+			f.recordViolation(r.Context(), ip, banTriggerFailedLogin)
+		}
+	})
+}
@@ -0,0 +1,205 @@
+// api-gateway/ipfilter_test.go
+// 단위 테스트: CIDR allow/deny, 자동 차단(Rate Limit 위반/로그인 실패), Replica 간 공유, Admin 조회/해제
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func ipRequest(path, ip string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, path, nil)
+	req.RemoteAddr = net.JoinHostPort(ip, "40000")
+	return req
+}
+
+// TestIPFilterLists tests the "*" and per-route CIDR rules
+func TestIPFilterLists(t *testing.T) {
+	router := newTestRouter(t)
+	f := NewIPFilter(newMemoryStore())
+	if err := f.SetConfig(router, IPFilterConfig{Routes: map[string]IPRuleConfig{
+		"*":     {Deny: []string{"203.0.113.0/24"}},
+		"users": {Allow: []string{"10.0.0.0/8", "192.168.1.10"}},
+	}}); err != nil {
+		t.Fatalf("SetConfig error: %v", err)
+	}
+	handler := ipFilterMiddleware(f, router, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		name       string
+		path       string
+		ip         string
+		expectCode int
+	}{
+		{"전체 deny 대상", "/blog/api/posts", "203.0.113.7", http.StatusForbidden},
+		{"전체 deny 밖", "/blog/api/posts", "198.51.100.7", http.StatusOK},
+		{"내부 전용 Route: 내부 대역", "/api/users/alice", "10.1.2.3", http.StatusOK},
+		{"내부 전용 Route: 단일 주소", "/api/users/alice", "192.168.1.10", http.StatusOK},
+		{"내부 전용 Route: 외부", "/api/users/alice", "198.51.100.7", http.StatusForbidden},
+		{"IPv4-mapped IPv6", "/api/users/alice", "::ffff:10.1.2.3", http.StatusOK},
+		{"파싱 불가 주소", "/api/users/alice", "unknown", http.StatusForbidden},
+		{"Health는 제외", "/health", "203.0.113.7", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, ipRequest(tt.path, tt.ip))
+			if rr.Code != tt.expectCode {
+				t.Errorf("Status = %d; want %d", rr.Code, tt.expectCode)
+			}
+		})
+	}
+}
+
+// TestIPFilterSpoofedForwardedFor tests that client-supplied X-Forwarded-For
+// entries cannot change the allow-list decision
+func TestIPFilterSpoofedForwardedFor(t *testing.T) {
+	router := newTestRouter(t)
+	f := NewIPFilter(newMemoryStore())
+	if err := f.SetConfig(router, IPFilterConfig{Routes: map[string]IPRuleConfig{
+		"users": {Allow: []string{"10.0.0.0/8"}},
+	}}); err != nil {
+		t.Fatalf("SetConfig error: %v", err)
+	}
+	handler := ipFilterMiddleware(f, router, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		name       string
+		hops       int
+		remoteAddr string
+		xff        string
+		expectCode int
+	}{
+		{"Proxy 없음: 헤더 무시", 0, "198.51.100.7:40000", "10.0.0.1", http.StatusForbidden},
+		{"Proxy 1단: 클라이언트가 앞에 붙인 항목 무시", 1, "10.9.9.9:40000", "10.0.0.1, 198.51.100.7", http.StatusForbidden},
+		{"Proxy 1단: Proxy가 기록한 내부 주소", 1, "10.9.9.9:40000", "10.0.0.1", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setTrustedProxyHops(t, tt.hops)
+			req := httptest.NewRequest(http.MethodGet, "/api/users/alice", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("X-Forwarded-For", tt.xff)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tt.expectCode {
+				t.Errorf("Status = %d; want %d", rr.Code, tt.expectCode)
+			}
+		})
+	}
+}
+
+// TestIPBans tests automatic bans from both triggers and their sharing over Redis
+func TestIPBans(t *testing.T) {
+	router := newTestRouter(t)
+	store, _ := newTestRedisStore(t)
+	cfg := IPFilterConfig{Ban: &BanConfig{RateLimitViolations: 3, FailedLogins: 2, Duration: jsonDuration(time.Minute)}}
+	replicaA, replicaB := NewIPFilter(store), NewIPFilter(store)
+	for _, f := range []*IPFilter{replicaA, replicaB} {
+		if err := f.SetConfig(router, cfg); err != nil {
+			t.Fatalf("SetConfig error: %v", err)
+		}
+	}
+	login := func(f *IPFilter, ip string) int {
+		rr := httptest.NewRecorder()
+		ipFilterMiddleware(f, router, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		})).ServeHTTP(rr, ipRequest("/api/login", ip))
+		return rr.Code
+	}
+	ctx := context.Background()
+
+	t.Run("Rate Limit 위반 누적 후 차단", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			replicaA.recordViolation(ctx, "198.51.100.1", banTriggerRateLimit)
+		}
+		if b, _ := replicaA.ban(ctx, "198.51.100.1"); b != nil {
+			t.Fatal("banned before reaching the threshold")
+		}
+		replicaA.recordViolation(ctx, "198.51.100.1", banTriggerRateLimit)
+
+		// 다른 Replica에서도 차단
+		rr := httptest.NewRecorder()
+		ipFilterMiddleware(replicaB, router, http.NotFoundHandler()).ServeHTTP(rr, ipRequest("/blog/api/posts", "198.51.100.1"))
+		p := decodeProblem(t, rr)
+		if rr.Code != http.StatusForbidden || p.Type != "/problems/client-banned" || rr.Header().Get("Retry-After") == "" {
+			t.Errorf("problem = %+v", p)
+		}
+	})
+
+	t.Run("로그인 실패 누적 후 차단", func(t *testing.T) {
+		if code := login(replicaA, "198.51.100.2"); code != http.StatusUnauthorized {
+			t.Fatalf("first login = %d; want 401 from upstream", code)
+		}
+		login(replicaB, "198.51.100.2")
+		if code := login(replicaA, "198.51.100.2"); code != http.StatusForbidden {
+			t.Errorf("login after 2 failures = %d; want 403", code)
+		}
+		b, _ := replicaA.ban(ctx, "198.51.100.2")
+		if b == nil || b.Trigger != banTriggerFailedLogin {
+			t.Errorf("ban = %+v; want failed_login ban", b)
+		}
+	})
+
+	t.Run("차단 해제", func(t *testing.T) {
+		if removed, err := replicaB.Unban(ctx, "198.51.100.2"); !removed || err != nil {
+			t.Fatalf("Unban = %v, %v", removed, err)
+		}
+		if code := login(replicaA, "198.51.100.2"); code != http.StatusUnauthorized {
+			t.Errorf("login after unban = %d; want 401", code)
+		}
+	})
+}
+
+// TestIPFilterConfigErrors tests rejected ip_filter sections
+func TestIPFilterConfigErrors(t *testing.T) {
+	router := newTestRouter(t)
+	tests := []struct {
+		name string
+		cfg  IPFilterConfig
+	}{
+		{"알 수 없는 Route", IPFilterConfig{Routes: map[string]IPRuleConfig{"nope": {Deny: []string{"10.0.0.0/8"}}}}},
+		{"잘못된 CIDR", IPFilterConfig{Routes: map[string]IPRuleConfig{"users": {Allow: []string{"10.0.0.0/33"}}}}},
+		{"잘못된 주소", IPFilterConfig{Routes: map[string]IPRuleConfig{"*": {Deny: []string{"example.com"}}}}},
+		{"음수 임계값", IPFilterConfig{Ban: &BanConfig{FailedLogins: -1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := NewIPFilter(newMemoryStore()).SetConfig(router, tt.cfg); err == nil {
+				t.Error("expected config error")
+			}
+		})
+	}
+}
+
+// TestAdminBans tests listing and removing bans
+func TestAdminBans(t *testing.T) {
+	admin, handler := newTestAdmin(t)
+	admin.ipFilter.SetConfig(admin.router, IPFilterConfig{Ban: &BanConfig{FailedLogins: 1}})
+	admin.ipFilter.recordViolation(context.Background(), "198.51.100.3", banTriggerFailedLogin)
+
+	rr := adminRequest(handler, http.MethodGet, "/admin/bans", "")
+	var body struct {
+		Bans []ipBan `json:"bans"`
+	}
+	json.NewDecoder(rr.Body).Decode(&body)
+	if len(body.Bans) != 1 || body.Bans[0].IP != "198.51.100.3" || !body.Bans[0].Until.After(time.Now()) {
+		t.Fatalf("bans = %+v", body.Bans)
+	}
+
+	if rr := adminRequest(handler, http.MethodDelete, "/admin/bans/198.51.100.3", ""); rr.Code != http.StatusOK {
+		t.Errorf("unban status = %d; want 200", rr.Code)
+	}
+	if rr := adminRequest(handler, http.MethodDelete, "/admin/bans/198.51.100.3", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("second unban status = %d; want 404", rr.Code)
+	}
+	if entries := admin.audit.Entries(); len(entries) == 0 || entries[len(entries)-1].Target != "198.51.100.3" {
+		t.Errorf("audit = %+v; want unban target", entries)
+	}
+}
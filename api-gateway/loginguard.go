@@ -0,0 +1,307 @@
+// api-gateway/loginguard.go
+// 로그인 Brute-force 방어: Username별 실패 누적에 따른 점진적 지연과 임시 잠금 (IP를 바꿔가는 Credential Stuffing 대응)
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	loginFailuresTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "login_failures_total",
+		Help: "Total number of failed logins (401 from auth-service) seen by the gateway",
+	})
+	loginLockoutsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "login_lockouts_total",
+		Help: "Total number of accounts temporarily locked after repeated failed logins",
+	})
+	loginGuardDelaysTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "login_guard_delays_total",
+		Help: "Total number of login attempts delayed because of earlier failures",
+	})
+	loginGuardRejectionsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "login_guard_rejections_total",
+			Help: "Total number of login attempts rejected by the gateway by reason (locked, body_too_large)",
+		},
+		[]string{"reason"},
+	)
+)
+
+const problemTypeLoginLocked = "/problems/login-locked"
+
+// LoginGuardConfig is the "login_guard" section of the config file. The guard
+// is on by default; zero values fall back to the defaults below.
+type LoginGuardConfig struct {
+	Disabled bool `json:"disabled,omitempty"`
+	// MaxBodyBytes caps the login body the gateway reads to find the username.
+	// Larger bodies are rejected so padding cannot be used to skip the guard
+	MaxBodyBytes int64 `json:"max_body_bytes,omitempty"`
+	// DelayAfter failures within Window, each attempt is held back for
+	// BaseDelay, doubling per further failure up to MaxDelay
+	DelayAfter int          `json:"delay_after,omitempty"`
+	BaseDelay  jsonDuration `json:"base_delay,omitempty"`
+	MaxDelay   jsonDuration `json:"max_delay,omitempty"`
+	// LockoutAfter failures within Window lock the account for LockoutDuration
+	LockoutAfter    int          `json:"lockout_after,omitempty"`
+	Window          jsonDuration `json:"window,omitempty"`
+	LockoutDuration jsonDuration `json:"lockout_duration,omitempty"`
+}
+
+var defaultLoginGuardConfig = LoginGuardConfig{
+	MaxBodyBytes:    4 << 10,
+	DelayAfter:      3,
+	BaseDelay:       jsonDuration(500 * time.Millisecond),
+	MaxDelay:        jsonDuration(4 * time.Second), // 서버 WriteTimeout(10s) 안에서 응답
+	LockoutAfter:    10,
+	Window:          jsonDuration(15 * time.Minute),
+	LockoutDuration: jsonDuration(15 * time.Minute),
+}
+
+func newLoginGuardConfig(cfg LoginGuardConfig) (*LoginGuardConfig, error) {
+	if cfg.MaxBodyBytes < 0 || cfg.DelayAfter < 0 || cfg.BaseDelay < 0 || cfg.MaxDelay < 0 ||
+		cfg.LockoutAfter < 0 || cfg.Window < 0 || cfg.LockoutDuration < 0 {
+		return nil, fmt.Errorf("login_guard: values must not be negative")
+	}
+	d := defaultLoginGuardConfig
+	if cfg.MaxBodyBytes == 0 {
+		cfg.MaxBodyBytes = d.MaxBodyBytes
+	}
+	if cfg.DelayAfter == 0 {
+		cfg.DelayAfter = d.DelayAfter
+	}
+	if cfg.BaseDelay == 0 {
+		cfg.BaseDelay = d.BaseDelay
+	}
+	if cfg.MaxDelay == 0 {
+		cfg.MaxDelay = d.MaxDelay
+	}
+	if cfg.LockoutAfter == 0 {
+		cfg.LockoutAfter = d.LockoutAfter
+	}
+	if cfg.Window == 0 {
+		cfg.Window = d.Window
+	}
+	if cfg.LockoutDuration == 0 {
+		cfg.LockoutDuration = d.LockoutDuration
+	}
+	if cfg.MaxDelay < cfg.BaseDelay {
+		return nil, fmt.Errorf("login_guard: max_delay must not be shorter than base_delay")
+	}
+	if cfg.LockoutAfter < cfg.DelayAfter {
+		return nil, fmt.Errorf("login_guard: lockout_after must not be lower than delay_after")
+	}
+	return &cfg, nil
+}
+
+// LoginGuard keeps failure counters and locks in the gateway store, so with
+// GATEWAY_STORE=redis an attacker cannot spread attempts across replicas.
+type LoginGuard struct {
+	store  kvStore
+	config atomic.Pointer[LoginGuardConfig]
+	now    func() time.Time
+	sleep  func(ctx context.Context, d time.Duration) error
+}
+
+func NewLoginGuard(store kvStore) *LoginGuard {
+	g := &LoginGuard{store: store, now: time.Now, sleep: sleepContext}
+	cfg, _ := newLoginGuardConfig(LoginGuardConfig{})
+	g.config.Store(cfg)
+	return g
+}
+
+var globalLoginGuard = NewLoginGuard(gatewayStore)
+
+// SetConfig replaces the thresholds.
+func (g *LoginGuard) SetConfig(cfg LoginGuardConfig) error {
+	c, err := newLoginGuardConfig(cfg)
+	if err != nil {
+		return err
+	}
+	g.config.Store(c)
+	return nil
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// accountKey hashes the normalized username so that store keys stay bounded
+// and do not list account names.
+func accountKey(username string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(username))))
+	return hex.EncodeToString(sum[:16])
+}
+
+// loginLock is the stored lock record.
+type loginLock struct {
+	Until time.Time `json:"until"`
+}
+
+func (g *LoginGuard) failures(ctx context.Context, account string) (int, error) {
+	data, ok, err := g.store.Get(ctx, "loginfail:"+account)
+	if err != nil || !ok {
+		return 0, err
+	}
+	return strconv.Atoi(string(data))
+}
+
+func (g *LoginGuard) lock(ctx context.Context, account string) (*loginLock, error) {
+	data, ok, err := g.store.Get(ctx, "loginlock:"+account)
+	if err != nil || !ok {
+		return nil, err
+	}
+	var l loginLock
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// delay is the hold-back before an attempt after n failures.
+func (cfg *LoginGuardConfig) delay(n int) time.Duration {
+	if n < cfg.DelayAfter {
+		return 0
+	}
+	d := time.Duration(cfg.BaseDelay)
+	for i := cfg.DelayAfter; i < n && d < time.Duration(cfg.MaxDelay); i++ {
+		d *= 2
+	}
+	return min(d, time.Duration(cfg.MaxDelay))
+}
+
+// recordFailure counts a failed login and locks the account at the threshold.
+func (g *LoginGuard) recordFailure(ctx context.Context, cfg *LoginGuardConfig, account, username, ip string) {
+	loginFailuresTotal.Inc()
+	n, err := g.store.Incr(ctx, "loginfail:"+account, time.Duration(cfg.Window))
+	if err != nil {
+		log.Printf("Login guard tracking failed: %v", err)
+		return
+	}
+	if n < int64(cfg.LockoutAfter) {
+		return
+	}
+	record, _ := json.Marshal(loginLock{Until: g.now().Add(time.Duration(cfg.LockoutDuration))})
+	created, err := g.store.SetNX(ctx, "loginlock:"+account, record, time.Duration(cfg.LockoutDuration))
+	if err != nil {
+		log.Printf("Login guard lock failed: %v", err)
+		return
+	}
+	g.store.Delete(ctx, "loginfail:"+account)
+	if created {
+		loginLockoutsTotal.Inc()
+		// 비밀번호는 기록하지 않음 (Username과 마지막 시도 IP만)
+		log.Printf("[ALERT] suspected brute force: user=%q locked for %s after %d failed logins (last ip=%s)",
+			username, time.Duration(cfg.LockoutDuration), n, ip)
+	}
+}
+
+// loginGuardMiddleware inspects POST bodies of the auth-login route for the
+// username, delays or rejects attempts on accounts with recent failures and
+// records the outcome from auth-service's status code.
+func loginGuardMiddleware(g *LoginGuard, router *Router, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg := g.config.Load()
+		if cfg.Disabled || r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if route := router.Resolve(r.URL.Path); route == nil || route.Name != "auth-login" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, cfg.MaxBodyBytes+1))
+		var tooLarge *http.MaxBytesError
+		if int64(len(body)) > cfg.MaxBodyBytes || errors.As(err, &tooLarge) {
+			loginGuardRejectionsTotal.WithLabelValues("body_too_large").Inc()
+			w.Header().Set("Connection", "close")
+			writeProblem(w, r, &problem{
+				Type:   problemTypeRequestTooLarge,
+				Status: http.StatusRequestEntityTooLarge,
+				Detail: fmt.Sprintf("Login request body exceeds %d bytes", cfg.MaxBodyBytes),
+			})
+			return
+		}
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, "Failed to read request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		var credentials struct {
+			Username string `json:"username"`
+		}
+		if json.Unmarshal(body, &credentials) != nil || strings.TrimSpace(credentials.Username) == "" {
+			// 형식 오류는 auth-service가 422로 응답
+			next.ServeHTTP(w, r)
+			return
+		}
+		account := accountKey(credentials.Username)
+		ctx := r.Context()
+
+		l, err := g.lock(ctx, account)
+		if err != nil {
+			// 저장소 장애 시 fail-open (로그인 가용성 우선)
+			log.Printf("Login guard lookup failed: %v", err)
+		}
+		if l != nil {
+			loginGuardRejectionsTotal.WithLabelValues("locked").Inc()
+			retry := int(l.Until.Sub(g.now()).Seconds()) + 1
+			w.Header().Set("Retry-After", strconv.Itoa(retry))
+			writeProblem(w, r, &problem{
+				Type:       problemTypeLoginLocked,
+				Status:     http.StatusTooManyRequests,
+				Detail:     "Too many failed login attempts; the account is temporarily locked",
+				RetryAfter: retry,
+			})
+			return
+		}
+
+		n, err := g.failures(ctx, account)
+		if err != nil {
+			log.Printf("Login guard lookup failed: %v", err)
+		}
+		if d := cfg.delay(n); d > 0 {
+			loginGuardDelaysTotal.Inc()
+			if g.sleep(ctx, d) != nil {
+				return // 클라이언트가 대기 중 연결 종료
+			}
+		}
+
+		rw := wrapResponseWriter(w)
+		next.ServeHTTP(rw, r)
+		// 응답 후 클라이언트가 연결을 끊어도 실패는 기록
+		ctx = context.WithoutCancel(ctx)
+		switch {
+		case rw.status == http.StatusUnauthorized:
+			g.recordFailure(ctx, cfg, account, credentials.Username, getClientIP(r))
+		case rw.status >= 200 && rw.status < 300 && n > 0:
+			g.store.Delete(ctx, "loginfail:"+account)
+		}
+	})
+}
@@ -0,0 +1,195 @@
+// api-gateway/loginguard_test.go
+// 단위 테스트: Username별 점진적 지연/잠금, Replica 간 공유, 본문 검사 한도, 비밀번호 비노출
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestLoginGuard returns a guard whose delays are recorded instead of slept
+func newTestLoginGuard(t *testing.T, store kvStore, cfg LoginGuardConfig) (*LoginGuard, *[]time.Duration) {
+	t.Helper()
+	g := NewLoginGuard(store)
+	if err := g.SetConfig(cfg); err != nil {
+		t.Fatalf("SetConfig error: %v", err)
+	}
+	delays := &[]time.Duration{}
+	g.sleep = func(_ context.Context, d time.Duration) error {
+		*delays = append(*delays, d)
+		return nil
+	}
+	return g, delays
+}
+
+// fakeAuthService accepts only alice/correct, like auth-service's /login
+func fakeAuthService(t *testing.T) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var c struct{ Username, Password string }
+		if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+			t.Errorf("upstream could not decode forwarded body: %v", err)
+		}
+		if c.Username == "alice" && c.Password == "correct" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+}
+
+func loginAttempt(handler http.Handler, username, password string) *httptest.ResponseRecorder {
+	body := `{"username": "` + username + `", "password": "` + password + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/login", strings.NewReader(body))
+	req.Header.Set("X-Forwarded-For", "198.51.100.9")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+// TestLoginGuard tests progressive delays, lockout and reset on success
+func TestLoginGuard(t *testing.T) {
+	router := newTestRouter(t)
+	cfg := LoginGuardConfig{DelayAfter: 2, BaseDelay: jsonDuration(time.Second), MaxDelay: jsonDuration(3 * time.Second), LockoutAfter: 5}
+
+	t.Run("실패 누적 시 지연 후 잠금", func(t *testing.T) {
+		g, delays := newTestLoginGuard(t, newMemoryStore(), cfg)
+		handler := loginGuardMiddleware(g, router, fakeAuthService(t))
+		for i := 0; i < 5; i++ {
+			if rr := loginAttempt(handler, "bob", "guess"); rr.Code != http.StatusUnauthorized {
+				t.Fatalf("attempt %d = %d; want 401", i+1, rr.Code)
+			}
+		}
+		want := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}
+		if len(*delays) != len(want) {
+			t.Fatalf("delays = %v; want %v", *delays, want)
+		}
+		for i := range want {
+			if (*delays)[i] != want[i] {
+				t.Errorf("delays = %v; want %v", *delays, want)
+			}
+		}
+
+		// 잠긴 계정은 올바른 비밀번호여도 upstream으로 전달하지 않음
+		rr := loginAttempt(handler, "BOB ", "guess")
+		p := decodeProblem(t, rr)
+		if rr.Code != http.StatusTooManyRequests || p.Type != problemTypeLoginLocked || rr.Header().Get("Retry-After") == "" {
+			t.Errorf("locked attempt = %d %+v", rr.Code, p)
+		}
+		if rr := loginAttempt(handler, "carol", "guess"); rr.Code != http.StatusUnauthorized {
+			t.Errorf("other account = %d; want 401", rr.Code)
+		}
+	})
+
+	t.Run("성공 시 실패 횟수 초기화", func(t *testing.T) {
+		g, delays := newTestLoginGuard(t, newMemoryStore(), cfg)
+		handler := loginGuardMiddleware(g, router, fakeAuthService(t))
+		loginAttempt(handler, "alice", "typo")
+		loginAttempt(handler, "alice", "typo")
+		if rr := loginAttempt(handler, "alice", "correct"); rr.Code != http.StatusOK {
+			t.Fatalf("login = %d; want 200", rr.Code)
+		}
+		loginAttempt(handler, "alice", "correct")
+		if len(*delays) != 1 {
+			t.Errorf("delays = %v; want only the attempt after 2 failures", *delays)
+		}
+	})
+
+	t.Run("Replica 간 공유", func(t *testing.T) {
+		store, _ := newTestRedisStore(t)
+		replicaA, _ := newTestLoginGuard(t, store, cfg)
+		replicaB, _ := newTestLoginGuard(t, store, cfg)
+		handlers := []http.Handler{
+			loginGuardMiddleware(replicaA, router, fakeAuthService(t)),
+			loginGuardMiddleware(replicaB, router, fakeAuthService(t)),
+		}
+		for i := 0; i < 5; i++ {
+			loginAttempt(handlers[i%2], "dave", "guess")
+		}
+		if rr := loginAttempt(handlers[1], "dave", "guess"); rr.Code != http.StatusTooManyRequests {
+			t.Errorf("attempt after 5 failures across replicas = %d; want 429", rr.Code)
+		}
+	})
+}
+
+// TestLoginGuardBody tests body inspection limits and pass-through
+func TestLoginGuardBody(t *testing.T) {
+	router := newTestRouter(t)
+	g, _ := newTestLoginGuard(t, newMemoryStore(), LoginGuardConfig{MaxBodyBytes: 64})
+	var forwarded []byte
+	handler := loginGuardMiddleware(g, router, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded, _ = io.ReadAll(r.Body)
+	}))
+
+	tests := []struct {
+		name       string
+		path       string
+		body       string
+		expectCode int
+	}{
+		{"본문 그대로 전달", "/api/login", `{"username": "alice", "password": "pw"}`, http.StatusOK},
+		{"검사 한도 초과", "/api/login", `{"username": "alice", "password": "` + strings.Repeat(" ", 64) + `"}`, http.StatusRequestEntityTooLarge},
+		{"JSON 아님", "/api/login", `username=alice`, http.StatusOK},
+		{"로그인 외 Route", "/api/register", strings.Repeat("x", 128), http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forwarded = nil
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body)))
+			if rr.Code != tt.expectCode {
+				t.Fatalf("Status = %d; want %d", rr.Code, tt.expectCode)
+			}
+			if tt.expectCode == http.StatusOK && string(forwarded) != tt.body {
+				t.Errorf("forwarded body = %q; want %q", forwarded, tt.body)
+			}
+		})
+	}
+}
+
+// TestLoginGuardNeverLogsPasswords tests the lockout alert log line
+func TestLoginGuardNeverLogsPasswords(t *testing.T) {
+	var buf bytes.Buffer
+	original := log.Writer()
+	log.SetOutput(&buf)
+	defer log.SetOutput(original)
+
+	g, _ := newTestLoginGuard(t, newMemoryStore(), LoginGuardConfig{DelayAfter: 1, LockoutAfter: 2})
+	handler := loginGuardMiddleware(g, newTestRouter(t), fakeAuthService(t))
+	loginAttempt(handler, "erin", "hunter2-secret")
+	loginAttempt(handler, "erin", "hunter2-secret")
+
+	if !strings.Contains(buf.String(), "[ALERT]") {
+		t.Fatalf("no lockout alert logged: %s", buf.String())
+	}
+	if strings.Contains(buf.String(), "hunter2-secret") {
+		t.Errorf("password leaked into logs: %s", buf.String())
+	}
+}
+
+// TestLoginGuardConfigErrors tests rejected login_guard sections
+func TestLoginGuardConfigErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  LoginGuardConfig
+	}{
+		{"음수 값", LoginGuardConfig{LockoutAfter: -1}},
+		{"max_delay < base_delay", LoginGuardConfig{BaseDelay: jsonDuration(time.Minute)}},
+		{"lockout_after < delay_after", LoginGuardConfig{DelayAfter: 5, LockoutAfter: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := NewLoginGuard(newMemoryStore()).SetConfig(tt.cfg); err == nil {
+				t.Error("expected config error")
+			}
+		})
+	}
+}
@@ -8,9 +8,9 @@ import (
 	"log"
 	"net"
 	"net/http"
-	"net/http/httputil"
 	"net/url"
 	"os"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -37,6 +37,22 @@ var (
 		},
 		[]string{"method"},
 	)
+	httpResponseSize = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "http_response_size_bytes",
+			Help:    "Size of HTTP response bodies",
+			Buckets: prometheus.ExponentialBuckets(256, 4, 8), // 256B ~ 4MB
+		},
+		[]string{"method"},
+	)
+	httpTimeToFirstByte = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "http_time_to_first_byte_seconds",
+			Help:    "Time until the response status line was written",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"method"},
+	)
 )
 
 func getEnv(key, fallback string) string {
@@ -57,7 +73,8 @@ func corsMiddleware(next http.Handler) http.Handler {
 				w.Header().Set("Access-Control-Allow-Origin", origin)
 				w.Header().Set("Access-Control-Allow-Credentials", "true")
 				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With")
+				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, X-CSRF-Token, Idempotency-Key, API-Version, X-Grpc-Web, X-User-Agent, Grpc-Timeout")
+				w.Header().Set("Access-Control-Expose-Headers", "Composite-Partial, Grpc-Status, Grpc-Message")
 				w.Header().Set("Access-Control-Max-Age", "86400")
 				break
 			}
@@ -152,22 +169,72 @@ func (rl *RateLimiter) GetLimiter(ip string) *rate.Limiter {
 	return v.limiter
 }
 
+// visitorSnapshot is the admin view of one client's token bucket
+type visitorSnapshot struct {
+	Client   string    `json:"client"`
+	Tokens   float64   `json:"tokens"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// Snapshot returns the current bucket of every tracked client.
+func (rl *RateLimiter) Snapshot() []visitorSnapshot {
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+
+	snapshot := make([]visitorSnapshot, 0, len(rl.visitors))
+	for ip, v := range rl.visitors {
+		snapshot = append(snapshot, visitorSnapshot{Client: ip, Tokens: v.limiter.Tokens(), LastSeen: v.lastSeen})
+	}
+	sort.Slice(snapshot, func(i, j int) bool { return snapshot[i].Client < snapshot[j].Client })
+	return snapshot
+}
+
+// Reset forgets a client's bucket so its next request starts with a full burst.
+func (rl *RateLimiter) Reset(ip string) bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	_, exists := rl.visitors[ip]
+	delete(rl.visitors, ip)
+	return exists
+}
+
 // Rate Limit: 20 req/sec, burst 50 (Gemini recommendation)
 var globalLimiter = NewRateLimiter(20, 50)
 
-func getClientIP(r *http.Request) string {
-	// X-Forwarded-For header (reverse proxy)
-	xff := r.Header.Get("X-Forwarded-For")
-	if xff != "" {
-		ips := strings.Split(xff, ",")
-		return strings.TrimSpace(ips[0])
+// trustedProxyHops is the number of proxies in front of the gateway (ingress,
+// load balancer) that append to X-Forwarded-For. 0 trusts no header and uses
+// the connection's peer address.
+var trustedProxyHops = getEnvInt("TRUSTED_PROXY_HOPS", 0)
+
+// trustedHopValue returns the entry of a comma-separated header that the
+// outermost trusted proxy wrote: trustedProxyHops from the right, or the
+// first entry of a shorter chain. Without trusted proxies the header came
+// from the client and "" is returned.
+func trustedHopValue(h http.Header, name string) string {
+	if trustedProxyHops <= 0 {
+		return ""
 	}
-	// X-Real-IP header
-	xri := r.Header.Get("X-Real-IP")
-	if xri != "" {
-		return xri
+	var chain []string
+	for _, v := range h.Values(name) {
+		for _, entry := range strings.Split(v, ",") {
+			chain = append(chain, strings.TrimSpace(entry))
+		}
+	}
+	if len(chain) == 0 {
+		return ""
+	}
+	return chain[max(len(chain)-trustedProxyHops, 0)]
+}
+
+// getClientIP returns the address the outermost trusted proxy saw (see
+// trustedHopValue). Entries further left in X-Forwarded-For come from the
+// client and are ignored, so a spoofed header cannot change rate limiting,
+// bans or allow-lists.
+func getClientIP(r *http.Request) string {
+	if ip := trustedHopValue(r.Header, "X-Forwarded-For"); net.ParseIP(ip) != nil {
+		return ip
 	}
-	// Fallback to RemoteAddr
 	ip, _, _ := net.SplitHostPort(r.RemoteAddr)
 	return ip
 }
@@ -180,131 +247,86 @@ func rateLimitMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
+		// API Key 클라이언트는 IP 기반 제한 대신 Key별 Quota로 관리
+		if apiKeyFromContext(r.Context()) != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
 		ip := getClientIP(r)
 		limiter := globalLimiter.GetLimiter(ip)
 		if !limiter.Allow() {
-			w.Header().Set("Content-Type", "application/json")
+			globalIPFilter.recordViolation(r.Context(), ip, banTriggerRateLimit)
 			w.Header().Set("Retry-After", "1")
-			w.WriteHeader(http.StatusTooManyRequests)
-			json.NewEncoder(w).Encode(map[string]string{"error": "Too Many Requests"})
+			writeProblem(w, r, &problem{Status: http.StatusTooManyRequests, Detail: "Rate limit exceeded", RetryAfter: 1})
 			return
 		}
 		next.ServeHTTP(w, r)
 	})
 }
 
-// === Request Size Limit ===
-const MaxRequestBodySize = 10 << 20 // 10MB (Gemini recommendation)
-
-func requestSizeLimitMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		r.Body = http.MaxBytesReader(w, r.Body, MaxRequestBodySize)
-		next.ServeHTTP(w, r)
-	})
-}
-
-// statusRecorder records the status code of the response
-type statusRecorder struct {
-	http.ResponseWriter
-	status int
-}
-
-func (r *statusRecorder) WriteHeader(status int) {
-	r.status = status
-	r.ResponseWriter.WriteHeader(status)
+// statusClass groups a status code into the "2xx".."5xx" metric label
+func statusClass(status int) string {
+	switch {
+	case status >= 500:
+		return "5xx"
+	case status >= 400:
+		return "4xx"
+	case status >= 300:
+		return "3xx"
+	case status >= 200:
+		return "2xx"
+	default:
+		return "unknown"
+	}
 }
 
 func prometheusMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		recorder := &statusRecorder{
-			ResponseWriter: w,
-			status:         http.StatusOK,
-		}
 		timer := prometheus.NewTimer(httpRequestDuration.WithLabelValues(r.Method))
-		next.ServeHTTP(recorder, r)
-		timer.ObserveDuration()
-
-		// Record status code
-		statusClass := "unknown"
-		if recorder.status >= 500 {
-			statusClass = "5xx"
-		} else if recorder.status >= 400 {
-			statusClass = "4xx"
-		} else if recorder.status >= 300 {
-			statusClass = "3xx"
-		} else if recorder.status >= 200 {
-			statusClass = "2xx"
-		}
-		httpRequestsTotal.WithLabelValues(r.Method, statusClass).Inc()
+		observe(w, r, next, func(rw *responseWriter) {
+			timer.ObserveDuration()
+
+			// Record status code, size and time to first byte
+			httpRequestsTotal.WithLabelValues(r.Method, statusClass(rw.status)).Inc()
+			httpResponseSize.WithLabelValues(r.Method).Observe(float64(rw.bytes))
+			if rw.wroteHeader {
+				httpTimeToFirstByte.WithLabelValues(r.Method).Observe(rw.firstByte.Seconds())
+			}
+		})
 	})
 }
 
-func main() {
-	port := getEnv("API_GATEWAY_PORT", "8000")
-
-	// 각 서비스 URL 파싱
-	userServiceURL, _ := url.Parse(getEnv("USER_SERVICE_URL", "http://user-service:8001"))
-	authServiceURL, _ := url.Parse(getEnv("AUTH_SERVICE_URL", "http://auth-service:8002"))
-	blogServiceURL, _ := url.Parse(getEnv("BLOG_SERVICE_URL", "http://blog-service:8005"))
-
-	// Create proxies with custom director to preserve hostname for Istio
-	userProxy := &httputil.ReverseProxy{
-		Director: func(req *http.Request) {
-			req.URL.Scheme = userServiceURL.Scheme
-			req.URL.Host = userServiceURL.Host
-			req.Host = userServiceURL.Host
-		},
-	}
-
-	authProxy := &httputil.ReverseProxy{
-		Director: func(req *http.Request) {
-			req.URL.Scheme = authServiceURL.Scheme
-			req.URL.Host = authServiceURL.Host
-			req.Host = authServiceURL.Host
-		},
-	}
-
-	blogProxy := &httputil.ReverseProxy{
-		Director: func(req *http.Request) {
-			req.URL.Scheme = blogServiceURL.Scheme
-			req.URL.Host = blogServiceURL.Host
-			req.Host = blogServiceURL.Host
-		},
+// newUpstreams creates one proxy per backend service in cfg.
+func newUpstreams(cfg *GatewayConfig) map[string]*Upstream {
+	upstreams := make(map[string]*Upstream, len(cfg.Upstreams))
+	for name, rawURL := range cfg.Upstreams {
+		target, err := url.Parse(rawURL)
+		if err != nil {
+			log.Fatalf("invalid upstream URL for %s: %v", name, err)
+		}
+		var modifyResponse func(*http.Response) error
+		if name == "blog-service" {
+			modifyResponse = setProxiedStaticCacheHeaders
+		}
+		breaker := newCircuitBreaker(name, cfg.CircuitBreakerThreshold, time.Duration(cfg.CircuitBreakerOpen))
+		upstreams[name] = NewUpstream(name, target, breaker, modifyResponse)
 	}
+	return upstreams
+}
 
+// newGatewayMux registers the public gateway endpoints.
+func newGatewayMux(router *Router) *http.ServeMux {
 	mux := http.NewServeMux()
 
-	apiHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		path := r.URL.Path
-
-		// /api/ 접두사를 제거하여 실제 경로 추출
-		trimmedPath := strings.TrimPrefix(path, "/api")
-
-		// /blog/api/ 같은 다른 접두사도 처리
-		if strings.HasPrefix(path, "/blog/api/") {
-			trimmedPath = strings.TrimPrefix(path, "/blog/api")
-		}
-
-		if strings.HasSuffix(trimmedPath, "/login") {
-			r.URL.Path = "/login"
-			authProxy.ServeHTTP(w, r)
-		} else if strings.HasSuffix(trimmedPath, "/register") {
-			r.URL.Path = "/users" // Register는 user-service의 /users 엔드포인트를 사용
-			userProxy.ServeHTTP(w, r)
-		} else if strings.HasPrefix(trimmedPath, "/users") {
-			r.URL.Path = trimmedPath
-			userProxy.ServeHTTP(w, r)
-		} else if strings.HasPrefix(trimmedPath, "/posts") || strings.HasPrefix(trimmedPath, "/categories") {
-			r.URL.Path = path // blog service의 전체 경로 사용
-			blogProxy.ServeHTTP(w, r)
-		} else {
-			http.NotFound(w, r)
-		}
-	})
-
-	mux.Handle("/api/", apiHandler)
-	mux.Handle("/blog/api/", apiHandler)
+	mux.Handle("/api/", router)
+	mux.Handle("/blog/api/", router)
+	// Blog HTML 페이지 및 정적 자산 (/blog/api/ 는 더 구체적인 패턴이 우선)
+	mux.Handle("/blog/", router)
+	mux.Handle(graphQLPath, graphQLHandler(router))
 	mux.Handle("/metrics", promhttp.Handler())
+	// gRPC 경로(/package.Service/Method)는 Content-Type으로 구분
+	mux.Handle("/", grpcHandler(router, http.HandlerFunc(notFound)))
 
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -315,29 +337,88 @@ func main() {
 			"api-gateway": map[string]interface{}{
 				"service_status": "online",
 				"info":           "Proxying API requests",
+				"maintenance":    maintenanceStats(),
 			},
 		}
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(stats)
 	})
+	return mux
+}
+
+func main() {
+	cfg := loadConfigFromEnv()
+
+	upstreams := newUpstreams(cfg)
+	startHealthChecks(upstreams, time.Duration(cfg.HealthCheckInterval))
+
+	// BLOG_STATIC_DIR이 설정되면 /blog/static/ 자산을 blog-service 대신 직접 서빙
+	router := NewRouter(defaultRoutes(), upstreams, newBlogStaticHandler(cfg.BlogStaticDir))
 
-	// Middleware Chain: CORS -> RequestSize -> RateLimit -> Security -> Prometheus -> Mux
+	if cfg.ConfigFile != "" {
+		if err := reloadConfigFile(cfg.ConfigFile, router); err != nil {
+			log.Fatalf("config file: %v", err)
+		}
+		watchConfigReload(cfg.ConfigFile, router)
+	}
+	mux := newGatewayMux(router)
+
+	// Middleware Chain: RequestID -> Recovery -> AccessLog -> CORS -> IPFilter -> CSRF -> RequestSize -> LoginGuard -> BFF -> APIKey -> RateLimit -> Security -> Idempotency -> Prometheus -> Mux
+	// RequestID comes first so that every error response carries the request id
+	// Recovery wraps everything else so a panic in any middleware still gets a 500
 	// CORS must be outermost to ensure CORS headers are included in rate limit errors
-	handler := corsMiddleware(
-		requestSizeLimitMiddleware(
-			rateLimitMiddleware(
-				securityHeadersMiddleware(
-					prometheusMiddleware(mux)))))
+	// IPFilter rejects banned and unlisted clients before any other work is done
+	// CSRF sits after CORS so preflights are answered before any token check
+	// LoginGuard reads the login body after RequestSize has capped it
+	// BFF injects Authorization after CSRF has checked the cookie-authenticated request
+	// APIKey runs before RateLimit so authenticated machine clients skip the per-IP limiter
+	// Idempotency replays stored responses inside Security so they still get the security headers
+	handler := requestIDMiddleware(
+		recoveryMiddleware(
+			accessLogMiddleware(
+				corsMiddleware(
+					ipFilterMiddleware(globalIPFilter, router,
+						csrfMiddleware(globalCSRF, router,
+							requestSizeLimitMiddleware(router,
+								loginGuardMiddleware(globalLoginGuard, router,
+									bffMiddleware(globalBFF, router,
+										apiKeyMiddleware(router,
+											rateLimitMiddleware(
+												securityHeadersMiddleware(
+													idempotencyMiddleware(globalIdempotency, router,
+														prometheusMiddleware(mux))))))))))))))
+
+	// Admin API는 별도 포트에서만 노출 (외부 Service/Ingress에 포함하지 않음)
+	if adminToken != "" {
+		admin := NewAdminServer(cfg, router, globalLimiter, globalAPIKeyAuth, globalIPFilter)
+		adminSrv := &http.Server{
+			Addr:              ":" + cfg.AdminPort,
+			Handler:           recoveryMiddleware(admin.Handler()),
+			ReadHeaderTimeout: 2 * time.Second,
+			WriteTimeout:      10 * time.Second,
+			IdleTimeout:       60 * time.Second,
+		}
+		go func() {
+			log.Printf("Admin API started on :%s", cfg.AdminPort)
+			if err := adminSrv.ListenAndServe(); err != nil {
+				log.Fatal(err)
+			}
+		}()
+	}
 
-	log.Printf("Go API Gateway started on :%s", port)
+	log.Printf("Go API Gateway started on :%s", cfg.Port)
 	srv := &http.Server{
-		Addr:              ":" + port,
+		Addr:              ":" + cfg.Port,
 		Handler:           handler,
 		ReadHeaderTimeout: 2 * time.Second,
 		WriteTimeout:      10 * time.Second,
 		IdleTimeout:       60 * time.Second,
 		MaxHeaderBytes:    1 << 14, // 16KB max header size
 	}
+	// gRPC 클라이언트를 위해 평문 HTTP/2(h2c, prior knowledge)도 허용
+	srv.Protocols = new(http.Protocols)
+	srv.Protocols.SetHTTP1(true)
+	srv.Protocols.SetUnencryptedHTTP2(true)
 	if err := srv.ListenAndServe(); err != nil {
 		log.Fatal(err)
 	}
@@ -61,47 +61,78 @@ func TestGetEnv(t *testing.T) {
 	}
 }
 
-// TestGetClientIP tests client IP extraction from various headers
+// setTrustedProxyHops sets trustedProxyHops for the duration of the test.
+func setTrustedProxyHops(t *testing.T, hops int) {
+	t.Helper()
+	prev := trustedProxyHops
+	trustedProxyHops = hops
+	t.Cleanup(func() { trustedProxyHops = prev })
+}
+
+// TestGetClientIP tests client IP extraction behind trusted proxies
 func TestGetClientIP(t *testing.T) {
 	tests := []struct {
 		name       string
+		hops       int
 		xff        string
 		xri        string
+		forwarded  string
 		remoteAddr string
 		expected   string
 	}{
 		{
-			name:       "X-Forwarded-For 헤더 사용",
+			name:       "Proxy 1단: 가장 오른쪽 항목 사용",
+			hops:       1,
 			xff:        "192.168.1.1, 10.0.0.1, 172.16.0.1",
-			xri:        "",
 			remoteAddr: "127.0.0.1:12345",
-			expected:   "192.168.1.1",
+			expected:   "172.16.0.1",
 		},
 		{
-			name:       "X-Real-IP 헤더 사용",
-			xff:        "",
-			xri:        "192.168.1.100",
+			name:       "Proxy 2단: 오른쪽에서 두 번째 항목 사용",
+			hops:       2,
+			xff:        "192.168.1.1, 10.0.0.1, 172.16.0.1",
+			remoteAddr: "127.0.0.1:12345",
+			expected:   "10.0.0.1",
+		},
+		{
+			name:       "체인이 hop 수보다 짧으면 첫 항목",
+			hops:       3,
+			xff:        "203.0.113.50",
 			remoteAddr: "127.0.0.1:12345",
-			expected:   "192.168.1.100",
+			expected:   "203.0.113.50",
 		},
 		{
-			name:       "RemoteAddr 폴백",
-			xff:        "",
-			xri:        "",
+			name:       "신뢰 Proxy 없음: 헤더 무시",
+			xff:        "192.168.1.1",
 			remoteAddr: "10.0.0.50:54321",
 			expected:   "10.0.0.50",
 		},
 		{
-			name:       "단일 IP X-Forwarded-For",
-			xff:        "203.0.113.50",
-			xri:        "",
-			remoteAddr: "127.0.0.1:12345",
-			expected:   "203.0.113.50",
+			name:       "파싱 불가 항목은 RemoteAddr 폴백",
+			hops:       1,
+			xff:        "unknown",
+			remoteAddr: "10.0.0.50:54321",
+			expected:   "10.0.0.50",
+		},
+		{
+			name:       "X-Real-IP 헤더는 신뢰하지 않음",
+			hops:       1,
+			xri:        "192.168.1.100",
+			remoteAddr: "10.0.0.50:54321",
+			expected:   "10.0.0.50",
+		},
+		{
+			name:       "Forwarded 헤더는 신뢰하지 않음",
+			hops:       1,
+			forwarded:  `for="[2001:db8::1]:4711";proto=https`,
+			remoteAddr: "10.0.0.50:54321",
+			expected:   "10.0.0.50",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			setTrustedProxyHops(t, tt.hops)
 			req := httptest.NewRequest(http.MethodGet, "/", nil)
 			if tt.xff != "" {
 				req.Header.Set("X-Forwarded-For", tt.xff)
@@ -109,6 +140,9 @@ func TestGetClientIP(t *testing.T) {
 			if tt.xri != "" {
 				req.Header.Set("X-Real-IP", tt.xri)
 			}
+			if tt.forwarded != "" {
+				req.Header.Set("Forwarded", tt.forwarded)
+			}
 			req.RemoteAddr = tt.remoteAddr
 
 			result := getClientIP(req)
@@ -398,11 +432,11 @@ func TestRateLimitMiddleware(t *testing.T) {
 	})
 }
 
-// TestStatusRecorder tests the status recorder
+// TestStatusRecorder tests status recording by the response writer wrapper
 func TestStatusRecorder(t *testing.T) {
 	t.Run("기본 상태 코드 200", func(t *testing.T) {
 		rr := httptest.NewRecorder()
-		sr := &statusRecorder{ResponseWriter: rr, status: http.StatusOK}
+		sr := wrapResponseWriter(rr)
 
 		if sr.status != http.StatusOK {
 			t.Errorf("Default status = %d; want %d", sr.status, http.StatusOK)
@@ -411,7 +445,7 @@ func TestStatusRecorder(t *testing.T) {
 
 	t.Run("WriteHeader 호출 시 상태 기록", func(t *testing.T) {
 		rr := httptest.NewRecorder()
-		sr := &statusRecorder{ResponseWriter: rr, status: http.StatusOK}
+		sr := wrapResponseWriter(rr)
 
 		sr.WriteHeader(http.StatusNotFound)
 
@@ -435,7 +469,7 @@ func TestRequestSizeLimitMiddleware(t *testing.T) {
 		w.WriteHeader(http.StatusOK)
 	})
 
-	handler := requestSizeLimitMiddleware(nextHandler)
+	handler := requestSizeLimitMiddleware(newTestRouter(t), nextHandler)
 
 	t.Run("정상 크기 요청 허용", func(t *testing.T) {
 		body := strings.NewReader("small body")
@@ -0,0 +1,231 @@
+// api-gateway/maintenance.go
+// 점검 모드: 전체 또는 Route/Upstream 단위로 503 점검 페이지 응답 (운영자 우회 가능)
+
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var maintenanceResponsesTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "maintenance_responses_total",
+		Help: "Total number of requests answered with the maintenance page",
+	},
+	[]string{"route"},
+)
+
+const maintenanceBypassHeader = "X-Maintenance-Bypass"
+
+// MaintenanceConfig is the "maintenance" section of the gateway config file
+// and the body accepted by PUT /admin/maintenance.
+type MaintenanceConfig struct {
+	// Enabled puts every route in maintenance
+	Enabled bool `json:"enabled"`
+	// Routes/Upstreams limit maintenance to the named routes or upstreams
+	Routes     []string     `json:"routes,omitempty"`
+	Upstreams  []string     `json:"upstreams,omitempty"`
+	RetryAfter jsonDuration `json:"retry_after,omitempty"`
+	Message    string       `json:"message,omitempty"`
+	// HTMLFile replaces the built-in HTML page for browser routes
+	HTMLFile string `json:"html_file,omitempty"`
+	// AllowCIDRs lets operators through while maintenance is active
+	AllowCIDRs []string `json:"allow_cidrs,omitempty"`
+}
+
+// maintenanceState is the parsed, immutable form of a MaintenanceConfig.
+type maintenanceState struct {
+	config   MaintenanceConfig
+	allow    []*net.IPNet
+	htmlPage *template.Template
+}
+
+const defaultMaintenanceHTML = `<!DOCTYPE html>
+<html lang="ko">
+<head><meta charset="utf-8"><title>서비스 점검 중</title></head>
+<body>
+<h1>서비스 점검 중입니다</h1>
+<p>{{.Message}}</p>
+<p>{{.RetryAfter}}초 후 다시 시도해 주세요.</p>
+</body>
+</html>
+`
+
+var (
+	defaultMaintenanceTemplate = template.Must(template.New("maintenance").Parse(defaultMaintenanceHTML))
+
+	currentMaintenance atomic.Pointer[maintenanceState]
+
+	// maintenanceBypassToken is a secret, so it comes from the environment only
+	maintenanceBypassToken = getEnv("MAINTENANCE_BYPASS_TOKEN", "")
+)
+
+func init() {
+	currentMaintenance.Store(&maintenanceState{config: envMaintenance(), htmlPage: defaultMaintenanceTemplate})
+}
+
+// envMaintenance is the maintenance config used until a config file sets
+// one, and again when a reloaded file has no "maintenance" section.
+func envMaintenance() MaintenanceConfig {
+	return MaintenanceConfig{Enabled: getEnv("MAINTENANCE_MODE", "false") == "true"}
+}
+
+// newMaintenanceState validates cfg (route and upstream names, CIDRs, HTML
+// template file).
+func newMaintenanceState(router *Router, cfg MaintenanceConfig) (*maintenanceState, error) {
+	for _, name := range cfg.Routes {
+		if router.route(name) == nil {
+			return nil, fmt.Errorf("maintenance: unknown route %q", name)
+		}
+	}
+	for _, name := range cfg.Upstreams {
+		if router.Upstream(name) == nil {
+			return nil, fmt.Errorf("maintenance: unknown upstream %q", name)
+		}
+	}
+	s := &maintenanceState{config: cfg, htmlPage: defaultMaintenanceTemplate}
+	for _, cidr := range cfg.AllowCIDRs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("maintenance allow_cidrs: %w", err)
+		}
+		s.allow = append(s.allow, ipNet)
+	}
+	if cfg.HTMLFile != "" {
+		data, err := os.ReadFile(cfg.HTMLFile)
+		if err != nil {
+			return nil, fmt.Errorf("maintenance html_file: %w", err)
+		}
+		tmpl, err := template.New("maintenance").Parse(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("maintenance html_file: %w", err)
+		}
+		s.htmlPage = tmpl
+	}
+	return s, nil
+}
+
+// SetMaintenance validates and atomically applies a new maintenance config.
+func SetMaintenance(router *Router, cfg MaintenanceConfig) error {
+	s, err := newMaintenanceState(router, cfg)
+	if err != nil {
+		return err
+	}
+	currentMaintenance.Store(s)
+	return nil
+}
+
+func Maintenance() MaintenanceConfig {
+	return currentMaintenance.Load().config
+}
+
+// Active reports whether anything is in maintenance.
+func (s *maintenanceState) Active() bool {
+	return s.config.Enabled || len(s.config.Routes) > 0 || len(s.config.Upstreams) > 0
+}
+
+// appliesTo reports whether the route (or its upstream) is in maintenance.
+func (s *maintenanceState) appliesTo(route *Route) bool {
+	if s.config.Enabled {
+		return true
+	}
+	return slices.Contains(s.config.Routes, route.Name) || slices.Contains(s.config.Upstreams, route.Upstream)
+}
+
+// appliesToUpstream reports whether calls to the named upstream are in
+// maintenance (composite parts and GraphQL resolvers, which have no route).
+func (s *maintenanceState) appliesToUpstream(name string) bool {
+	return s.config.Enabled || slices.Contains(s.config.Upstreams, name)
+}
+
+// bypassed lets allow-listed IPs and holders of the bypass token through.
+// The IP comes from getClientIP, so only trusted proxies' X-Forwarded-For
+// entries count toward allow_cidrs.
+func (s *maintenanceState) bypassed(r *http.Request) bool {
+	if token := r.Header.Get(maintenanceBypassHeader); token != "" && maintenanceBypassToken != "" &&
+		subtle.ConstantTimeCompare([]byte(token), []byte(maintenanceBypassToken)) == 1 {
+		return true
+	}
+	if ip := net.ParseIP(getClientIP(r)); ip != nil {
+		for _, ipNet := range s.allow {
+			if ipNet.Contains(ip) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *maintenanceState) retryAfter() int {
+	if d := time.Duration(s.config.RetryAfter); d > 0 {
+		return int(d.Seconds())
+	}
+	return 60
+}
+
+func (s *maintenanceState) message() string {
+	if s.config.Message != "" {
+		return s.config.Message
+	}
+	return "Service under maintenance"
+}
+
+// prefersHTML reports whether the maintenance page should be rendered as HTML:
+// browser routes (blog-pages) or clients that explicitly accept text/html.
+func prefersHTML(r *http.Request, route *Route) bool {
+	if route != nil && route.Name == "blog-pages" {
+		return true
+	}
+	return !isAPIPath(r.URL.Path) && strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+// serve writes the 503 maintenance response.
+func (s *maintenanceState) serve(w http.ResponseWriter, r *http.Request, route *Route) {
+	routeName, upstream := "", ""
+	if route != nil {
+		routeName, upstream = route.Name, route.Upstream
+	}
+	maintenanceResponsesTotal.WithLabelValues(routeName).Inc()
+
+	retry := s.retryAfter()
+	w.Header().Set("Retry-After", strconv.Itoa(retry))
+	w.Header().Set("Cache-Control", "no-store")
+
+	if prefersHTML(r, route) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		s.htmlPage.Execute(w, map[string]interface{}{"Message": s.message(), "RetryAfter": retry})
+		return
+	}
+	writeProblem(w, r, &problem{
+		Type:       "/problems/maintenance",
+		Status:     http.StatusServiceUnavailable,
+		Detail:     s.message(),
+		Upstream:   upstream,
+		RetryAfter: retry,
+	})
+}
+
+// maintenanceStats is the /stats view of the maintenance state.
+func maintenanceStats() map[string]interface{} {
+	s := currentMaintenance.Load()
+	return map[string]interface{}{
+		"active":    s.Active(),
+		"global":    s.config.Enabled,
+		"routes":    s.config.Routes,
+		"upstreams": s.config.Upstreams,
+	}
+}
@@ -0,0 +1,217 @@
+// api-gateway/maintenance_test.go
+// 단위 테스트: 점검 모드 (전체/Route/Upstream 단위, 우회, 설정 Reload)
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// useMaintenance applies cfg and restores the disabled state after the test
+func useMaintenance(t *testing.T, router *Router, cfg MaintenanceConfig) {
+	t.Helper()
+	if err := SetMaintenance(router, cfg); err != nil {
+		t.Fatalf("SetMaintenance error: %v", err)
+	}
+	t.Cleanup(func() { SetMaintenance(router, MaintenanceConfig{}) })
+}
+
+// TestMaintenanceScopes tests global, per-route and per-upstream maintenance
+func TestMaintenanceScopes(t *testing.T) {
+	router := newTestRouter(t)
+
+	tests := []struct {
+		name       string
+		cfg        MaintenanceConfig
+		path       string
+		expectCode int
+	}{
+		{"비활성", MaintenanceConfig{}, "/api/users/a", http.StatusOK},
+		{"전체 점검", MaintenanceConfig{Enabled: true}, "/api/users/a", http.StatusServiceUnavailable},
+		{"Route 단위 - 대상", MaintenanceConfig{Routes: []string{"blog-api"}}, "/blog/api/posts", http.StatusServiceUnavailable},
+		{"Route 단위 - 대상 외", MaintenanceConfig{Routes: []string{"blog-api"}}, "/api/users/a", http.StatusOK},
+		{"Upstream 단위 - 대상", MaintenanceConfig{Upstreams: []string{"blog-service"}}, "/blog/", http.StatusServiceUnavailable},
+		{"Upstream 단위 - 대상 외", MaintenanceConfig{Upstreams: []string{"blog-service"}}, "/api/login", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useMaintenance(t, router, tt.cfg)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rr.Code != tt.expectCode {
+				t.Errorf("Status = %d; want %d", rr.Code, tt.expectCode)
+			}
+		})
+	}
+}
+
+// TestMaintenanceResponse tests Retry-After and JSON/HTML rendering
+func TestMaintenanceResponse(t *testing.T) {
+	router := newTestRouter(t)
+	useMaintenance(t, router, MaintenanceConfig{
+		Enabled:    true,
+		RetryAfter: jsonDuration(2 * time.Minute),
+		Message:    "DB migration",
+	})
+
+	t.Run("API 경로는 JSON", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/blog/api/posts", nil))
+		if got := rr.Header().Get("Retry-After"); got != "120" {
+			t.Errorf("Retry-After = %s; want 120", got)
+		}
+		var body map[string]interface{}
+		if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+			t.Fatalf("decode error: %v", err)
+		}
+		if body["detail"] != "DB migration" || body["retry_after"] != float64(120) || body["type"] != "/problems/maintenance" {
+			t.Errorf("body = %v", body)
+		}
+	})
+
+	t.Run("Blog 페이지는 HTML", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/blog/", nil))
+		if !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/html") {
+			t.Errorf("Content-Type = %s; want text/html", rr.Header().Get("Content-Type"))
+		}
+		if !strings.Contains(rr.Body.String(), "DB migration") {
+			t.Error("HTML page should include the message")
+		}
+	})
+
+	t.Run("사용자 정의 HTML 파일", func(t *testing.T) {
+		page := filepath.Join(t.TempDir(), "maintenance.html")
+		os.WriteFile(page, []byte(`<p>custom {{.RetryAfter}}</p>`), 0o644)
+		useMaintenance(t, router, MaintenanceConfig{Enabled: true, HTMLFile: page, RetryAfter: jsonDuration(time.Minute)})
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/blog/", nil))
+		if got := rr.Body.String(); got != "<p>custom 60</p>" {
+			t.Errorf("Body = %q; want custom page", got)
+		}
+	})
+}
+
+// TestMaintenanceBypass tests the operator allow-list and bypass header
+func TestMaintenanceBypass(t *testing.T) {
+	router := newTestRouter(t)
+	useMaintenance(t, router, MaintenanceConfig{Enabled: true, AllowCIDRs: []string{"10.0.0.0/8"}})
+
+	originalToken := maintenanceBypassToken
+	maintenanceBypassToken = "ops-token"
+	defer func() { maintenanceBypassToken = originalToken }()
+
+	tests := []struct {
+		name       string
+		hops       int
+		remoteAddr string
+		xff        string
+		header     string
+		expectCode int
+	}{
+		{"허용 CIDR", 0, "10.1.2.3:1234", "", "", http.StatusOK},
+		{"허용되지 않은 IP", 0, "203.0.113.7:1234", "", "", http.StatusServiceUnavailable},
+		{"우회 헤더", 0, "203.0.113.7:1234", "", "ops-token", http.StatusOK},
+		{"잘못된 우회 헤더", 0, "203.0.113.7:1234", "", "guess", http.StatusServiceUnavailable},
+		{"위조한 X-Forwarded-For (Proxy 없음)", 0, "203.0.113.7:1234", "10.1.2.3", "", http.StatusServiceUnavailable},
+		{"위조한 X-Forwarded-For (Proxy 1단)", 1, "10.9.9.9:1234", "10.1.2.3, 203.0.113.7", "", http.StatusServiceUnavailable},
+		{"Proxy가 기록한 허용 CIDR", 1, "10.9.9.9:1234", "10.1.2.3", "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setTrustedProxyHops(t, tt.hops)
+			req := httptest.NewRequest(http.MethodGet, "/api/users/a", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.xff != "" {
+				req.Header.Set("X-Forwarded-For", tt.xff)
+			}
+			if tt.header != "" {
+				req.Header.Set(maintenanceBypassHeader, tt.header)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if rr.Code != tt.expectCode {
+				t.Errorf("Status = %d; want %d", rr.Code, tt.expectCode)
+			}
+		})
+	}
+}
+
+// TestReloadConfigFile tests applying and rejecting config files
+func TestReloadConfigFile(t *testing.T) {
+	router := newTestRouter(t)
+	defer SetMaintenance(router, MaintenanceConfig{})
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "gateway.json")
+	os.WriteFile(valid, []byte(`{"maintenance": {"routes": ["blog-api"], "retry_after": "5m"}}`), 0o644)
+	if err := reloadConfigFile(valid, router); err != nil {
+		t.Fatalf("reloadConfigFile error: %v", err)
+	}
+	if m := Maintenance(); len(m.Routes) != 1 || time.Duration(m.RetryAfter) != 5*time.Minute {
+		t.Errorf("maintenance = %+v; want blog-api, 5m", m)
+	}
+
+	invalid := filepath.Join(dir, "bad.json")
+	os.WriteFile(invalid, []byte(`{"maintenance": {"allow_cidrs": ["nope"]}}`), 0o644)
+	if err := reloadConfigFile(invalid, router); err == nil {
+		t.Error("invalid CIDR should be rejected")
+	}
+	if m := Maintenance(); len(m.Routes) != 1 {
+		t.Error("failed reload should keep the previous config")
+	}
+
+	unknown := filepath.Join(dir, "unknown.json")
+	os.WriteFile(unknown, []byte(`{"maintenence": {}}`), 0o644)
+	if err := reloadConfigFile(unknown, router); err == nil {
+		t.Error("unknown sections should be rejected")
+	}
+
+	for _, section := range []string{`{"routes": ["blog-apii"]}`, `{"upstreams": ["blog"]}`} {
+		typo := filepath.Join(dir, "typo.json")
+		os.WriteFile(typo, []byte(`{"maintenance": `+section+`}`), 0o644)
+		if err := reloadConfigFile(typo, router); err == nil {
+			t.Errorf("maintenance %s should be rejected", section)
+		}
+	}
+
+	t.Run("maintenance 섹션이 없으면 MAINTENANCE_MODE 유지", func(t *testing.T) {
+		t.Setenv("MAINTENANCE_MODE", "true")
+		other := filepath.Join(dir, "other.json")
+		os.WriteFile(other, []byte(`{"csrf": {"disabled": false}}`), 0o644)
+		if err := reloadConfigFile(other, router); err != nil {
+			t.Fatalf("reloadConfigFile error: %v", err)
+		}
+		if m := Maintenance(); !m.Enabled || len(m.Routes) != 0 {
+			t.Errorf("maintenance = %+v; want enabled from MAINTENANCE_MODE", m)
+		}
+	})
+}
+
+// TestStatsReflectsMaintenance tests the /stats maintenance block
+func TestStatsReflectsMaintenance(t *testing.T) {
+	router := newTestRouter(t)
+	useMaintenance(t, router, MaintenanceConfig{Upstreams: []string{"blog-service"}})
+	mux := newGatewayMux(router)
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/stats", nil))
+	var stats map[string]map[string]interface{}
+	if err := json.NewDecoder(rr.Body).Decode(&stats); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	m, _ := stats["api-gateway"]["maintenance"].(map[string]interface{})
+	if m["active"] != true || m["global"] != false {
+		t.Errorf("maintenance stats = %v; want active, not global", m)
+	}
+}
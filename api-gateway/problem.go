@@ -0,0 +1,124 @@
+// api-gateway/problem.go
+// RFC 7807 Problem Details: Gateway가 직접 생성하는 모든 에러 응답 (application/problem+json)
+
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+const (
+	problemContentType = "application/problem+json"
+
+	problemTypeValidation          = "/problems/request-validation"
+	problemTypeUpstreamUnreachable = "/problems/upstream-unreachable"
+	problemTypeUpstreamTimeout     = "/problems/upstream-timeout"
+	problemTypeRequestTooLarge     = "/problems/request-too-large"
+	problemTypeClientClosed        = "/problems/client-closed-request"
+
+	requestIDHeader            = "X-Request-ID"
+	requestIDKey    contextKey = "request-id"
+
+	// statusClientClosedRequest follows the nginx convention for requests the
+	// client abandoned before the upstream answered.
+	statusClientClosedRequest = 499
+)
+
+// problem is an RFC 7807 problem details body.
+type problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+	// Upstream names the service the request was routed to, if any
+	Upstream string `json:"upstream,omitempty"`
+	// RetryAfter mirrors the Retry-After header in seconds
+	RetryAfter int `json:"retry_after,omitempty"`
+	// Errors lists individual validation failures
+	Errors []fieldError `json:"errors,omitempty"`
+}
+
+// fieldError points at one invalid part of the request.
+type fieldError struct {
+	// In is path, query, header, cookie or body
+	In   string `json:"in"`
+	Name string `json:"name,omitempty"`
+	// Pointer is the JSON pointer of an invalid body field
+	Pointer string `json:"pointer,omitempty"`
+	Detail  string `json:"detail"`
+}
+
+// writeProblem renders p, filling in the defaults and the request context
+// (request id, path and upstream). Every gateway-generated error goes through here.
+func writeProblem(w http.ResponseWriter, r *http.Request, p *problem) {
+	if r != nil && grpcProtocol(r) != "" {
+		// gRPC 클라이언트는 problem+json 대신 grpc-status를 읽음
+		writeGRPCStatus(w, r, grpcCodeFromHTTP(p.Status), p.Detail)
+		return
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+		if p.Status == statusClientClosedRequest {
+			p.Title = "Client Closed Request"
+		}
+	}
+	if p.Type == "" {
+		p.Type = "/problems/" + strings.ReplaceAll(strings.ToLower(p.Title), " ", "-")
+	}
+	if r != nil {
+		p.Instance = r.URL.Path
+		p.RequestID = requestIDFromContext(r.Context())
+		if route := routeFromContext(r.Context()); route != nil && p.Upstream == "" {
+			p.Upstream = route.Upstream
+		}
+	}
+
+	w.Header().Set("Content-Type", problemContentType)
+	w.Header().Del("Content-Length")
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// writeError writes a problem with only a status and detail.
+func writeError(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	writeProblem(w, r, &problem{Status: status, Detail: detail})
+}
+
+// notFound replaces http.NotFound so that 404s are problem+json as well.
+func notFound(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusNotFound, "No route for "+r.URL.Path)
+}
+
+// === Request ID ===
+
+// requestIDMiddleware keeps the caller's X-Request-ID (Istio's ingress sets
+// one) or assigns a new one, and echoes it on the response.
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if id == "" || len(id) > 128 {
+			id = newRequestID()
+			r.Header.Set(requestIDHeader, id)
+		}
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey, id)))
+	})
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func requestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
@@ -0,0 +1,152 @@
+// api-gateway/problem_test.go
+// 단위 테스트: RFC 7807 에러 응답, Request ID, 프록시 에러 매핑
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func decodeProblem(t *testing.T, rr *httptest.ResponseRecorder) problem {
+	t.Helper()
+	if ct := rr.Header().Get("Content-Type"); ct != problemContentType {
+		t.Fatalf("Content-Type = %s; want %s", ct, problemContentType)
+	}
+	var p problem
+	if err := json.NewDecoder(rr.Body).Decode(&p); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	return p
+}
+
+// TestRequestIDMiddleware tests propagation and generation of X-Request-ID
+func TestRequestIDMiddleware(t *testing.T) {
+	var seen string
+	handler := requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.Header.Get(requestIDHeader)
+		if requestIDFromContext(r.Context()) != seen {
+			t.Error("context and header request id differ")
+		}
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(requestIDHeader, "abc-123")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if seen != "abc-123" || rr.Header().Get(requestIDHeader) != "abc-123" {
+		t.Errorf("request id = %s / %s; want caller's abc-123", seen, rr.Header().Get(requestIDHeader))
+	}
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	if len(seen) != 32 || rr.Header().Get(requestIDHeader) != seen {
+		t.Errorf("generated request id = %q; want 32 hex chars echoed", seen)
+	}
+}
+
+// TestGatewayErrorsAreProblems tests that gateway-generated errors share one format
+func TestGatewayErrorsAreProblems(t *testing.T) {
+	handler := requestIDMiddleware(newGatewayMux(newTestRouter(t)))
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		typ    string
+	}{
+		{"알 수 없는 경로", "/nope", http.StatusNotFound, "/problems/not-found"},
+		{"라우트 없는 API 경로", "/api/unknown", http.StatusNotFound, "/problems/not-found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set(requestIDHeader, "req-1")
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			p := decodeProblem(t, rr)
+			if p.Status != tt.status || rr.Code != tt.status || p.Type != tt.typ || p.RequestID != "req-1" || p.Instance != tt.path {
+				t.Errorf("problem = %+v; want %d %s", p, tt.status, tt.typ)
+			}
+		})
+	}
+
+	t.Run("Rate limit", func(t *testing.T) {
+		original := globalLimiter
+		globalLimiter = NewRateLimiter(0, 0)
+		defer func() { globalLimiter = original }()
+
+		rr := httptest.NewRecorder()
+		rateLimitMiddleware(http.NotFoundHandler()).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/users/a", nil))
+		p := decodeProblem(t, rr)
+		if p.Status != http.StatusTooManyRequests || p.Type != "/problems/too-many-requests" || rr.Header().Get("Retry-After") != "1" {
+			t.Errorf("problem = %+v", p)
+		}
+	})
+}
+
+// TestProxyErrorProblems tests the ReverseProxy error mapping
+func TestProxyErrorProblems(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer slow.Close()
+	dead := httptest.NewServer(http.NotFoundHandler())
+	dead.Close()
+
+	serve := func(u *Upstream, req *http.Request) (*httptest.ResponseRecorder, problem) {
+		rr := httptest.NewRecorder()
+		u.ServeHTTP(rr, req)
+		return rr, decodeProblem(t, rr)
+	}
+
+	t.Run("연결 실패는 502", func(t *testing.T) {
+		u := newTestUpstream(t, "user-service", dead)
+		rr, p := serve(u, httptest.NewRequest(http.MethodGet, "/users/a", nil))
+		if rr.Code != http.StatusBadGateway || p.Type != problemTypeUpstreamUnreachable || p.Upstream != "user-service" {
+			t.Errorf("problem = %+v", p)
+		}
+	})
+
+	t.Run("타임아웃은 504", func(t *testing.T) {
+		u := newTestUpstream(t, "user-service", slow)
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		rr, p := serve(u, httptest.NewRequest(http.MethodGet, "/users/a", nil).WithContext(ctx))
+		if rr.Code != http.StatusGatewayTimeout || p.Type != problemTypeUpstreamTimeout {
+			t.Errorf("problem = %+v", p)
+		}
+	})
+
+	t.Run("클라이언트 취소는 499, breaker 실패 아님", func(t *testing.T) {
+		target, _ := url.Parse(slow.URL)
+		u := NewUpstream("user-service", target, newCircuitBreaker("user-service", 1, time.Minute), nil)
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(20*time.Millisecond, cancel)
+		rr, p := serve(u, httptest.NewRequest(http.MethodGet, "/users/a", nil).WithContext(ctx))
+		if rr.Code != statusClientClosedRequest || p.Type != problemTypeClientClosed {
+			t.Errorf("problem = %+v", p)
+		}
+		if u.breaker.State() != breakerClosed {
+			t.Errorf("breaker = %s; client cancellation should not count as failure", u.breaker.State())
+		}
+	})
+
+	t.Run("요청 본문 초과는 413", func(t *testing.T) {
+		u := newTestUpstream(t, "user-service", slow)
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(strings.Repeat("x", 100)))
+		req.Body = http.MaxBytesReader(rr, req.Body, 10)
+		u.ServeHTTP(rr, req)
+		p := decodeProblem(t, rr)
+		if rr.Code != http.StatusRequestEntityTooLarge || p.Type != problemTypeRequestTooLarge {
+			t.Errorf("problem = %+v", p)
+		}
+	})
+}
@@ -0,0 +1,206 @@
+// api-gateway/recording.go
+// 기록 모드: 샘플링한 요청/응답 쌍을 민감 정보 마스킹 후 Capture 파일로 저장 (cmd/replay로 재생)
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"time"
+
+	"titanium-api-go/recording"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var recordedRequestsTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "recorded_requests_total",
+		Help: "Total number of request/response pairs written to capture files",
+	},
+	[]string{"route", "result"},
+)
+
+const (
+	defaultRecordingMaxBodyBytes = 64 << 10
+	defaultRecordingMaxFileBytes = 64 << 20
+	defaultRecordingMaxFiles     = 10
+)
+
+// RecordingConfig is the "recording" section of the config file.
+type RecordingConfig struct {
+	// Dir receives capture-<timestamp>.jsonl files; recording is off when empty
+	Dir string `json:"dir"`
+	// Percent of matching requests recorded (default 100)
+	Percent float64 `json:"percent,omitempty"`
+	// Routes limits recording to the named routes
+	Routes       []string `json:"routes,omitempty"`
+	MaxBodyBytes int64    `json:"max_body_bytes,omitempty"`
+	MaxFileBytes int64    `json:"max_file_bytes,omitempty"`
+	MaxFiles     int      `json:"max_files,omitempty"`
+	// RedactHeaders/RedactFields extend the built-in lists
+	// (Authorization, cookies, API keys; password and token fields)
+	RedactHeaders []string `json:"redact_headers,omitempty"`
+	RedactFields  []string `json:"redact_fields,omitempty"`
+}
+
+type recorder struct {
+	config   RecordingConfig
+	redactor *recording.Redactor
+	writer   *recording.Writer
+}
+
+func newRecorder(cfg RecordingConfig) (*recorder, error) {
+	if cfg.Dir == "" {
+		return nil, nil
+	}
+	if cfg.Percent < 0 || cfg.Percent > 100 || cfg.MaxBodyBytes < 0 || cfg.MaxFileBytes < 0 || cfg.MaxFiles < 0 {
+		return nil, fmt.Errorf("recording: percent must be 0-100 and limits must not be negative")
+	}
+	if cfg.Percent == 0 {
+		cfg.Percent = 100
+	}
+	if cfg.MaxBodyBytes == 0 {
+		cfg.MaxBodyBytes = defaultRecordingMaxBodyBytes
+	}
+	if cfg.MaxFileBytes == 0 {
+		cfg.MaxFileBytes = defaultRecordingMaxFileBytes
+	}
+	if cfg.MaxFiles == 0 {
+		cfg.MaxFiles = defaultRecordingMaxFiles
+	}
+	writer, err := recording.NewWriter(cfg.Dir, cfg.MaxFileBytes, cfg.MaxFiles)
+	if err != nil {
+		return nil, fmt.Errorf("recording: %w", err)
+	}
+	return &recorder{
+		config: cfg,
+		redactor: recording.NewRedactor(
+			append(slices.Clone(recording.DefaultRedactHeaders), cfg.RedactHeaders...),
+			append(slices.Clone(recording.DefaultRedactFields), cfg.RedactFields...),
+		),
+		writer: writer,
+	}, nil
+}
+
+// SetRecording replaces the recording config; an empty Dir stops recording.
+func (rt *Router) SetRecording(cfg RecordingConfig) error {
+	rec, err := newRecorder(cfg)
+	if err != nil {
+		return err
+	}
+	rt.swapRecorder(rec)
+	return nil
+}
+
+func (rt *Router) swapRecorder(rec *recorder) {
+	if old := rt.recorder.Swap(rec); old != nil {
+		old.writer.Close()
+	}
+}
+
+func (rec *recorder) sampled(route *Route) bool {
+	if len(rec.config.Routes) > 0 && !slices.Contains(rec.config.Routes, route.Name) {
+		return false
+	}
+	return rand.Float64()*100 < rec.config.Percent
+}
+
+// serve runs next and appends the exchange to the capture. The request URL is
+// the one the client sent, so replays go through the gateway's own routing.
+func (rec *recorder) serve(w http.ResponseWriter, r *http.Request, route *Route, next http.Handler) {
+	entry := &recording.Record{
+		Time:  time.Now().UTC(),
+		Route: route.Name,
+		Request: recording.Request{
+			Method: r.Method,
+			URL:    rec.redactor.URL(r.URL.RequestURI()),
+		},
+	}
+	entry.Request.Header = rec.redactor.Header(r.Header)
+	body, ok := peekBody(r, rec.config.MaxBodyBytes)
+	entry.Request.Truncated = !ok
+	if ok {
+		entry.Request.SetBody(rec.redactor.Body(r.Header.Get("Content-Type"), body))
+	}
+
+	capture := newCaptureWriter(w, rec.config.MaxBodyBytes)
+	next.ServeHTTP(capture, r)
+
+	entry.DurationMs = float64(time.Since(entry.Time).Microseconds()) / 1000
+	entry.Response.Status = capture.status
+	entry.Response.Header = rec.redactor.Header(w.Header())
+	entry.Response.Truncated = capture.truncated
+	if !capture.truncated {
+		entry.Response.SetBody(rec.redactor.Body(w.Header().Get("Content-Type"), capture.body.Bytes()))
+	}
+
+	switch err := rec.writer.Write(entry); {
+	case err == recording.ErrClosed:
+		// 설정 변경으로 교체된 recorder: 기록되지 않았으므로 집계하지 않음
+	case err != nil:
+		recordedRequestsTotal.WithLabelValues(route.Name, "error").Inc()
+		log.Printf("recording: %v", err)
+	default:
+		recordedRequestsTotal.WithLabelValues(route.Name, "written").Inc()
+	}
+}
+
+// peekBody reads up to limit bytes of the request body and restores it for
+// the next handler. It reports false when the body is larger than limit.
+func peekBody(r *http.Request, limit int64) ([]byte, bool) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, true
+	}
+	buf, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(buf), r.Body), r.Body}
+	if err != nil || int64(len(buf)) > limit {
+		return nil, false
+	}
+	return buf, true
+}
+
+// captureWriter keeps the response status and the first limit bytes of its body.
+type captureWriter struct {
+	http.ResponseWriter
+	status    int
+	body      bytes.Buffer
+	limit     int64
+	truncated bool
+}
+
+func newCaptureWriter(w http.ResponseWriter, limit int64) *captureWriter {
+	return &captureWriter{ResponseWriter: w, status: http.StatusOK, limit: limit}
+}
+
+func (c *captureWriter) WriteHeader(status int) {
+	c.status = status
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *captureWriter) Write(b []byte) (int, error) {
+	if room := c.limit - int64(c.body.Len()); room > 0 {
+		if int64(len(b)) > room {
+			c.body.Write(b[:room])
+			c.truncated = true
+		} else {
+			c.body.Write(b)
+		}
+	} else if len(b) > 0 {
+		c.truncated = true
+	}
+	return c.ResponseWriter.Write(b)
+}
+
+func (c *captureWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
@@ -0,0 +1,86 @@
+// api-gateway/recording/record.go
+// 요청/응답 Capture 포맷 (JSON Lines): Gateway 기록 모드와 cmd/replay가 공유
+
+// Package recording defines the capture format written by the gateway's
+// recording mode and read by cmd/replay. A capture file holds one Record per
+// line (JSON Lines).
+package recording
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+	"unicode/utf8"
+)
+
+// Message is the request or response half of a Record. Bodies that are valid
+// UTF-8 are stored as-is; anything else is base64 encoded.
+type Message struct {
+	Header       http.Header `json:"header,omitempty"`
+	Body         string      `json:"body,omitempty"`
+	BodyEncoding string      `json:"body_encoding,omitempty"`
+	// Truncated is set when the body exceeded the capture limit
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+type Request struct {
+	Method string `json:"method"`
+	// URL is the path and query as received by the gateway
+	URL string `json:"url"`
+	Message
+}
+
+type Response struct {
+	Status int `json:"status"`
+	Message
+}
+
+type Record struct {
+	Time       time.Time `json:"time"`
+	Route      string    `json:"route"`
+	DurationMs float64   `json:"duration_ms"`
+	Request    Request   `json:"request"`
+	Response   Response  `json:"response"`
+}
+
+// SetBody stores body in its text or base64 form.
+func (m *Message) SetBody(body []byte) {
+	if utf8.Valid(body) {
+		m.Body, m.BodyEncoding = string(body), ""
+		return
+	}
+	m.Body, m.BodyEncoding = base64.StdEncoding.EncodeToString(body), "base64"
+}
+
+// BodyBytes decodes the stored body.
+func (m *Message) BodyBytes() ([]byte, error) {
+	if m.BodyEncoding == "base64" {
+		return base64.StdEncoding.DecodeString(m.Body)
+	}
+	return []byte(m.Body), nil
+}
+
+// maxLineBytes bounds a single record when reading a capture.
+const maxLineBytes = 16 << 20
+
+// Read calls fn for every record in a capture, stopping at the first error.
+func Read(r io.Reader, fn func(*Record) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineBytes)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return err
+		}
+		if err := fn(&rec); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
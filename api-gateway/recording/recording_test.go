@@ -0,0 +1,119 @@
+// api-gateway/recording/recording_test.go
+// 단위 테스트: Capture 포맷, 민감 정보 마스킹, 파일 Rotation
+
+package recording
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRedactor tests header, query and body field masking
+func TestRedactor(t *testing.T) {
+	r := NewRedactor(DefaultRedactHeaders, DefaultRedactFields)
+
+	h := r.Header(http.Header{"Authorization": {"Bearer abc"}, "Cookie": {"session=1"}, "Accept": {"*/*"}})
+	if h.Get("Authorization") != Redacted || h.Get("Cookie") != Redacted || h.Get("Accept") != "*/*" {
+		t.Errorf("header = %v", h)
+	}
+
+	urlTests := []struct {
+		name     string
+		uri      string
+		expected string
+	}{
+		{"Query 토큰", "/api/users/a?token=abc&page=2", "/api/users/a?page=2&token=%5BREDACTED%5D"},
+		{"대상 없는 Query는 그대로", "/blog/api/posts?limit=10&offset=0", "/blog/api/posts?limit=10&offset=0"},
+		{"Query 없음", "/api/users/a", "/api/users/a"},
+	}
+	for _, tt := range urlTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.URL(tt.uri); got != tt.expected {
+				t.Errorf("URL = %s; want %s", got, tt.expected)
+			}
+		})
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		expected    string
+	}{
+		{"JSON 중첩 필드", "application/json", `{"user":{"Password":"x","name":"a"},"tokens":[{"token":"t"}]}`,
+			`{"tokens":[{"token":"[REDACTED]"}],"user":{"Password":"[REDACTED]","name":"a"}}`},
+		{"Form", "application/x-www-form-urlencoded", "username=a&password=x", "password=%5BREDACTED%5D&username=a"},
+		{"잘린 JSON은 그대로", "application/json", `{"password":"x`, `{"password":"x`},
+		{"기타 Content-Type", "text/plain", "password=x", "password=x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(r.Body(tt.contentType, []byte(tt.body))); got != tt.expected {
+				t.Errorf("Body = %s; want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestRecordRoundTrip tests text/base64 bodies through Write and Read
+func TestRecordRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewWriter(dir, 0, 0)
+	if err != nil {
+		t.Fatalf("NewWriter error: %v", err)
+	}
+	text := &Record{Route: "users", Request: Request{Method: "GET", URL: "/api/users/a"}}
+	text.Response.Status = 200
+	text.Response.SetBody([]byte(`{"id":1}`))
+	binary := &Record{Route: "blog-pages"}
+	binary.Response.SetBody([]byte{0xff, 0x00})
+	w.Write(text)
+	w.Write(binary)
+	w.Close()
+
+	files, _ := Files(dir)
+	data, _ := os.ReadFile(files[0])
+	var got []*Record
+	if err := Read(bytes.NewReader(data), func(r *Record) error { got = append(got, r); return nil }); err != nil {
+		t.Fatalf("Read error: %v", err)
+	}
+	if len(got) != 2 || got[0].Request.URL != "/api/users/a" || got[0].Response.Body != `{"id":1}` {
+		t.Fatalf("records = %+v", got)
+	}
+	if body, _ := got[1].Response.BodyBytes(); got[1].Response.BodyEncoding != "base64" || !bytes.Equal(body, []byte{0xff, 0x00}) {
+		t.Errorf("binary body = %v (%s)", body, got[1].Response.BodyEncoding)
+	}
+	if err := w.Write(text); err != ErrClosed {
+		t.Errorf("Write after Close = %v; want ErrClosed", err)
+	}
+}
+
+// TestWriterRotation tests size-based rotation and pruning of old files
+func TestWriterRotation(t *testing.T) {
+	dir := t.TempDir()
+	w, _ := NewWriter(dir, 200, 2)
+	tick := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	w.now = func() time.Time { tick = tick.Add(time.Second); return tick }
+
+	for i := 0; i < 6; i++ {
+		rec := &Record{Route: "users", Request: Request{Method: "GET", URL: "/api/users/" + strings.Repeat("a", 50)}}
+		if err := w.Write(rec); err != nil {
+			t.Fatalf("Write error: %v", err)
+		}
+	}
+	w.Close()
+
+	files, _ := Files(dir)
+	if len(files) != 2 {
+		t.Fatalf("files = %v; want 2 after pruning", files)
+	}
+	for _, f := range files {
+		if info, _ := os.Stat(f); info.Size() > 200 {
+			t.Errorf("%s size = %d; want <= 200", f, info.Size())
+		}
+	}
+}
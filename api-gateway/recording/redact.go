@@ -0,0 +1,132 @@
+// api-gateway/recording/redact.go
+// Capture 저장 전 민감 정보 마스킹 (헤더, Query 파라미터, JSON/Form 본문 필드)
+
+package recording
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Redacted replaces every masked header value and body field.
+const Redacted = "[REDACTED]"
+
+var (
+	// DefaultRedactHeaders covers credentials and session cookies.
+	DefaultRedactHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "X-API-Key", "X-Maintenance-Bypass"}
+	// DefaultRedactFields covers password and token fields of auth/user requests.
+	DefaultRedactFields = []string{"password", "token", "access_token", "refresh_token", "secret"}
+)
+
+// Redactor masks headers and body fields by case-insensitive name.
+type Redactor struct {
+	headers map[string]bool
+	fields  map[string]bool
+}
+
+func NewRedactor(headers, fields []string) *Redactor {
+	r := &Redactor{headers: map[string]bool{}, fields: map[string]bool{}}
+	for _, h := range headers {
+		r.headers[http.CanonicalHeaderKey(h)] = true
+	}
+	for _, f := range fields {
+		r.fields[strings.ToLower(f)] = true
+	}
+	return r
+}
+
+// Header returns a copy of h with redacted values.
+func (r *Redactor) Header(h http.Header) http.Header {
+	out := h.Clone()
+	for name, values := range out {
+		if r.headers[name] {
+			for i := range values {
+				values[i] = Redacted
+			}
+		}
+	}
+	return out
+}
+
+// URL masks query parameters named like body fields (e.g. ?token=) in a
+// request URI. URIs without such parameters are returned unchanged.
+func (r *Redactor) URL(requestURI string) string {
+	path, rawQuery, ok := strings.Cut(requestURI, "?")
+	if !ok || len(r.fields) == 0 {
+		return requestURI
+	}
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return requestURI
+	}
+	masked := false
+	for name, values := range query {
+		if r.fields[strings.ToLower(name)] {
+			for i := range values {
+				values[i] = Redacted
+			}
+			masked = true
+		}
+	}
+	if !masked {
+		return requestURI
+	}
+	return path + "?" + query.Encode()
+}
+
+// Body masks fields of JSON and form-encoded bodies. Other content types, and
+// bodies that fail to parse (e.g. truncated JSON), are returned unchanged.
+func (r *Redactor) Body(contentType string, body []byte) []byte {
+	if len(r.fields) == 0 || len(body) == 0 {
+		return body
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		var v interface{}
+		if err := json.Unmarshal(body, &v); err != nil {
+			return body
+		}
+		out, err := json.Marshal(r.redactJSON(v))
+		if err != nil {
+			return body
+		}
+		return out
+	case mediaType == "application/x-www-form-urlencoded":
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return body
+		}
+		for name, values := range form {
+			if r.fields[strings.ToLower(name)] {
+				for i := range values {
+					values[i] = Redacted
+				}
+			}
+		}
+		return []byte(form.Encode())
+	default:
+		return body
+	}
+}
+
+func (r *Redactor) redactJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			if r.fields[strings.ToLower(k)] {
+				v[k] = Redacted
+			} else {
+				v[k] = r.redactJSON(child)
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = r.redactJSON(child)
+		}
+	}
+	return v
+}
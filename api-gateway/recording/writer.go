@@ -0,0 +1,103 @@
+// api-gateway/recording/writer.go
+// Capture 파일 기록: 크기 기준 Rotation, 오래된 파일 정리
+
+package recording
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrClosed is returned by Write after Close.
+var ErrClosed = errors.New("recording: writer closed")
+
+// Writer appends records to capture-<timestamp>.jsonl files in dir, starting
+// a new file once maxBytes is reached and keeping at most maxFiles files.
+type Writer struct {
+	mu       sync.Mutex
+	dir      string
+	maxBytes int64
+	maxFiles int
+	file     *os.File
+	size     int64
+	closed   bool
+	now      func() time.Time
+}
+
+func NewWriter(dir string, maxBytes int64, maxFiles int) (*Writer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Writer{dir: dir, maxBytes: maxBytes, maxFiles: maxFiles, now: time.Now}, nil
+}
+
+func (w *Writer) Write(rec *Record) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return ErrClosed
+	}
+	if w.file == nil || (w.maxBytes > 0 && w.size+int64(len(line)) > w.maxBytes && w.size > 0) {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := w.file.Write(line)
+	w.size += int64(n)
+	return err
+}
+
+// rotate opens a new capture file and removes the oldest ones; callers must hold w.mu.
+func (w *Writer) rotate() error {
+	if w.file != nil {
+		w.file.Close()
+	}
+	name := filepath.Join(w.dir, fmt.Sprintf("capture-%s.jsonl", w.now().UTC().Format("20060102T150405.000000000")))
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		w.file = nil
+		return err
+	}
+	w.file, w.size = f, 0
+
+	if w.maxFiles > 0 {
+		files, _ := Files(w.dir)
+		for len(files) > w.maxFiles {
+			os.Remove(files[0])
+			files = files[1:]
+		}
+	}
+	return nil
+}
+
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	if w.file == nil {
+		return nil
+	}
+	return w.file.Close()
+}
+
+// Files lists the capture files in dir, oldest first.
+func Files(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "capture-*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
@@ -0,0 +1,104 @@
+// api-gateway/recording_test.go
+// 단위 테스트: 기록 모드 (샘플링 대상, 마스킹, 원래 요청 URL 보존)
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"titanium-api-go/recording"
+)
+
+func readCaptures(t *testing.T, dir string) []*recording.Record {
+	t.Helper()
+	files, _ := recording.Files(dir)
+	var records []*recording.Record
+	for _, path := range files {
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("open capture: %v", err)
+		}
+		recording.Read(f, func(r *recording.Record) error { records = append(records, r); return nil })
+		f.Close()
+	}
+	return records
+}
+
+// TestRecording tests captured request/response pairs
+func TestRecording(t *testing.T) {
+	router := newTestRouter(t)
+	dir := t.TempDir()
+	if err := router.SetRecording(RecordingConfig{Dir: dir, Routes: []string{"user-register"}}); err != nil {
+		t.Fatalf("SetRecording error: %v", err)
+	}
+	defer router.SetRecording(RecordingConfig{})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/register?ref=a&token=t", strings.NewReader(`{"username":"a","password":"secret"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer abc")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Body.String() != "/users" {
+		t.Fatalf("upstream path = %s; recording should not change proxying", rr.Body.String())
+	}
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/users/a", nil))
+
+	records := readCaptures(t, dir)
+	if len(records) != 1 {
+		t.Fatalf("records = %d; want only the user-register route", len(records))
+	}
+	rec := records[0]
+	if rec.Route != "user-register" || rec.Request.URL != "/api/register?ref=a&token=%5BREDACTED%5D" {
+		t.Errorf("record = %s %s; want original gateway URL with the token redacted", rec.Route, rec.Request.URL)
+	}
+	if rec.Request.Header.Get("Authorization") != recording.Redacted {
+		t.Errorf("Authorization = %s; want redacted", rec.Request.Header.Get("Authorization"))
+	}
+	if strings.Contains(rec.Request.Body, "secret") || !strings.Contains(rec.Request.Body, `"username":"a"`) {
+		t.Errorf("request body = %s; want password redacted", rec.Request.Body)
+	}
+	if rec.Response.Status != http.StatusOK || rec.Response.Body != "/users" || rec.Response.Header.Get("X-Backend") != "user-service" {
+		t.Errorf("response = %+v", rec.Response)
+	}
+}
+
+// TestRecordingClosedWriter tests that a request finishing after its recorder
+// was replaced is not counted as written
+func TestRecordingClosedWriter(t *testing.T) {
+	router := newTestRouter(t)
+	if err := router.SetRecording(RecordingConfig{Dir: t.TempDir()}); err != nil {
+		t.Fatalf("SetRecording error: %v", err)
+	}
+	defer router.SetRecording(RecordingConfig{})
+	rec := router.recorder.Load()
+	rec.writer.Close()
+
+	route := router.route("users")
+	written := testutil.ToFloat64(recordedRequestsTotal.WithLabelValues(route.Name, "written"))
+	failed := testutil.ToFloat64(recordedRequestsTotal.WithLabelValues(route.Name, "error"))
+	req := httptest.NewRequest(http.MethodGet, "/api/users/a", nil)
+	rec.serve(httptest.NewRecorder(), req, route, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	if got := testutil.ToFloat64(recordedRequestsTotal.WithLabelValues(route.Name, "written")); got != written {
+		t.Errorf("recorded_requests_total{result=written} increased by %v; want 0", got-written)
+	}
+	if got := testutil.ToFloat64(recordedRequestsTotal.WithLabelValues(route.Name, "error")); got != failed {
+		t.Errorf("recorded_requests_total{result=error} increased by %v; want 0", got-failed)
+	}
+}
+
+// TestRecordingConfigValidation tests rejected recording configs
+func TestRecordingConfigValidation(t *testing.T) {
+	router := newTestRouter(t)
+	if err := router.SetRecording(RecordingConfig{Dir: t.TempDir(), Percent: 150}); err == nil {
+		t.Error("percent over 100 should be rejected")
+	}
+	if router.recorder.Load() != nil {
+		t.Error("rejected config should not be applied")
+	}
+}
@@ -0,0 +1,221 @@
+// api-gateway/recovery.go
+// Panic 복구: 500 problem+json 응답, 메트릭, Request ID와 함께 stack 로그, Sentry 호환 endpoint로 이벤트 전송
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"titanium-api-go/recording"
+)
+
+var (
+	panicsTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "panics_total",
+			Help: "Total number of panics recovered by the gateway",
+		},
+	)
+	panicReportsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "panic_reports_total",
+			Help: "Total number of panic events forwarded to the error tracker by result (sent, error, dropped)",
+		},
+		[]string{"result"},
+	)
+)
+
+// recoveryMiddleware turns a panic anywhere below it into a 500 problem+json.
+// http.ErrAbortHandler is re-raised untouched: it is how ReverseProxy and the
+// fault injector deliberately abort a response.
+func recoveryMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := wrapResponseWriter(w)
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			if p == http.ErrAbortHandler {
+				panic(p)
+			}
+
+			panicsTotal.Inc()
+			stack := debug.Stack()
+			id := requestIDFromContext(r.Context())
+			log.Printf("[PANIC] id=%s %s %s: %v\n%s", id, r.Method, r.URL.RequestURI(), p, stack)
+			globalPanicReporter.report(r, p, stack)
+
+			if rw.wroteHeader || rw.hijacked {
+				// 응답이 이미 시작되었으면 잘린 응답을 정상으로 오인하지 않도록 연결 종료
+				panic(http.ErrAbortHandler)
+			}
+			writeProblem(rw, r, &problem{Status: http.StatusInternalServerError, Detail: "Internal gateway error"})
+		}()
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// === Error Tracker ===
+
+const (
+	maxConcurrentPanicReports = 4
+	panicReportTimeout        = 5 * time.Second
+)
+
+// panicReporter sends panic events to a Sentry-compatible store endpoint
+// (POST /api/<project>/store/), configured with a DSN of the form
+// https://<key>@<host>/<project>.
+type panicReporter struct {
+	endpoint    string
+	key         string
+	environment string
+	client      *http.Client
+	redactor    *recording.Redactor
+	// slots bounds in-flight reports so a panic storm cannot pile up goroutines
+	slots chan struct{}
+}
+
+func newPanicReporter(dsn, environment string) (*panicReporter, error) {
+	u, err := url.Parse(dsn)
+	if err != nil || u.Scheme == "" || u.Host == "" || u.User == nil {
+		return nil, fmt.Errorf("invalid DSN")
+	}
+	project := strings.Trim(u.Path, "/")
+	if project == "" {
+		return nil, fmt.Errorf("DSN has no project id")
+	}
+	return &panicReporter{
+		endpoint:    fmt.Sprintf("%s://%s/api/%s/store/", u.Scheme, u.Host, project),
+		key:         u.User.Username(),
+		environment: environment,
+		client:      &http.Client{Timeout: panicReportTimeout},
+		redactor:    recording.NewRedactor(recording.DefaultRedactHeaders, nil),
+		slots:       make(chan struct{}, maxConcurrentPanicReports),
+	}, nil
+}
+
+func newPanicReporterFromEnv() *panicReporter {
+	dsn := getEnv("SENTRY_DSN", "")
+	if dsn == "" {
+		return nil
+	}
+	reporter, err := newPanicReporter(dsn, getEnv("SENTRY_ENVIRONMENT", "production"))
+	if err != nil {
+		log.Fatalf("SENTRY_DSN: %v", err)
+	}
+	return reporter
+}
+
+// globalPanicReporter is nil unless SENTRY_DSN is set.
+var globalPanicReporter = newPanicReporterFromEnv()
+
+// sentryEvent is the subset of the Sentry event payload the gateway fills in.
+type sentryEvent struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   string            `json:"timestamp"`
+	Level       string            `json:"level"`
+	Platform    string            `json:"platform"`
+	Logger      string            `json:"logger"`
+	Environment string            `json:"environment,omitempty"`
+	Message     string            `json:"message"`
+	Exception   sentryExceptions  `json:"exception"`
+	Request     sentryRequest     `json:"request"`
+	Tags        map[string]string `json:"tags"`
+	Extra       map[string]string `json:"extra"`
+}
+
+type sentryExceptions struct {
+	Values []sentryException `json:"values"`
+}
+
+type sentryException struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type sentryRequest struct {
+	URL     string            `json:"url"`
+	Method  string            `json:"method"`
+	Headers map[string]string `json:"headers"`
+}
+
+// report sends the event in the background. Nothing is sent when no DSN is
+// configured, and events are dropped rather than queued when reports are
+// already in flight.
+func (pr *panicReporter) report(r *http.Request, p interface{}, stack []byte) {
+	if pr == nil {
+		return
+	}
+	select {
+	case pr.slots <- struct{}{}:
+	default:
+		panicReportsTotal.WithLabelValues("dropped").Inc()
+		return
+	}
+
+	event := pr.newEvent(r, p, stack)
+	go func() {
+		defer func() { <-pr.slots }()
+		if err := pr.send(event); err != nil {
+			panicReportsTotal.WithLabelValues("error").Inc()
+			log.Printf("[PANIC] report %s failed: %v", event.EventID, err)
+			return
+		}
+		panicReportsTotal.WithLabelValues("sent").Inc()
+	}()
+}
+
+func (pr *panicReporter) newEvent(r *http.Request, p interface{}, stack []byte) *sentryEvent {
+	headers := map[string]string{}
+	for name, values := range pr.redactor.Header(r.Header) {
+		headers[name] = strings.Join(values, ", ")
+	}
+	value := fmt.Sprint(p)
+	return &sentryEvent{
+		EventID:     newRequestID(),
+		Timestamp:   time.Now().UTC().Format(time.RFC3339),
+		Level:       "fatal",
+		Platform:    "go",
+		Logger:      "api-gateway",
+		Environment: pr.environment,
+		Message:     "panic: " + value,
+		Exception:   sentryExceptions{Values: []sentryException{{Type: fmt.Sprintf("panic(%T)", p), Value: value}}},
+		Request:     sentryRequest{URL: r.URL.RequestURI(), Method: r.Method, Headers: headers},
+		Tags:        map[string]string{"request_id": requestIDFromContext(r.Context())},
+		Extra:       map[string]string{"stack": string(stack)},
+	}
+}
+
+func (pr *panicReporter) send(event *sentryEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, pr.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", fmt.Sprintf("Sentry sentry_version=7, sentry_client=titanium-api-gateway/1.0, sentry_key=%s", pr.key))
+	resp, err := pr.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
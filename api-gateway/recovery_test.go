@@ -0,0 +1,120 @@
+// api-gateway/recovery_test.go
+// 단위 테스트: Panic 복구 응답, ErrAbortHandler 전파, Sentry 호환 이벤트 전송
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// TestRecoveryMiddleware tests the 500 response and panics that must propagate
+func TestRecoveryMiddleware(t *testing.T) {
+	t.Run("panic은 500 problem+json", func(t *testing.T) {
+		before := testutil.ToFloat64(panicsTotal)
+		handler := requestIDMiddleware(recoveryMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var m map[string]int
+			m["boom"]++
+		})))
+		req := httptest.NewRequest(http.MethodGet, "/api/users/alice", nil)
+		req.Header.Set(requestIDHeader, "req-panic")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		p := decodeProblem(t, rr)
+		if rr.Code != http.StatusInternalServerError || p.Type != "/problems/internal-server-error" || p.RequestID != "req-panic" {
+			t.Errorf("problem = %+v", p)
+		}
+		if got := testutil.ToFloat64(panicsTotal) - before; got != 1 {
+			t.Errorf("panics_total increased by %v; want 1", got)
+		}
+	})
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{"ErrAbortHandler는 그대로 전파", func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		}},
+		{"응답 시작 후 panic은 연결 종료", func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "partial")
+			panic("boom")
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if p := recover(); p != http.ErrAbortHandler {
+					t.Errorf("recovered %v; want http.ErrAbortHandler", p)
+				}
+			}()
+			recoveryMiddleware(tt.handler).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		})
+	}
+}
+
+// TestPanicReporter tests events sent to a local Sentry stand-in
+func TestPanicReporter(t *testing.T) {
+	type received struct {
+		path  string
+		auth  string
+		event sentryEvent
+	}
+	events := make(chan received, 1)
+	sentry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var rcv received
+		rcv.path, rcv.auth = r.URL.Path, r.Header.Get("X-Sentry-Auth")
+		json.NewDecoder(r.Body).Decode(&rcv.event)
+		events <- rcv
+	}))
+	defer sentry.Close()
+
+	reporter, err := newPanicReporter(strings.Replace(sentry.URL, "http://", "http://public-key@", 1)+"/42", "test")
+	if err != nil {
+		t.Fatalf("newPanicReporter error: %v", err)
+	}
+	original := globalPanicReporter
+	globalPanicReporter = reporter
+	defer func() { globalPanicReporter = original }()
+
+	handler := requestIDMiddleware(recoveryMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("nil upstream")
+	})))
+	req := httptest.NewRequest(http.MethodPost, "/api/login", nil)
+	req.Header.Set(requestIDHeader, "req-42")
+	req.Header.Set("Authorization", "Bearer secret")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	select {
+	case rcv := <-events:
+		if rcv.path != "/api/42/store/" || !strings.Contains(rcv.auth, "sentry_key=public-key") {
+			t.Errorf("request = %s (%s)", rcv.path, rcv.auth)
+		}
+		e := rcv.event
+		if e.Message != "panic: nil upstream" || e.Tags["request_id"] != "req-42" || e.Environment != "test" || len(e.EventID) != 32 {
+			t.Errorf("event = %+v", e)
+		}
+		if e.Request.Headers["Authorization"] != "[REDACTED]" || !strings.Contains(e.Extra["stack"], "recovery_test.go") {
+			t.Errorf("event request/stack = %+v", e.Request)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event reported")
+	}
+}
+
+// TestPanicReporterDSN tests rejected DSNs
+func TestPanicReporterDSN(t *testing.T) {
+	for _, dsn := range []string{"not a url", "https://sentry.example.com/1", "https://key@sentry.example.com"} {
+		if _, err := newPanicReporter(dsn, ""); err == nil {
+			t.Errorf("newPanicReporter(%q) should fail", dsn)
+		}
+	}
+}
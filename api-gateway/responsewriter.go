@@ -0,0 +1,170 @@
+// api-gateway/responsewriter.go
+// 응답 관찰용 ResponseWriter 래퍼: status, 전송 바이트, TTFB, panic 여부 기록 및 Access Log
+
+package main
+
+import (
+	"bufio"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"time"
+)
+
+// responseWriter observes a response for metrics and access logs. It is the
+// only wrapper the middlewares use, so optional interfaces must survive it:
+// Flush, Hijack and Push are always present and forward to the underlying
+// writer (through http.ResponseController unwrapping), returning
+// http.ErrNotSupported when it lacks them, and ReadFrom keeps sendfile.
+type responseWriter struct {
+	http.ResponseWriter
+	start time.Time
+
+	status      int
+	bytes       int64
+	firstByte   time.Duration
+	wroteHeader bool
+	hijacked    bool
+	// panicked is set by observe when the handler panicked
+	panicked bool
+}
+
+// wrapResponseWriter returns w itself if an outer middleware already wraps
+// the response, so that every layer sees the same counters.
+func wrapResponseWriter(w http.ResponseWriter) *responseWriter {
+	if rw, ok := w.(*responseWriter); ok {
+		return rw
+	}
+	return &responseWriter{ResponseWriter: w, start: time.Now(), status: http.StatusOK}
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	// 1xx (101 제외)는 최종 응답이 아니므로 기록하지 않음
+	if status >= 100 && status < 200 && status != http.StatusSwitchingProtocols {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.status = status
+		w.firstByte = time.Since(w.start)
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// ReadFrom lets io.Copy use the connection's sendfile path (static files).
+func (w *responseWriter) ReadFrom(src io.Reader) (int64, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	var n int64
+	var err error
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(src)
+	} else {
+		n, err = io.Copy(writerOnly{w.ResponseWriter}, src)
+	}
+	w.bytes += n
+	return n, err
+}
+
+// writerOnly hides ReadFrom so io.Copy does not recurse.
+type writerOnly struct {
+	io.Writer
+}
+
+func (w *responseWriter) Flush() {
+	w.FlushError()
+}
+
+func (w *responseWriter) FlushError() error {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil {
+		w.hijacked = true
+		if !w.wroteHeader {
+			// Upgrade 응답은 Hijack한 연결에 직접 쓰므로 101로 기록
+			w.wroteHeader = true
+			w.status = http.StatusSwitchingProtocols
+			w.firstByte = time.Since(w.start)
+		}
+	}
+	return conn, brw, err
+}
+
+func (w *responseWriter) Push(target string, opts *http.PushOptions) error {
+	if p, ok := w.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// observe runs next with w wrapped and calls done once the response is
+// finished, including when next panics. The panic is re-raised afterwards so
+// that it still reaches the server (or a recovery middleware).
+func observe(w http.ResponseWriter, r *http.Request, next http.Handler, done func(*responseWriter)) {
+	rw := wrapResponseWriter(w)
+	defer func() {
+		if p := recover(); p != nil {
+			rw.panicked = true
+			if !rw.wroteHeader {
+				rw.status = http.StatusInternalServerError
+			}
+			done(rw)
+			panic(p)
+		}
+		done(rw)
+	}()
+	next.ServeHTTP(rw, r)
+}
+
+// === Access Log ===
+var accessLogEnabled = getEnv("ACCESS_LOG", "true") == "true"
+
+// accessLogMiddleware logs one line per request, after the response is done.
+// It sits right after requestIDMiddleware so that it sees gateway rejections
+// (rate limit, body size, API key) too.
+func accessLogMiddleware(next http.Handler) http.Handler {
+	if !accessLogEnabled {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uri := r.URL.RequestURI()
+		observe(w, r, next, func(rw *responseWriter) {
+			log.Printf("[ACCESS] %s %s %s %d %dB ttfb=%s dur=%s id=%s%s",
+				getClientIP(r), r.Method, uri, rw.status, rw.bytes,
+				rw.firstByte.Round(time.Microsecond), time.Since(rw.start).Round(time.Microsecond),
+				requestIDFromContext(r.Context()), accessLogFlags(rw))
+		})
+	})
+}
+
+func accessLogFlags(rw *responseWriter) string {
+	switch {
+	case rw.panicked:
+		return " panic"
+	case rw.hijacked:
+		return " hijacked"
+	}
+	return ""
+}
@@ -0,0 +1,199 @@
+// api-gateway/responsewriter_test.go
+// 단위 테스트: ResponseWriter 래퍼의 선택 인터페이스 보존, status/bytes/TTFB/panic 기록
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Underlying writers with each combination of optional interfaces.
+// calls records which optional method reached the underlying writer.
+type baseWriter struct {
+	header http.Header
+	status int
+	body   strings.Builder
+	calls  []string
+}
+
+func (w *baseWriter) Header() http.Header {
+	if w.header == nil {
+		w.header = http.Header{}
+	}
+	return w.header
+}
+func (w *baseWriter) WriteHeader(status int)      { w.status = status }
+func (w *baseWriter) Write(b []byte) (int, error) { return w.body.Write(b) }
+
+type flushWriter struct{ *baseWriter }
+
+func (w flushWriter) Flush() { w.calls = append(w.calls, "flush") }
+
+type hijackWriter struct{ *baseWriter }
+
+func (w hijackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.calls = append(w.calls, "hijack")
+	client, server := net.Pipe()
+	client.Close()
+	return server, nil, nil
+}
+
+type flushHijackWriter struct{ *baseWriter }
+
+func (w flushHijackWriter) Flush() { flushWriter(w).Flush() }
+func (w flushHijackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return hijackWriter(w).Hijack()
+}
+
+type readerFromWriter struct{ *baseWriter }
+
+func (w readerFromWriter) ReadFrom(src io.Reader) (int64, error) {
+	w.calls = append(w.calls, "readfrom")
+	return io.Copy(&w.body, src)
+}
+
+type pushWriter struct{ *baseWriter }
+
+func (w pushWriter) Push(target string, _ *http.PushOptions) error {
+	w.calls = append(w.calls, "push")
+	return nil
+}
+
+// unwrapWriter hides everything but Unwrap, like the other wrappers in the repo
+type unwrapWriter struct{ http.ResponseWriter }
+
+func (w unwrapWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// TestResponseWriterInterfaces tests that optional interfaces reach the
+// underlying writer exactly when it supports them
+func TestResponseWriterInterfaces(t *testing.T) {
+	tests := []struct {
+		name   string
+		wrap   func(*baseWriter) http.ResponseWriter
+		expect []string
+	}{
+		{"기본", func(b *baseWriter) http.ResponseWriter { return b }, nil},
+		{"Flusher", func(b *baseWriter) http.ResponseWriter { return flushWriter{b} }, []string{"flush"}},
+		{"Hijacker", func(b *baseWriter) http.ResponseWriter { return hijackWriter{b} }, []string{"hijack"}},
+		{"Flusher+Hijacker", func(b *baseWriter) http.ResponseWriter { return flushHijackWriter{b} }, []string{"flush", "hijack"}},
+		{"ReaderFrom", func(b *baseWriter) http.ResponseWriter { return readerFromWriter{b} }, []string{"readfrom"}},
+		{"Pusher", func(b *baseWriter) http.ResponseWriter { return pushWriter{b} }, []string{"push"}},
+		{"Unwrap 너머의 Flusher+Hijacker", func(b *baseWriter) http.ResponseWriter { return unwrapWriter{flushHijackWriter{b}} }, []string{"flush", "hijack"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := &baseWriter{}
+			rw := wrapResponseWriter(tt.wrap(base))
+			supported := map[string]bool{}
+			for _, c := range tt.expect {
+				supported[c] = true
+			}
+			check := func(name string, err error) {
+				t.Helper()
+				if supported[name] && err != nil {
+					t.Errorf("%s error = %v; want forwarded", name, err)
+				}
+				if !supported[name] && !errors.Is(err, http.ErrNotSupported) {
+					t.Errorf("%s error = %v; want ErrNotSupported", name, err)
+				}
+			}
+
+			// 래퍼는 항상 선택 인터페이스를 구현
+			var w http.ResponseWriter = rw
+			if _, ok := w.(http.Flusher); !ok {
+				t.Fatal("wrapper does not implement http.Flusher")
+			}
+			if _, ok := w.(http.Hijacker); !ok {
+				t.Fatal("wrapper does not implement http.Hijacker")
+			}
+			if _, ok := w.(io.ReaderFrom); !ok {
+				t.Fatal("wrapper does not implement io.ReaderFrom")
+			}
+			if _, ok := w.(http.Pusher); !ok {
+				t.Fatal("wrapper does not implement http.Pusher")
+			}
+
+			check("push", rw.Push("/blog/static/app.css", nil))
+			n, err := rw.ReadFrom(strings.NewReader("hello"))
+			if err != nil || n != 5 || base.body.String() != "hello" {
+				t.Errorf("ReadFrom = %d, %v, body %q", n, err, base.body.String())
+			}
+			check("flush", http.NewResponseController(rw).Flush())
+			_, _, err = rw.Hijack()
+			check("hijack", err)
+
+			var calls []string
+			for _, c := range base.calls {
+				if supported[c] {
+					calls = append(calls, c)
+				}
+			}
+			if strings.Join(calls, ",") != strings.Join(base.calls, ",") || len(base.calls) != len(tt.expect) {
+				t.Errorf("underlying calls = %v; want %v", base.calls, tt.expect)
+			}
+			if rw.bytes != 5 || rw.status != http.StatusOK || base.status != http.StatusOK {
+				t.Errorf("bytes = %d, status = %d/%d; want 5, 200", rw.bytes, rw.status, base.status)
+			}
+		})
+	}
+}
+
+// TestResponseWriterRecording tests status, bytes, TTFB, hijack and panic capture
+func TestResponseWriterRecording(t *testing.T) {
+	t.Run("status, bytes, TTFB", func(t *testing.T) {
+		var got *responseWriter
+		observe(&baseWriter{}, httptest.NewRequest(http.MethodGet, "/", nil), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(10 * time.Millisecond)
+			w.WriteHeader(http.StatusEarlyHints)
+			w.WriteHeader(http.StatusCreated)
+			io.WriteString(w, "abc")
+			io.WriteString(w, "de")
+			time.Sleep(10 * time.Millisecond)
+		}), func(rw *responseWriter) { got = rw })
+
+		if got.status != http.StatusCreated || got.bytes != 5 {
+			t.Errorf("status = %d, bytes = %d; want 201, 5", got.status, got.bytes)
+		}
+		if got.firstByte < 10*time.Millisecond || got.firstByte >= time.Since(got.start) {
+			t.Errorf("ttfb = %s; want between handler start and end", got.firstByte)
+		}
+	})
+
+	t.Run("바깥 래퍼 재사용", func(t *testing.T) {
+		outer := wrapResponseWriter(httptest.NewRecorder())
+		if wrapResponseWriter(outer) != outer {
+			t.Error("wrapResponseWriter should reuse an existing wrapper")
+		}
+	})
+
+	t.Run("Hijack은 101로 기록", func(t *testing.T) {
+		rw := wrapResponseWriter(hijackWriter{&baseWriter{}})
+		rw.Hijack()
+		if !rw.hijacked || rw.status != http.StatusSwitchingProtocols {
+			t.Errorf("hijacked = %v, status = %d; want true, 101", rw.hijacked, rw.status)
+		}
+	})
+
+	t.Run("panic 기록 후 다시 panic", func(t *testing.T) {
+		var got *responseWriter
+		defer func() {
+			if p := recover(); p != "boom" {
+				t.Errorf("recovered %v; want re-panic with boom", p)
+			}
+			if got == nil || !got.panicked || got.status != http.StatusInternalServerError {
+				t.Errorf("done called with %+v; want panicked 500", got)
+			}
+		}()
+		observe(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		}), func(rw *responseWriter) { got = rw })
+	})
+}
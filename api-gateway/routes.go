@@ -0,0 +1,221 @@
+// api-gateway/routes.go
+// 라우팅 테이블: 요청 경로 -> upstream 매핑 및 경로 재작성
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+const routeContextKey contextKey = "route"
+
+// Route maps a family of gateway paths to an upstream. Match and Rewrite are
+// human-readable descriptions shown by the admin API.
+type Route struct {
+	Name     string `json:"name"`
+	Match    string `json:"match"`
+	Upstream string `json:"upstream"`
+	Rewrite  string `json:"rewrite"`
+	// MaxBodyBytes is the default request body limit (MaxRequestBodySize if 0)
+	MaxBodyBytes int64 `json:"max_body_bytes,omitempty"`
+
+	// match receives the original path and the path with /api or /blog/api trimmed
+	match   func(path, trimmed string) bool
+	rewrite func(path, trimmed string) string
+	// activeStreams counts open WebSocket/SSE connections, kept across config reloads
+	activeStreams atomic.Int64
+}
+
+// onAPIPath restricts a matcher to /api/ and /blog/api/ requests.
+func onAPIPath(match func(trimmed string) bool) func(path, trimmed string) bool {
+	return func(path, trimmed string) bool {
+		return isAPIPath(path) && match(trimmed)
+	}
+}
+
+// defaultRoutes is the gateway routing table, evaluated in order.
+func defaultRoutes() []*Route {
+	return []*Route{
+		{
+			Name: "auth-login", Match: "/api/**/login", Upstream: "auth-service", Rewrite: "/login", MaxBodyBytes: 64 << 10,
+			match:   onAPIPath(func(trimmed string) bool { return strings.HasSuffix(trimmed, "/login") }),
+			rewrite: func(_, _ string) string { return "/login" },
+		},
+		{
+			// Register는 user-service의 /users 엔드포인트를 사용
+			Name: "user-register", Match: "/api/**/register", Upstream: "user-service", Rewrite: "/users", MaxBodyBytes: 64 << 10,
+			match:   onAPIPath(func(trimmed string) bool { return strings.HasSuffix(trimmed, "/register") }),
+			rewrite: func(_, _ string) string { return "/users" },
+		},
+		{
+			Name: "users", Match: "/api/users*", Upstream: "user-service", Rewrite: "strip /api", MaxBodyBytes: 1 << 20,
+			match:   onAPIPath(func(trimmed string) bool { return strings.HasPrefix(trimmed, "/users") }),
+			rewrite: func(_, trimmed string) string { return trimmed },
+		},
+		{
+			// blog service의 전체 경로 사용
+			Name: "blog-api", Match: "/blog/api/{posts,categories}*", Upstream: "blog-service", Rewrite: "none",
+			match: onAPIPath(func(trimmed string) bool {
+				return strings.HasPrefix(trimmed, "/posts") || strings.HasPrefix(trimmed, "/categories")
+			}),
+			rewrite: func(path, _ string) string { return path },
+		},
+		{
+			Name: "blog-pages", Match: "/blog/*", Upstream: "blog-service", Rewrite: "none",
+			match: func(path, _ string) bool {
+				return strings.HasPrefix(path, "/blog/") && !strings.HasPrefix(path, "/blog/api/")
+			},
+			rewrite: func(path, _ string) string { return path },
+		},
+	}
+}
+
+// trimAPIPrefix removes /api/ (or /blog/api/) to extract the service path.
+func trimAPIPrefix(path string) string {
+	if strings.HasPrefix(path, "/blog/api/") {
+		return strings.TrimPrefix(path, "/blog/api")
+	}
+	if strings.HasPrefix(path, "/api/") {
+		return strings.TrimPrefix(path, "/api")
+	}
+	return path
+}
+
+// Router dispatches requests to upstreams according to the route table.
+type Router struct {
+	routes    []*Route
+	upstreams map[string]*Upstream
+	// blogStatic serves /blog/static/ locally when BLOG_STATIC_DIR is mounted
+	blogStatic http.Handler
+	// shadows maps route names to their mirroring target
+	shadows   atomic.Pointer[map[string]*shadowTarget]
+	faults    atomic.Pointer[[]FaultRule]
+	recorder  atomic.Pointer[recorder]
+	validator atomic.Pointer[requestValidator]
+	// bodyLimits overrides route body limits by route name
+	bodyLimits atomic.Pointer[map[string]BodyLimitConfig]
+	streams    atomic.Pointer[map[string]StreamConfig]
+	versions   atomic.Pointer[map[string]RouteVersionsConfig]
+	composites atomic.Pointer[compositeSet]
+	graphql    atomic.Pointer[GraphQLConfig]
+	grpc       atomic.Pointer[map[string]GRPCServiceConfig]
+}
+
+func NewRouter(routes []*Route, upstreams map[string]*Upstream, blogStatic http.Handler) *Router {
+	return &Router{
+		routes:     routes,
+		upstreams:  upstreams,
+		blogStatic: blogStatic,
+	}
+}
+
+// Resolve returns the first route matching the request path, or nil. A
+// version segment (/api/v2/...) does not take part in matching.
+func (rt *Router) Resolve(path string) *Route {
+	path, _ = splitPathVersion(path)
+	trimmed := trimAPIPrefix(path)
+	for _, route := range rt.routes {
+		if route.match(path, trimmed) {
+			return route
+		}
+	}
+	return nil
+}
+
+func (rt *Router) Routes() []*Route {
+	return rt.routes
+}
+
+// route returns the route with the given name, or nil.
+func (rt *Router) route(name string) *Route {
+	for _, route := range rt.routes {
+		if route.Name == name {
+			return route
+		}
+	}
+	return nil
+}
+
+func (rt *Router) Upstream(name string) *Upstream {
+	return rt.upstreams[name]
+}
+
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if rt.serveComposite(w, r) {
+		return
+	}
+	route := rt.Resolve(r.URL.Path)
+	if route == nil {
+		notFound(w, r)
+		return
+	}
+	if rt.upstreams[route.Upstream] == nil {
+		notFound(w, r)
+		return
+	}
+
+	if m := currentMaintenance.Load(); m.appliesTo(route) && !m.bypassed(r) {
+		m.serve(w, r, route)
+		return
+	}
+
+	version, ok := rt.selectVersion(w, r, route)
+	if !ok {
+		return
+	}
+	ctx := context.WithValue(r.Context(), routeContextKey, route)
+	if version != nil {
+		ctx = context.WithValue(ctx, apiVersionContextKey, version)
+	}
+	r = r.WithContext(ctx)
+	if kind := streamKind(r); kind != "" {
+		rt.serveStream(w, r, route, kind)
+		return
+	}
+	if rec := rt.recorder.Load(); rec != nil && rec.sampled(route) {
+		rec.serve(w, r, route, http.HandlerFunc(rt.proxy))
+		return
+	}
+	rt.proxy(w, r)
+}
+
+// proxy sends a request already resolved by ServeHTTP to its upstream (the
+// version's, if it names one), applying path rewriting, request validation,
+// fault injection and shadowing (except for streams).
+func (rt *Router) proxy(w http.ResponseWriter, r *http.Request) {
+	route := routeFromContext(r.Context())
+	upstream := rt.upstreams[upstreamName(r.Context(), route)]
+	var handler http.Handler = upstream
+	if route.Name == "blog-pages" {
+		handler = blogPageHandler(upstream, rt.blogStatic)
+	} else {
+		r.URL.Path = route.rewrite(r.URL.Path, trimAPIPrefix(r.URL.Path))
+		if v := apiVersionFromContext(r.Context()); v != nil {
+			r.URL.Path = v.PathPrefix + r.URL.Path
+		}
+	}
+	if v := rt.validator.Load(); v != nil && !rt.bodyLimit(route).Streaming && !v.check(w, r, route) {
+		return
+	}
+	if f := rt.faultRule(r, route); f != nil {
+		next := handler
+		handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			f.inject(w, r, route, next)
+		})
+	}
+
+	if s := rt.shadow(route.Name); s != nil && streamKind(r) == "" {
+		s.serve(w, r, handler)
+		return
+	}
+	handler.ServeHTTP(w, r)
+}
+
+// routeFromContext returns the route resolved for the request, or nil.
+func routeFromContext(ctx context.Context) *Route {
+	route, _ := ctx.Value(routeContextKey).(*Route)
+	return route
+}
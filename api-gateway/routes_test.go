@@ -0,0 +1,102 @@
+// api-gateway/routes_test.go
+// 단위 테스트: 라우팅 테이블, 경로 재작성
+
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// newTestBackend starts an upstream that echoes its name and the received path
+func newTestBackend(t *testing.T, name string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Backend", name)
+		io.WriteString(w, r.URL.Path)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestUpstream(t *testing.T, name string, srv *httptest.Server) *Upstream {
+	t.Helper()
+	target, _ := url.Parse(srv.URL)
+	return NewUpstream(name, target, newCircuitBreaker(name, 3, time.Minute), nil)
+}
+
+// newTestRouter wires the default routes to three echo backends
+func newTestRouter(t *testing.T) *Router {
+	t.Helper()
+	upstreams := map[string]*Upstream{}
+	for _, name := range []string{"user-service", "auth-service", "blog-service"} {
+		upstreams[name] = newTestUpstream(t, name, newTestBackend(t, name))
+	}
+	return NewRouter(defaultRoutes(), upstreams, nil)
+}
+
+// TestRouter tests that every documented route reaches the right upstream path
+func TestRouter(t *testing.T) {
+	router := newTestRouter(t)
+
+	tests := []struct {
+		name          string
+		path          string
+		expectedRoute string
+		backend       string
+		upstreamPath  string
+	}{
+		{"로그인", "/api/login", "auth-login", "auth-service", "/login"},
+		{"회원가입 경로 재작성", "/api/register", "user-register", "user-service", "/users"},
+		{"사용자 조회", "/api/users/alice", "users", "user-service", "/users/alice"},
+		{"Blog API 전체 경로 유지", "/blog/api/posts/1", "blog-api", "blog-service", "/blog/api/posts/1"},
+		{"카테고리", "/blog/api/categories", "blog-api", "blog-service", "/blog/api/categories"},
+		{"Blog HTML 페이지", "/blog/", "blog-pages", "blog-service", "/blog/"},
+		{"SPA 경로는 API 라우트와 충돌하지 않음", "/blog/login", "blog-pages", "blog-service", "/blog/login"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route := router.Resolve(tt.path)
+			if route == nil || route.Name != tt.expectedRoute {
+				t.Fatalf("Resolve(%s) = %v; want %s", tt.path, route, tt.expectedRoute)
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if got := rr.Header().Get("X-Backend"); got != tt.backend {
+				t.Errorf("backend = %s; want %s", got, tt.backend)
+			}
+			if got := rr.Body.String(); got != tt.upstreamPath {
+				t.Errorf("upstream path = %s; want %s", got, tt.upstreamPath)
+			}
+		})
+	}
+
+	t.Run("매칭되지 않는 경로는 404", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/unknown", nil))
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("Status = %d; want 404", rr.Code)
+		}
+	})
+}
+
+// TestTrimAPIPrefix tests service path extraction
+func TestTrimAPIPrefix(t *testing.T) {
+	tests := map[string]string{
+		"/api/users/a":    "/users/a",
+		"/blog/api/posts": "/posts",
+		"/blog/":          "/blog/",
+		"/health":         "/health",
+	}
+	for in, want := range tests {
+		if got := trimAPIPrefix(in); got != want {
+			t.Errorf("trimAPIPrefix(%s) = %s; want %s", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,306 @@
+// api-gateway/shadow.go
+// Shadow 트래픽: Route 단위로 요청 사본을 후보 Upstream에 비동기 전송하고 응답을 비교
+
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	shadowRequestsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "shadow_requests_total",
+			Help: "Total number of mirrored requests by result (sent, error, dropped, body_too_large, unsafe_method)",
+		},
+		[]string{"route", "result"},
+	)
+	shadowMismatchesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "shadow_mismatches_total",
+			Help: "Total number of shadow responses that differed from the primary response",
+		},
+		[]string{"route", "kind"},
+	)
+)
+
+const (
+	shadowRequestHeader        = "X-Shadow-Request"
+	defaultShadowMaxBodyBytes  = 1 << 20
+	defaultShadowTimeout       = 5 * time.Second
+	defaultShadowLogEvery      = 10
+	maxConcurrentShadowRequest = 64
+)
+
+// shadowSlots bounds in-flight shadow requests; when full, mirroring is skipped
+// rather than queued so a slow candidate never builds up memory or goroutines.
+var shadowSlots = make(chan struct{}, maxConcurrentShadowRequest)
+
+// ShadowConfig mirrors one route to a candidate upstream
+// ("shadow" section of the config file, keyed by route name).
+type ShadowConfig struct {
+	URL string `json:"url"`
+	// MaxBodyBytes caps the request body copied to the shadow (and the
+	// response bodies kept for comparison); larger requests are not mirrored
+	MaxBodyBytes int64        `json:"max_body_bytes,omitempty"`
+	Timeout      jsonDuration `json:"timeout,omitempty"`
+	// Compare checks status code and body against the primary response
+	Compare bool `json:"compare,omitempty"`
+	// LogEvery logs one of every N mismatches
+	LogEvery int `json:"log_every,omitempty"`
+	// MirrorUnsafe also mirrors non-idempotent methods (POST, PATCH); only for
+	// candidates that do not share state with the primary
+	MirrorUnsafe bool `json:"mirror_unsafe,omitempty"`
+}
+
+type shadowTarget struct {
+	route      string
+	config     ShadowConfig
+	target     *url.URL
+	client     *http.Client
+	mismatches atomic.Uint64
+}
+
+func newShadowTarget(route string, cfg ShadowConfig) (*shadowTarget, error) {
+	target, err := url.Parse(cfg.URL)
+	if err != nil || target.Scheme == "" || target.Host == "" {
+		return nil, fmt.Errorf("shadow %s: invalid url %q", route, cfg.URL)
+	}
+	if cfg.MaxBodyBytes < 0 || cfg.Timeout < 0 || cfg.LogEvery < 0 {
+		return nil, fmt.Errorf("shadow %s: limits must not be negative", route)
+	}
+	if cfg.MaxBodyBytes == 0 {
+		cfg.MaxBodyBytes = defaultShadowMaxBodyBytes
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = jsonDuration(defaultShadowTimeout)
+	}
+	if cfg.LogEvery == 0 {
+		cfg.LogEvery = defaultShadowLogEvery
+	}
+	return &shadowTarget{
+		route:  route,
+		config: cfg,
+		target: target,
+		client: &http.Client{Timeout: time.Duration(cfg.Timeout)},
+	}, nil
+}
+
+// SetShadows replaces the mirrored routes; unknown route names are rejected.
+func (rt *Router) SetShadows(configs map[string]ShadowConfig) error {
+	shadows, err := rt.newShadows(configs)
+	if err != nil {
+		return err
+	}
+	rt.shadows.Store(&shadows)
+	return nil
+}
+
+func (rt *Router) newShadows(configs map[string]ShadowConfig) (map[string]*shadowTarget, error) {
+	shadows := map[string]*shadowTarget{}
+	for name, cfg := range configs {
+		if rt.route(name) == nil {
+			return nil, fmt.Errorf("shadow: unknown route %q", name)
+		}
+		s, err := newShadowTarget(name, cfg)
+		if err != nil {
+			return nil, err
+		}
+		shadows[name] = s
+	}
+	return shadows, nil
+}
+
+func (rt *Router) shadow(route string) *shadowTarget {
+	if shadows := rt.shadows.Load(); shadows != nil {
+		return (*shadows)[route]
+	}
+	return nil
+}
+
+// serve runs primary and mirrors r to the shadow target. The shadow request is
+// sent from a separate goroutine; the primary response never waits for it.
+func (s *shadowTarget) serve(w http.ResponseWriter, r *http.Request, primary http.Handler) {
+	if !s.config.MirrorUnsafe && !idempotentMethod(r.Method) {
+		shadowRequestsTotal.WithLabelValues(s.route, "unsafe_method").Inc()
+		primary.ServeHTTP(w, r)
+		return
+	}
+	body, ok := peekBody(r, s.config.MaxBodyBytes)
+	if !ok {
+		shadowRequestsTotal.WithLabelValues(s.route, "body_too_large").Inc()
+		primary.ServeHTTP(w, r)
+		return
+	}
+	req, err := s.newRequest(r, body)
+	if err != nil {
+		shadowRequestsTotal.WithLabelValues(s.route, "error").Inc()
+		primary.ServeHTTP(w, r)
+		return
+	}
+
+	if !s.config.Compare {
+		s.dispatch(req, nil)
+		primary.ServeHTTP(w, r)
+		return
+	}
+	capture := newCaptureWriter(w, s.config.MaxBodyBytes)
+	primary.ServeHTTP(capture, r)
+	s.dispatch(req, &shadowResponse{
+		status:    capture.status,
+		header:    w.Header().Clone(),
+		body:      capture.body.Bytes(),
+		truncated: capture.truncated,
+	})
+}
+
+// shadowResponse is one side of a comparison; truncated bodies are not
+// compared.
+type shadowResponse struct {
+	status    int
+	header    http.Header
+	body      []byte
+	truncated bool
+}
+
+// newRequest copies r for the shadow target, with the same header handling as
+// the primary's Rewrite. It is detached from the client's cancellation so that
+// the shadow keeps running after the primary response is sent.
+func (s *shadowTarget) newRequest(r *http.Request, body []byte) (*http.Request, error) {
+	u := *r.URL
+	u.Scheme = s.target.Scheme
+	u.Host = s.target.Host
+	req, err := http.NewRequestWithContext(context.WithoutCancel(r.Context()), r.Method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header = r.Header.Clone()
+	stripUntrustedHeaders(req.Header)
+	currentHeaderRules.Load().applyRequest(req.Header, r, routeFromContext(r.Context()))
+	req.Header.Set(shadowRequestHeader, "true")
+	return req, nil
+}
+
+// idempotentMethod reports whether repeating a request has no further effect
+// (RFC 9110 9.2.2), which makes it safe to send to a shadow.
+func idempotentMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func (s *shadowTarget) dispatch(req *http.Request, primary *shadowResponse) {
+	select {
+	case shadowSlots <- struct{}{}:
+	default:
+		shadowRequestsTotal.WithLabelValues(s.route, "dropped").Inc()
+		return
+	}
+	go func() {
+		defer func() { <-shadowSlots }()
+		resp, err := s.client.Do(req)
+		if err != nil {
+			shadowRequestsTotal.WithLabelValues(s.route, "error").Inc()
+			return
+		}
+		defer resp.Body.Close()
+		shadowRequestsTotal.WithLabelValues(s.route, "sent").Inc()
+		if primary == nil {
+			io.Copy(io.Discard, resp.Body)
+			return
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, s.config.MaxBodyBytes+1))
+		s.compare(req, primary, &shadowResponse{
+			status:    resp.StatusCode,
+			header:    resp.Header,
+			body:      body,
+			truncated: int64(len(body)) > s.config.MaxBodyBytes,
+		})
+	}()
+}
+
+// compare records status and body mismatches. Bodies are only compared when
+// neither side exceeded MaxBodyBytes and both encodings can be decoded (see
+// sameBody).
+func (s *shadowTarget) compare(req *http.Request, primary, shadow *shadowResponse) {
+	var kinds []string
+	if shadow.status != primary.status {
+		kinds = append(kinds, "status")
+	}
+	if same, ok := sameBody(primary, shadow, s.config.MaxBodyBytes); ok && !same {
+		kinds = append(kinds, "body")
+	}
+	if len(kinds) == 0 {
+		return
+	}
+
+	for _, kind := range kinds {
+		shadowMismatchesTotal.WithLabelValues(s.route, kind).Inc()
+	}
+	if n := s.mismatches.Add(1); (n-1)%uint64(s.config.LogEvery) == 0 {
+		log.Printf("[SHADOW] %s %s %s: mismatch %v (primary %d/%dB, shadow %d/%dB)",
+			s.route, req.Method, req.URL.Path, kinds, primary.status, len(primary.body), shadow.status, len(shadow.body))
+	}
+}
+
+// sameBody compares the bodies after undoing Content-Encoding (gzip), and as
+// parsed values when both are JSON, so key order and whitespace do not count.
+// ok is false when the bodies cannot be compared: truncated, or compressed
+// with another encoding.
+func sameBody(a, b *shadowResponse, limit int64) (same, ok bool) {
+	if a.truncated || b.truncated {
+		return false, false
+	}
+	bodyA, okA := decodedBody(a, limit)
+	bodyB, okB := decodedBody(b, limit)
+	if !okA || !okB {
+		return false, false
+	}
+	if jsonBody(a.header) && jsonBody(b.header) {
+		var va, vb any
+		if json.Unmarshal(bodyA, &va) == nil && json.Unmarshal(bodyB, &vb) == nil {
+			return reflect.DeepEqual(va, vb), true
+		}
+	}
+	return bytes.Equal(bodyA, bodyB), true
+}
+
+// decodedBody returns res.body without its Content-Encoding, up to limit
+// bytes once decompressed.
+func decodedBody(res *shadowResponse, limit int64) ([]byte, bool) {
+	switch strings.ToLower(res.header.Get("Content-Encoding")) {
+	case "", "identity":
+		return res.body, true
+	case "gzip":
+		zr, err := gzip.NewReader(bytes.NewReader(res.body))
+		if err != nil {
+			return nil, false
+		}
+		body, err := io.ReadAll(io.LimitReader(zr, limit+1))
+		return body, err == nil && int64(len(body)) <= limit
+	}
+	return nil, false
+}
+
+func jsonBody(h http.Header) bool {
+	mediaType, _, _ := mime.ParseMediaType(h.Get("Content-Type"))
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
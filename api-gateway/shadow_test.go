@@ -0,0 +1,227 @@
+// api-gateway/shadow_test.go
+// 단위 테스트: Shadow 트래픽 미러링 및 응답 비교
+
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type shadowHit struct {
+	method, path, body, header, userID string
+}
+
+// newShadowBackend records mirrored requests and answers with status/body
+func newShadowBackend(t *testing.T, status int, body string, delay time.Duration) (*httptest.Server, chan shadowHit) {
+	t.Helper()
+	hits := make(chan shadowHit, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		time.Sleep(delay)
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+		hits <- shadowHit{r.Method, r.URL.Path, string(b), r.Header.Get(shadowRequestHeader), r.Header.Get("X-User-Id")}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, hits
+}
+
+func waitShadow(t *testing.T, hits chan shadowHit) shadowHit {
+	t.Helper()
+	select {
+	case hit := <-hits:
+		return hit
+	case <-time.After(2 * time.Second):
+		t.Fatal("shadow request not received")
+		return shadowHit{}
+	}
+}
+
+// waitMismatches polls until the async comparison has run
+func waitMismatches(s *shadowTarget, want uint64) uint64 {
+	deadline := time.Now().Add(time.Second)
+	for s.mismatches.Load() < want && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	return s.mismatches.Load()
+}
+
+// TestShadowMirroring tests that the shadow receives a copy of the rewritten request
+func TestShadowMirroring(t *testing.T) {
+	router := newTestRouter(t)
+	shadow, hits := newShadowBackend(t, http.StatusOK, "", 0)
+	if err := router.SetShadows(map[string]ShadowConfig{
+		"user-register": {URL: shadow.URL, MirrorUnsafe: true},
+		"users":         {URL: shadow.URL},
+	}); err != nil {
+		t.Fatalf("SetShadows error: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/register", strings.NewReader(`{"name":"a"}`))
+	req.Header.Set("X-User-Id", "admin")
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || rr.Body.String() != "/users" {
+		t.Errorf("primary = %d %q; want 200 /users", rr.Code, rr.Body.String())
+	}
+
+	hit := waitShadow(t, hits)
+	if hit.method != http.MethodPost || hit.path != "/users" || hit.body != `{"name":"a"}` || hit.header != "true" || hit.userID != "" {
+		t.Errorf("shadow hit = %+v", hit)
+	}
+
+	t.Run("mirror_unsafe 없으면 POST/PATCH는 미러링하지 않음", func(t *testing.T) {
+		for _, method := range []string{http.MethodPost, http.MethodPatch} {
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/api/users/a", strings.NewReader(`{}`)))
+		}
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/api/users/a", strings.NewReader(`{}`)))
+		if hit := waitShadow(t, hits); hit.method != http.MethodPut {
+			t.Errorf("shadow hit = %+v; want only the PUT", hit)
+		}
+	})
+
+	t.Run("다른 Route는 미러링하지 않음", func(t *testing.T) {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/blog/api/posts", nil))
+		select {
+		case hit := <-hits:
+			t.Errorf("unexpected shadow hit %+v", hit)
+		case <-time.After(100 * time.Millisecond):
+		}
+	})
+}
+
+// TestShadowDoesNotDelayPrimary tests that a slow shadow does not add latency
+func TestShadowDoesNotDelayPrimary(t *testing.T) {
+	router := newTestRouter(t)
+	shadow, hits := newShadowBackend(t, http.StatusOK, "", 500*time.Millisecond)
+	router.SetShadows(map[string]ShadowConfig{"users": {URL: shadow.URL, Compare: true}})
+
+	start := time.Now()
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/users/a", nil))
+	if elapsed := time.Since(start); elapsed > 300*time.Millisecond {
+		t.Errorf("primary took %s; shadow should not block it", elapsed)
+	}
+	waitShadow(t, hits)
+}
+
+// TestShadowBodyLimit tests that oversized bodies reach the primary but are not mirrored
+func TestShadowBodyLimit(t *testing.T) {
+	router := newTestRouter(t)
+	shadow, hits := newShadowBackend(t, http.StatusOK, "", 0)
+	router.SetShadows(map[string]ShadowConfig{"user-register": {URL: shadow.URL, MaxBodyBytes: 4, MirrorUnsafe: true}})
+
+	received := make(chan string, 1)
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		received <- string(b)
+	}))
+	defer primary.Close()
+	router.upstreams["user-service"] = newTestUpstream(t, "user-service", primary)
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/register", strings.NewReader("0123456789")))
+	if got := <-received; got != "0123456789" {
+		t.Errorf("primary body = %q; want full body", got)
+	}
+	select {
+	case hit := <-hits:
+		t.Errorf("oversized request should not be mirrored, got %+v", hit)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// TestShadowCompare tests status/body mismatch detection
+func TestShadowCompare(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		mismatches uint64
+	}{
+		{"일치", http.StatusOK, "/users/a", 0},
+		{"상태 코드 불일치", http.StatusInternalServerError, "/users/a", 1},
+		{"본문 불일치", http.StatusOK, "/v2/users/a", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestRouter(t)
+			shadow, hits := newShadowBackend(t, tt.status, tt.body, 0)
+			router.SetShadows(map[string]ShadowConfig{"users": {URL: shadow.URL, Compare: true}})
+
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/users/a", nil))
+			waitShadow(t, hits)
+			s := router.shadow("users")
+			if got := waitMismatches(s, tt.mismatches); got != tt.mismatches {
+				t.Errorf("mismatches = %d; want %d", got, tt.mismatches)
+			}
+		})
+	}
+}
+
+// TestShadowSameBody tests body comparison across encodings and JSON formatting
+func TestShadowSameBody(t *testing.T) {
+	gzipped := func(body string) []byte {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		io.WriteString(zw, body)
+		zw.Close()
+		return buf.Bytes()
+	}
+	jsonHeader := http.Header{"Content-Type": {"application/json"}}
+	gzipJSONHeader := http.Header{"Content-Type": {"application/json"}, "Content-Encoding": {"gzip"}}
+
+	tests := []struct {
+		name       string
+		primary    *shadowResponse
+		shadow     *shadowResponse
+		expectSame bool
+		expectOK   bool
+	}{
+		{"JSON 키 순서와 공백 무시",
+			&shadowResponse{header: jsonHeader, body: []byte(`{"id": 1, "name": "a"}`)},
+			&shadowResponse{header: jsonHeader, body: []byte(`{"name":"a","id":1}`)}, true, true},
+		{"JSON 값 차이",
+			&shadowResponse{header: jsonHeader, body: []byte(`{"id": 1}`)},
+			&shadowResponse{header: jsonHeader, body: []byte(`{"id": 2}`)}, false, true},
+		{"gzip 해제 후 비교",
+			&shadowResponse{header: gzipJSONHeader, body: gzipped(`{"id": 1}`)},
+			&shadowResponse{header: jsonHeader, body: []byte(`{"id":1}`)}, true, true},
+		{"JSON 아니면 바이트 비교",
+			&shadowResponse{header: http.Header{}, body: []byte("a b")},
+			&shadowResponse{header: http.Header{}, body: []byte("a  b")}, false, true},
+		{"해제할 수 없는 인코딩은 비교 안 함",
+			&shadowResponse{header: http.Header{"Content-Encoding": {"br"}}, body: []byte{1, 2}},
+			&shadowResponse{header: http.Header{}, body: []byte("x")}, false, false},
+		{"잘린 본문은 비교 안 함",
+			&shadowResponse{header: jsonHeader, body: []byte(`{"id"`), truncated: true},
+			&shadowResponse{header: jsonHeader, body: []byte(`{"id": 1}`)}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if same, ok := sameBody(tt.primary, tt.shadow, 1<<20); same != tt.expectSame || ok != tt.expectOK {
+				t.Errorf("sameBody = %v, %v; want %v, %v", same, ok, tt.expectSame, tt.expectOK)
+			}
+		})
+	}
+}
+
+// TestShadowConfigValidation tests rejected shadow configs
+func TestShadowConfigValidation(t *testing.T) {
+	router := newTestRouter(t)
+	if err := router.SetShadows(map[string]ShadowConfig{"nope": {URL: "http://x"}}); err == nil {
+		t.Error("unknown route should be rejected")
+	}
+	if err := router.SetShadows(map[string]ShadowConfig{"users": {URL: "not a url"}}); err == nil {
+		t.Error("invalid url should be rejected")
+	}
+	if router.shadow("users") != nil {
+		t.Error("rejected config should not be applied")
+	}
+}
@@ -0,0 +1,180 @@
+// api-gateway/static.go
+// Blog HTML/정적 자산 서빙: blog-service 프록시 또는 마운트된 디렉터리에서 직접 제공
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	blogStaticPrefix = "/blog/static/"
+
+	// 버전이 포함된 자산은 내용이 바뀌면 URL도 바뀌므로 1년간 캐시
+	immutableCacheControl = "public, max-age=31536000, immutable"
+	// 버전 없는 자산은 매번 ETag로 재검증
+	revalidateCacheControl = "no-cache"
+)
+
+// versionedAssetPattern matches fingerprinted file names such as app.3f9a1c2b.js
+var versionedAssetPattern = regexp.MustCompile(`\.[0-9a-fA-F]{8,}\.[A-Za-z0-9]+$`)
+
+// isVersionedAsset reports whether the request targets a content-addressed asset
+// (fingerprinted file name or explicit ?v= query) that is safe to cache forever.
+func isVersionedAsset(r *http.Request) bool {
+	if !strings.HasPrefix(r.URL.Path, blogStaticPrefix) {
+		return false
+	}
+	if r.URL.Query().Get("v") != "" {
+		return true
+	}
+	return versionedAssetPattern.MatchString(path.Base(r.URL.Path))
+}
+
+// staticCacheControl returns the Cache-Control value for a blog static asset.
+func staticCacheControl(r *http.Request) string {
+	if isVersionedAsset(r) {
+		return immutableCacheControl
+	}
+	return revalidateCacheControl
+}
+
+type etagEntry struct {
+	modTime time.Time
+	size    int64
+	etag    string
+}
+
+// staticFileServer serves /blog/static/ from a filesystem with strong ETags.
+type staticFileServer struct {
+	fsys  fs.FS
+	mu    sync.RWMutex
+	etags map[string]etagEntry
+}
+
+func newStaticFileServer(fsys fs.FS) *staticFileServer {
+	return &staticFileServer{
+		fsys:  fsys,
+		etags: make(map[string]etagEntry),
+	}
+}
+
+// newBlogStaticHandler returns a handler for a mounted blog-service static
+// bundle, or nil when dir is empty (assets are then proxied to blog-service).
+func newBlogStaticHandler(dir string) http.Handler {
+	if dir == "" {
+		return nil
+	}
+	return newStaticFileServer(os.DirFS(dir))
+}
+
+func (s *staticFileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeError(w, r, http.StatusMethodNotAllowed, "")
+		return
+	}
+
+	cleaned := path.Clean(r.URL.Path)
+	if !strings.HasPrefix(cleaned, blogStaticPrefix) {
+		notFound(w, r)
+		return
+	}
+	name := strings.TrimPrefix(cleaned, blogStaticPrefix)
+	if name == "" || !fs.ValidPath(name) {
+		notFound(w, r)
+		return
+	}
+
+	f, err := s.fsys.Open(name)
+	if err != nil {
+		notFound(w, r)
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		// 디렉터리 목록은 노출하지 않음
+		notFound(w, r)
+		return
+	}
+
+	rs, ok := f.(io.ReadSeeker)
+	if !ok {
+		writeError(w, r, http.StatusInternalServerError, "")
+		return
+	}
+
+	etag, err := s.etag(name, info, rs)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "")
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", staticCacheControl(r))
+	// ServeContent handles If-None-Match/If-Modified-Since and Range requests
+	http.ServeContent(w, r, info.Name(), info.ModTime(), rs)
+}
+
+// etag returns a strong ETag derived from the file content, cached until the
+// file's size or modification time changes.
+func (s *staticFileServer) etag(name string, info fs.FileInfo, rs io.ReadSeeker) (string, error) {
+	s.mu.RLock()
+	e, ok := s.etags[name]
+	s.mu.RUnlock()
+	if ok && e.size == info.Size() && e.modTime.Equal(info.ModTime()) {
+		return e.etag, nil
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, rs); err != nil {
+		return "", err
+	}
+	if _, err := rs.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	etag := fmt.Sprintf(`"%s"`, hex.EncodeToString(h.Sum(nil))[:32])
+
+	s.mu.Lock()
+	s.etags[name] = etagEntry{modTime: info.ModTime(), size: info.Size(), etag: etag}
+	s.mu.Unlock()
+	return etag, nil
+}
+
+// blogPageHandler routes /blog/ HTML pages to blog-service and /blog/static/
+// assets to the local bundle when one is mounted.
+func blogPageHandler(blogProxy http.Handler, static http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if static != nil && strings.HasPrefix(r.URL.Path, blogStaticPrefix) {
+			static.ServeHTTP(w, r)
+			return
+		}
+		blogProxy.ServeHTTP(w, r)
+	})
+}
+
+// setProxiedStaticCacheHeaders applies the same caching policy to assets
+// proxied from blog-service (which already provides ETag/Last-Modified).
+func setProxiedStaticCacheHeaders(resp *http.Response) error {
+	req := resp.Request
+	if req == nil || !strings.HasPrefix(req.URL.Path, blogStaticPrefix) {
+		return nil
+	}
+	if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusNotModified {
+		resp.Header.Set("Cache-Control", staticCacheControl(req))
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+// api-gateway/static_test.go
+// 단위 테스트: Blog 정적 자산 서빙, 캐시 정책, ETag
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func newTestStaticServer() *staticFileServer {
+	return newStaticFileServer(fstest.MapFS{
+		"css/style.css":       {Data: []byte("body{}"), ModTime: time.Unix(1700000000, 0)},
+		"js/app.3f9a1c2b.js":  {Data: []byte("console.log(1)"), ModTime: time.Unix(1700000000, 0)},
+		"js/modules/auth.js":  {Data: []byte("export {}"), ModTime: time.Unix(1700000000, 0)},
+		"fonts/NotoSans.woff": {Data: []byte("font"), ModTime: time.Unix(1700000000, 0)},
+	})
+}
+
+// TestIsVersionedAsset tests fingerprint/query detection
+func TestIsVersionedAsset(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		expected bool
+	}{
+		{"fingerprint 파일명", "/blog/static/js/app.3f9a1c2b.js", true},
+		{"v 쿼리 파라미터", "/blog/static/css/style.css?v=42", true},
+		{"버전 없는 자산", "/blog/static/css/style.css", false},
+		{"min 파일은 버전 아님", "/blog/static/js/marked.min.js", false},
+		{"static 외 경로", "/blog/app.3f9a1c2b.js", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if got := isVersionedAsset(req); got != tt.expected {
+				t.Errorf("isVersionedAsset(%s) = %v; want %v", tt.target, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestStaticFileServer tests serving, caching headers and conditional requests
+func TestStaticFileServer(t *testing.T) {
+	srv := newTestStaticServer()
+
+	t.Run("버전 없는 자산 - no-cache + ETag", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/blog/static/css/style.css", nil)
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Status = %d; want 200", rr.Code)
+		}
+		if got := rr.Body.String(); got != "body{}" {
+			t.Errorf("Body = %q; want %q", got, "body{}")
+		}
+		if got := rr.Header().Get("Cache-Control"); got != revalidateCacheControl {
+			t.Errorf("Cache-Control = %s; want %s", got, revalidateCacheControl)
+		}
+		if rr.Header().Get("ETag") == "" {
+			t.Error("ETag header should be set")
+		}
+	})
+
+	t.Run("버전 자산 - immutable", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/blog/static/js/app.3f9a1c2b.js", nil)
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, req)
+
+		if got := rr.Header().Get("Cache-Control"); got != immutableCacheControl {
+			t.Errorf("Cache-Control = %s; want %s", got, immutableCacheControl)
+		}
+	})
+
+	t.Run("If-None-Match 일치 시 304", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/blog/static/js/modules/auth.js", nil)
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, req)
+		etag := rr.Header().Get("ETag")
+
+		req2 := httptest.NewRequest(http.MethodGet, "/blog/static/js/modules/auth.js", nil)
+		req2.Header.Set("If-None-Match", etag)
+		rr2 := httptest.NewRecorder()
+		srv.ServeHTTP(rr2, req2)
+
+		if rr2.Code != http.StatusNotModified {
+			t.Errorf("Status = %d; want 304", rr2.Code)
+		}
+		if rr2.Body.Len() != 0 {
+			t.Error("304 response should have empty body")
+		}
+	})
+
+	t.Run("ETag는 캐시되어 동일 값 반환", func(t *testing.T) {
+		first := httptest.NewRecorder()
+		srv.ServeHTTP(first, httptest.NewRequest(http.MethodGet, "/blog/static/css/style.css", nil))
+		second := httptest.NewRecorder()
+		srv.ServeHTTP(second, httptest.NewRequest(http.MethodGet, "/blog/static/css/style.css", nil))
+
+		if first.Header().Get("ETag") != second.Header().Get("ETag") {
+			t.Error("ETag should be stable across requests")
+		}
+	})
+
+	t.Run("디렉터리 목록 차단", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/blog/static/js/", nil))
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("Status = %d; want 404", rr.Code)
+		}
+	})
+
+	t.Run("경로 탈출 차단", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/blog/static/css/style.css", nil)
+		req.URL.Path = "/blog/static/../templates/index.html"
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, req)
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("Status = %d; want 404", rr.Code)
+		}
+	})
+
+	t.Run("존재하지 않는 파일 404", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/blog/static/missing.js", nil))
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("Status = %d; want 404", rr.Code)
+		}
+	})
+
+	t.Run("POST 거부", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/blog/static/css/style.css", nil))
+		if rr.Code != http.StatusMethodNotAllowed {
+			t.Errorf("Status = %d; want 405", rr.Code)
+		}
+	})
+}
+
+// TestBlogPageHandler tests routing between the proxy and the local bundle
+func TestBlogPageHandler(t *testing.T) {
+	proxied := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Test-Upstream", "blog-service")
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name          string
+		static        http.Handler
+		path          string
+		expectProxied bool
+	}{
+		{"HTML 페이지는 프록시", newTestStaticServer(), "/blog/", true},
+		{"SPA 하위 경로는 프록시", newTestStaticServer(), "/blog/posts/1", true},
+		{"마운트된 정적 자산은 직접 서빙", newTestStaticServer(), "/blog/static/css/style.css", false},
+		{"마운트 없으면 정적 자산도 프록시", nil, "/blog/static/css/style.css", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			blogPageHandler(proxied, tt.static).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if got := rr.Header().Get("X-Test-Upstream") == "blog-service"; got != tt.expectProxied {
+				t.Errorf("proxied = %v; want %v", got, tt.expectProxied)
+			}
+		})
+	}
+}
+
+// TestSetProxiedStaticCacheHeaders tests cache policy on proxied assets
+func TestSetProxiedStaticCacheHeaders(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		status   int
+		expected string
+	}{
+		{"버전 자산", "/blog/static/js/app.3f9a1c2b.js", http.StatusOK, immutableCacheControl},
+		{"일반 자산", "/blog/static/css/style.css", http.StatusOK, revalidateCacheControl},
+		{"HTML 페이지는 변경 없음", "/blog/", http.StatusOK, ""},
+		{"에러 응답은 변경 없음", "/blog/static/missing.js", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: tt.status,
+				Header:     http.Header{},
+				Request:    httptest.NewRequest(http.MethodGet, tt.target, nil),
+			}
+			if err := setProxiedStaticCacheHeaders(resp); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := resp.Header.Get("Cache-Control"); got != tt.expected {
+				t.Errorf("Cache-Control = %q; want %q", got, tt.expected)
+			}
+		})
+	}
+}
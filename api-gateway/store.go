@@ -0,0 +1,237 @@
+// api-gateway/store.go
+// 상태 저장소: 단일 인스턴스는 in-memory, 다중 Replica는 Redis 공유
+
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// kvStore is the small key/value contract gateway features share so that the
+// same code runs against process memory or a Redis shared by all replicas.
+type kvStore interface {
+	// Incr increments key and applies ttl when the key is created.
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// SetNX stores value only if key does not exist and reports whether it did.
+	SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+	Delete(ctx context.Context, key string) error
+	// Keys lists live keys that start with prefix.
+	Keys(ctx context.Context, prefix string) ([]string, error)
+}
+
+// newStoreFromEnv selects the backend with GATEWAY_STORE (memory|redis).
+// Redis uses the same REDIS_HOST/REDIS_PORT/REDIS_PASSWORD/REDIS_DB as the
+// Python services.
+func newStoreFromEnv() kvStore {
+	if getEnv("GATEWAY_STORE", "memory") != "redis" {
+		return newMemoryStore()
+	}
+	db, _ := strconv.Atoi(getEnv("REDIS_DB", "0"))
+	addr := net.JoinHostPort(getEnv("REDIS_HOST", "redis-service"), getEnv("REDIS_PORT", "6379"))
+	log.Printf("Gateway state store: redis (%s db=%d)", addr, db)
+	return newRedisStore(redis.NewClient(&redis.Options{
+		Addr:         addr,
+		Password:     getEnv("REDIS_PASSWORD", ""),
+		DB:           db,
+		DialTimeout:  2 * time.Second,
+		ReadTimeout:  500 * time.Millisecond,
+		WriteTimeout: 500 * time.Millisecond,
+	}))
+}
+
+var gatewayStore = newStoreFromEnv()
+
+// === In-memory Store ===
+type memoryEntry struct {
+	value    []byte
+	expireAt time.Time
+}
+
+func (e memoryEntry) expired(now time.Time) bool {
+	return !e.expireAt.IsZero() && now.After(e.expireAt)
+}
+
+type memoryStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	now     func() time.Time
+}
+
+func newMemoryStore() *memoryStore {
+	s := &memoryStore{
+		entries: make(map[string]memoryEntry),
+		now:     time.Now,
+	}
+	go s.cleanupExpired()
+	return s
+}
+
+func (s *memoryStore) cleanupExpired() {
+	for {
+		time.Sleep(time.Minute)
+		s.mu.Lock()
+		now := s.now()
+		for k, e := range s.entries {
+			if e.expired(now) {
+				delete(s.entries, k)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+func (s *memoryStore) expiry(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return s.now().Add(ttl)
+}
+
+// lookup returns a live entry; callers must hold s.mu.
+func (s *memoryStore) lookup(key string) (memoryEntry, bool) {
+	e, ok := s.entries[key]
+	if !ok {
+		return memoryEntry{}, false
+	}
+	if e.expired(s.now()) {
+		delete(s.entries, key)
+		return memoryEntry{}, false
+	}
+	return e, true
+}
+
+func (s *memoryStore) Incr(_ context.Context, key string, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.lookup(key)
+	if !ok {
+		s.entries[key] = memoryEntry{value: []byte("1"), expireAt: s.expiry(ttl)}
+		return 1, nil
+	}
+	n, err := strconv.ParseInt(string(e.value), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	n++
+	e.value = []byte(strconv.FormatInt(n, 10))
+	s.entries[key] = e
+	return n, nil
+}
+
+func (s *memoryStore) Get(_ context.Context, key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.lookup(key)
+	if !ok {
+		return nil, false, nil
+	}
+	return append([]byte(nil), e.value...), true, nil
+}
+
+func (s *memoryStore) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = memoryEntry{value: append([]byte(nil), value...), expireAt: s.expiry(ttl)}
+	return nil
+}
+
+func (s *memoryStore) SetNX(_ context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.lookup(key); ok {
+		return false, nil
+	}
+	s.entries[key] = memoryEntry{value: append([]byte(nil), value...), expireAt: s.expiry(ttl)}
+	return true, nil
+}
+
+func (s *memoryStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+func (s *memoryStore) Keys(_ context.Context, prefix string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var keys []string
+	for k := range s.entries {
+		if _, ok := s.lookup(k); ok && strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+// === Redis Store ===
+const redisKeyPrefix = "api-gateway:"
+
+type redisStore struct {
+	client *redis.Client
+}
+
+func newRedisStore(client *redis.Client) *redisStore {
+	return &redisStore{client: client}
+}
+
+// incrScript keeps INCR and the first EXPIRE atomic across replicas.
+var incrScript = redis.NewScript(`
+local n = redis.call("INCR", KEYS[1])
+if n == 1 and tonumber(ARGV[1]) > 0 then
+  redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return n`)
+
+func (s *redisStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	return incrScript.Run(ctx, s.client, []string{redisKeyPrefix + key}, ttl.Milliseconds()).Int64()
+}
+
+func (s *redisStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	v, err := s.client.Get(ctx, redisKeyPrefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return v, true, nil
+}
+
+func (s *redisStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return s.client.Set(ctx, redisKeyPrefix+key, value, ttl).Err()
+}
+
+func (s *redisStore) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	return s.client.SetNX(ctx, redisKeyPrefix+key, value, ttl).Result()
+}
+
+func (s *redisStore) Delete(ctx context.Context, key string) error {
+	return s.client.Del(ctx, redisKeyPrefix+key).Err()
+}
+
+func (s *redisStore) Keys(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	iter := s.client.Scan(ctx, 0, redisKeyPrefix+prefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, strings.TrimPrefix(iter.Val(), redisKeyPrefix))
+	}
+	return keys, iter.Err()
+}
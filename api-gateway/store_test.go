@@ -0,0 +1,131 @@
+// api-gateway/store_test.go
+// 단위 테스트: in-memory / Redis 상태 저장소
+
+package main
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestRedisStore starts an in-process Redis stand-in for store tests
+func newTestRedisStore(t *testing.T) (*redisStore, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return newRedisStore(client), mr
+}
+
+// TestKVStores runs the same contract against both backends
+func TestKVStores(t *testing.T) {
+	redisBackend, _ := newTestRedisStore(t)
+	backends := map[string]kvStore{
+		"memory": newMemoryStore(),
+		"redis":  redisBackend,
+	}
+
+	for name, store := range backends {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			t.Run("Incr 누적", func(t *testing.T) {
+				for want := int64(1); want <= 3; want++ {
+					got, err := store.Incr(ctx, "counter", time.Minute)
+					if err != nil {
+						t.Fatalf("Incr error: %v", err)
+					}
+					if got != want {
+						t.Errorf("Incr = %d; want %d", got, want)
+					}
+				}
+			})
+
+			t.Run("Set/Get/Delete", func(t *testing.T) {
+				if err := store.Set(ctx, "k", []byte("v"), time.Minute); err != nil {
+					t.Fatalf("Set error: %v", err)
+				}
+				v, ok, err := store.Get(ctx, "k")
+				if err != nil || !ok || string(v) != "v" {
+					t.Errorf("Get = %q, %v, %v; want v, true, nil", v, ok, err)
+				}
+				if err := store.Delete(ctx, "k"); err != nil {
+					t.Fatalf("Delete error: %v", err)
+				}
+				if _, ok, _ := store.Get(ctx, "k"); ok {
+					t.Error("key should be deleted")
+				}
+			})
+
+			t.Run("SetNX는 최초 1회만 성공", func(t *testing.T) {
+				first, _ := store.SetNX(ctx, "once", []byte("a"), time.Minute)
+				second, _ := store.SetNX(ctx, "once", []byte("b"), time.Minute)
+				if !first || second {
+					t.Errorf("SetNX = %v, %v; want true, false", first, second)
+				}
+				v, _, _ := store.Get(ctx, "once")
+				if string(v) != "a" {
+					t.Errorf("value = %q; want a", v)
+				}
+			})
+
+			t.Run("Keys prefix 조회", func(t *testing.T) {
+				store.Set(ctx, "list:a", []byte("1"), time.Minute)
+				store.Set(ctx, "list:b", []byte("1"), time.Minute)
+				store.Set(ctx, "other", []byte("1"), time.Minute)
+				keys, err := store.Keys(ctx, "list:")
+				if err != nil {
+					t.Fatalf("Keys error: %v", err)
+				}
+				sort.Strings(keys)
+				if len(keys) != 2 || keys[0] != "list:a" || keys[1] != "list:b" {
+					t.Errorf("Keys = %v; want [list:a list:b]", keys)
+				}
+			})
+		})
+	}
+}
+
+// TestMemoryStoreExpiry tests TTL handling with a fake clock
+func TestMemoryStoreExpiry(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	s := &memoryStore{entries: make(map[string]memoryEntry), now: func() time.Time { return now }}
+	ctx := context.Background()
+
+	s.Set(ctx, "short", []byte("x"), time.Second)
+	s.Incr(ctx, "count", time.Second)
+	s.Set(ctx, "forever", []byte("x"), 0)
+
+	now = now.Add(2 * time.Second)
+
+	if _, ok, _ := s.Get(ctx, "short"); ok {
+		t.Error("expired key should not be returned")
+	}
+	if n, _ := s.Incr(ctx, "count", time.Second); n != 1 {
+		t.Errorf("Incr after expiry = %d; want 1", n)
+	}
+	if _, ok, _ := s.Get(ctx, "forever"); !ok {
+		t.Error("key without ttl should not expire")
+	}
+}
+
+// TestRedisStoreIncrTTL tests that the first Incr sets the expiry
+func TestRedisStoreIncrTTL(t *testing.T) {
+	store, mr := newTestRedisStore(t)
+	ctx := context.Background()
+
+	store.Incr(ctx, "ttl-counter", time.Minute)
+	if ttl := mr.TTL(redisKeyPrefix + "ttl-counter"); ttl != time.Minute {
+		t.Errorf("TTL = %v; want 1m", ttl)
+	}
+
+	mr.FastForward(2 * time.Minute)
+	if n, _ := store.Incr(ctx, "ttl-counter", time.Minute); n != 1 {
+		t.Errorf("Incr after expiry = %d; want 1", n)
+	}
+}
@@ -0,0 +1,253 @@
+// api-gateway/stream.go
+// WebSocket / Server-Sent Events: 장시간 연결의 deadline 해제, Route별 idle timeout과 동시 연결 수 제한
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	activeStreams = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "active_streams",
+			Help: "Number of open WebSocket and SSE connections",
+		},
+		[]string{"route", "kind"},
+	)
+	streamDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "stream_duration_seconds",
+			Help:    "Lifetime of WebSocket and SSE connections",
+			Buckets: []float64{1, 10, 30, 60, 300, 900, 1800, 3600},
+		},
+		[]string{"route", "kind"},
+	)
+	streamsRejectedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "streams_rejected_total",
+			Help: "Total number of streams rejected because the route's connection limit was reached",
+		},
+		[]string{"route", "kind"},
+	)
+)
+
+const (
+	streamWebSocket = "websocket"
+	streamSSE       = "sse"
+
+	defaultStreamIdleTimeout    = 60 * time.Second
+	defaultStreamMaxConnections = 1000
+)
+
+// StreamConfig tunes long-lived connections on one route
+// ("streams" section of the config file, keyed by route name).
+type StreamConfig struct {
+	// IdleTimeout closes a stream after no bytes moved in either direction
+	IdleTimeout jsonDuration `json:"idle_timeout,omitempty"`
+	// MaxConnections caps the open streams on the route; further ones get 503
+	MaxConnections int `json:"max_connections,omitempty"`
+}
+
+func (rt *Router) newStreamConfigs(configs map[string]StreamConfig) (map[string]StreamConfig, error) {
+	for name, cfg := range configs {
+		if rt.route(name) == nil {
+			return nil, fmt.Errorf("streams: unknown route %q", name)
+		}
+		if cfg.IdleTimeout < 0 || cfg.MaxConnections < 0 {
+			return nil, fmt.Errorf("streams %s: limits must not be negative", name)
+		}
+	}
+	return configs, nil
+}
+
+// SetStreams replaces the per-route stream settings.
+func (rt *Router) SetStreams(configs map[string]StreamConfig) error {
+	streams, err := rt.newStreamConfigs(configs)
+	if err != nil {
+		return err
+	}
+	rt.streams.Store(&streams)
+	return nil
+}
+
+// streamConfig returns the settings for route with defaults applied.
+func (rt *Router) streamConfig(route string) StreamConfig {
+	var cfg StreamConfig
+	if streams := rt.streams.Load(); streams != nil {
+		cfg = (*streams)[route]
+	}
+	if cfg.IdleTimeout == 0 {
+		cfg.IdleTimeout = jsonDuration(defaultStreamIdleTimeout)
+	}
+	if cfg.MaxConnections == 0 {
+		cfg.MaxConnections = defaultStreamMaxConnections
+	}
+	return cfg
+}
+
+// streamKind reports whether r opens a WebSocket or an SSE stream.
+func streamKind(r *http.Request) string {
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") && headerHasToken(r.Header, "Connection", "upgrade") {
+		return streamWebSocket
+	}
+	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		return streamSSE
+	}
+	return ""
+}
+
+func headerHasToken(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// serveStream proxies a WebSocket or SSE request. Streams are not recorded or
+// mirrored; they only count against the route's connection limit and are
+// closed once idle.
+func (rt *Router) serveStream(w http.ResponseWriter, r *http.Request, route *Route, kind string) {
+	cfg := rt.streamConfig(route.Name)
+	if route.activeStreams.Add(1) > int64(cfg.MaxConnections) {
+		route.activeStreams.Add(-1)
+		streamsRejectedTotal.WithLabelValues(route.Name, kind).Inc()
+		w.Header().Set("Retry-After", "5")
+		writeError(w, r, http.StatusServiceUnavailable, "Too many open streams on this route")
+		return
+	}
+	defer route.activeStreams.Add(-1)
+
+	active := activeStreams.WithLabelValues(route.Name, kind)
+	active.Inc()
+	defer active.Dec()
+	start := time.Now()
+	defer func() {
+		streamDuration.WithLabelValues(route.Name, kind).Observe(time.Since(start).Seconds())
+	}()
+
+	if kind == streamSSE {
+		// WebSocket은 Hijack 시 deadline이 해제되지만 SSE는 일반 응답이므로 직접 해제
+		rc := http.NewResponseController(w)
+		rc.SetReadDeadline(time.Time{})
+		rc.SetWriteDeadline(time.Time{})
+	}
+
+	// idle 시 context를 취소하면 ReverseProxy가 Upstream 연결(및 Hijack된 연결)을 닫음
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	idle := newIdleWatch(time.Duration(cfg.IdleTimeout), cancel)
+	defer idle.stop()
+
+	rt.proxy(&streamWriter{ResponseWriter: w, idle: idle}, r.WithContext(ctx))
+}
+
+// idleWatch calls onIdle once no activity was seen for timeout.
+type idleWatch struct {
+	timeout time.Duration
+	last    atomic.Int64
+
+	mu    sync.Mutex
+	timer *time.Timer
+}
+
+func newIdleWatch(timeout time.Duration, onIdle func()) *idleWatch {
+	iw := &idleWatch{timeout: timeout}
+	iw.touch()
+	iw.mu.Lock()
+	defer iw.mu.Unlock()
+	iw.timer = time.AfterFunc(timeout, func() {
+		// 매 Write마다 timer를 재설정하지 않고 만료 시점에 마지막 활동 시각을 확인
+		iw.mu.Lock()
+		defer iw.mu.Unlock()
+		if rest := iw.timeout - time.Since(time.Unix(0, iw.last.Load())); rest > 0 {
+			iw.timer.Reset(rest)
+			return
+		}
+		onIdle()
+	})
+	return iw
+}
+
+func (iw *idleWatch) touch() {
+	iw.last.Store(time.Now().UnixNano())
+}
+
+func (iw *idleWatch) stop() {
+	iw.mu.Lock()
+	defer iw.mu.Unlock()
+	iw.timer.Stop()
+}
+
+// streamWriter marks activity on every write and wraps hijacked connections
+// so that WebSocket frames in both directions keep the stream alive.
+type streamWriter struct {
+	http.ResponseWriter
+	idle *idleWatch
+}
+
+func (w *streamWriter) Write(b []byte) (int, error) {
+	w.idle.touch()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *streamWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	return &idleConn{Conn: conn, idle: w.idle}, brw, nil
+}
+
+func (w *streamWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+type idleConn struct {
+	net.Conn
+	idle *idleWatch
+}
+
+func (c *idleConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		c.idle.touch()
+	}
+	return n, err
+}
+
+func (c *idleConn) Write(b []byte) (int, error) {
+	c.idle.touch()
+	return c.Conn.Write(b)
+}
+
+// idleBody marks activity on every read of a request body, for streams whose
+// client sends messages (gRPC client streaming).
+type idleBody struct {
+	io.ReadCloser
+	idle *idleWatch
+}
+
+func (b *idleBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.idle.touch()
+	}
+	return n, err
+}
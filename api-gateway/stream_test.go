@@ -0,0 +1,187 @@
+// api-gateway/stream_test.go
+// 단위 테스트: WebSocket Upgrade / SSE 프록시, idle timeout, 동시 연결 수 제한
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newWebSocketBackend answers upgrades with 101 and echoes raw bytes
+func newWebSocketBackend(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, brw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			t.Errorf("backend hijack: %v", err)
+			return
+		}
+		defer conn.Close()
+		brw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n")
+		brw.Flush()
+		io.Copy(conn, brw)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// newStreamGateway serves router behind the metrics middleware with a short
+// WriteTimeout, as in production
+func newStreamGateway(t *testing.T, router *Router) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewUnstartedServer(prometheusMiddleware(router))
+	srv.Config.WriteTimeout = 50 * time.Millisecond
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// dialWebSocket sends an upgrade request and returns the connection after the 101
+func dialWebSocket(t *testing.T, srv *httptest.Server, path string) (net.Conn, *bufio.Reader, int) {
+	t.Helper()
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	fmt.Fprintf(conn, "GET %s HTTP/1.1\r\nHost: gateway\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n", path)
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatalf("read upgrade response: %v", err)
+	}
+	return conn, br, resp.StatusCode
+}
+
+// TestWebSocketProxy tests upgrades, echo past WriteTimeout, idle close and connection limits
+func TestWebSocketProxy(t *testing.T) {
+	router := newTestRouter(t)
+	router.upstreams["blog-service"] = newTestUpstream(t, "blog-service", newWebSocketBackend(t))
+	if err := router.SetStreams(map[string]StreamConfig{
+		"blog-api": {IdleTimeout: jsonDuration(200 * time.Millisecond), MaxConnections: 1},
+	}); err != nil {
+		t.Fatalf("SetStreams error: %v", err)
+	}
+	gateway := newStreamGateway(t, router)
+
+	conn, br, status := dialWebSocket(t, gateway, "/blog/api/posts/live")
+	if status != http.StatusSwitchingProtocols {
+		t.Fatalf("Status = %d; want 101", status)
+	}
+
+	t.Run("WriteTimeout 이후에도 양방향 전송", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			time.Sleep(60 * time.Millisecond)
+			io.WriteString(conn, "ping\n")
+			line, err := br.ReadString('\n')
+			if err != nil || line != "ping\n" {
+				t.Fatalf("echo %d = %q, %v", i, line, err)
+			}
+		}
+	})
+
+	t.Run("동시 연결 수 초과는 503", func(t *testing.T) {
+		_, _, status := dialWebSocket(t, gateway, "/blog/api/posts/live")
+		if status != http.StatusServiceUnavailable {
+			t.Errorf("Status = %d; want 503", status)
+		}
+	})
+
+	t.Run("idle 연결 종료", func(t *testing.T) {
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		start := time.Now()
+		if _, err := br.ReadString('\n'); err == nil {
+			t.Fatal("expected connection to be closed")
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("closed after %s; want ~200ms idle timeout", elapsed)
+		}
+	})
+
+	t.Run("종료 후 슬롯 반환", func(t *testing.T) {
+		deadline := time.Now().Add(time.Second)
+		for router.route("blog-api").activeStreams.Load() != 0 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if _, _, status := dialWebSocket(t, gateway, "/blog/api/posts/live"); status != http.StatusSwitchingProtocols {
+			t.Errorf("Status = %d; want 101", status)
+		}
+	})
+}
+
+// TestSSEProxy tests that events are flushed as they arrive past WriteTimeout
+func TestSSEProxy(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := 0; i < 3; i++ {
+			fmt.Fprintf(w, "data: post-%d\n\n", i)
+			http.NewResponseController(w).Flush()
+			time.Sleep(40 * time.Millisecond)
+		}
+	}))
+	defer backend.Close()
+
+	router := newTestRouter(t)
+	router.upstreams["blog-service"] = newTestUpstream(t, "blog-service", backend)
+	gateway := newStreamGateway(t, router)
+
+	req, _ := http.NewRequest(http.MethodGet, gateway.URL+"/blog/api/posts/events", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	// 첫 이벤트는 Upstream 응답이 끝나기 전에 도착해야 함
+	first := make(chan string, 1)
+	br := bufio.NewReader(resp.Body)
+	go func() {
+		line, _ := br.ReadString('\n')
+		first <- line
+	}()
+	select {
+	case line := <-first:
+		if line != "data: post-0\n" {
+			t.Errorf("first event = %q", line)
+		}
+	case <-time.After(80 * time.Millisecond):
+		t.Fatal("first event was not flushed")
+	}
+
+	rest, err := io.ReadAll(br)
+	if err != nil || strings.Count(string(rest), "data: ") != 2 {
+		t.Errorf("remaining events = %q, %v; want 2 events after WriteTimeout", rest, err)
+	}
+}
+
+// TestStreamKind tests WebSocket and SSE detection
+func TestStreamKind(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		expect string
+	}{
+		{"WebSocket", http.Header{"Upgrade": {"websocket"}, "Connection": {"keep-alive, Upgrade"}}, streamWebSocket},
+		{"Connection 없는 Upgrade", http.Header{"Upgrade": {"websocket"}}, ""},
+		{"SSE", http.Header{"Accept": {"text/event-stream"}}, streamSSE},
+		{"일반 요청", http.Header{"Accept": {"application/json"}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header = tt.header
+			if got := streamKind(req); got != tt.expect {
+				t.Errorf("streamKind = %q; want %q", got, tt.expect)
+			}
+		})
+	}
+}
@@ -0,0 +1,384 @@
+// api-gateway/upstream.go
+// Upstream 관리: 리버스 프록시, Circuit Breaker, 헬스 체크, Drain
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	upstreamHealthy = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "upstream_healthy",
+			Help: "Whether the last active health check of the upstream succeeded (1) or not (0)",
+		},
+		[]string{"upstream"},
+	)
+	upstreamCircuitState = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "upstream_circuit_state",
+			Help: "Circuit breaker state per upstream (0=closed, 1=half-open, 2=open)",
+		},
+		[]string{"upstream"},
+	)
+)
+
+// === Circuit Breaker ===
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerHalfOpen
+	breakerOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerHalfOpen:
+		return "half-open"
+	case breakerOpen:
+		return "open"
+	default:
+		return "closed"
+	}
+}
+
+// circuitBreaker opens after threshold consecutive failures, rejects requests
+// for openTimeout, then lets a single trial request through (half-open).
+type circuitBreaker struct {
+	mu          sync.Mutex
+	name        string
+	state       breakerState
+	failures    int
+	threshold   int
+	openTimeout time.Duration
+	openedAt    time.Time
+	trialActive bool
+	now         func() time.Time
+}
+
+func newCircuitBreaker(name string, threshold int, openTimeout time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		name:        name,
+		threshold:   threshold,
+		openTimeout: openTimeout,
+		now:         time.Now,
+	}
+}
+
+// setState updates the state and its gauge; callers must hold cb.mu.
+func (cb *circuitBreaker) setState(s breakerState) {
+	if cb.state != s {
+		log.Printf("Circuit breaker %s: %s -> %s", cb.name, cb.state, s)
+	}
+	cb.state = s
+	upstreamCircuitState.WithLabelValues(cb.name).Set(float64(s))
+}
+
+// Allow reports whether a request may be sent to the upstream, and whether
+// it is the half-open trial, which the caller must release with ReleaseTrial.
+func (cb *circuitBreaker) Allow() (ok, trial bool) {
+	if cb.threshold <= 0 {
+		return true, false
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case breakerOpen:
+		if cb.now().Sub(cb.openedAt) < cb.openTimeout {
+			return false, false
+		}
+		cb.setState(breakerHalfOpen)
+		cb.trialActive = true
+		return true, true
+	case breakerHalfOpen:
+		if cb.trialActive {
+			return false, false
+		}
+		cb.trialActive = true
+		return true, true
+	default:
+		return true, false
+	}
+}
+
+func (cb *circuitBreaker) RecordSuccess() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.failures = 0
+	cb.trialActive = false
+	if cb.state != breakerClosed {
+		cb.setState(breakerClosed)
+	}
+}
+
+func (cb *circuitBreaker) RecordFailure() {
+	if cb.threshold <= 0 {
+		return
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.failures++
+	cb.trialActive = false
+	if cb.state == breakerHalfOpen || cb.failures >= cb.threshold {
+		cb.openedAt = cb.now()
+		cb.setState(breakerOpen)
+	}
+}
+
+// ReleaseTrial ends a half-open trial that recorded neither success nor
+// failure (client cancellation, oversized body), so the next request can
+// become the trial instead of the breaker rejecting everything.
+func (cb *circuitBreaker) ReleaseTrial() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.trialActive = false
+}
+
+// Reset forces the breaker closed (admin action).
+func (cb *circuitBreaker) Reset() {
+	cb.RecordSuccess()
+}
+
+func (cb *circuitBreaker) State() breakerState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state
+}
+
+// remaining returns how long an open breaker keeps rejecting requests.
+func (cb *circuitBreaker) remaining() time.Duration {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if cb.state != breakerOpen {
+		return 0
+	}
+	return cb.openTimeout - cb.now().Sub(cb.openedAt)
+}
+
+// === Upstream ===
+type upstreamHealth struct {
+	Healthy   bool      `json:"healthy"`
+	CheckedAt time.Time `json:"checked_at"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// upstreamVersion is one deployment of an upstream (stable, canary, ...),
+// with its own proxy and circuit breaker so a bad canary cannot trip stable.
+type upstreamVersion struct {
+	Name    string
+	Target  *url.URL
+	Weight  int
+	Canary  bool
+	proxy   *httputil.ReverseProxy
+	breaker *circuitBreaker
+}
+
+// Upstream is one backend service reached through a reverse proxy.
+type Upstream struct {
+	Name     string
+	Target   *url.URL
+	breaker  *circuitBreaker
+	draining atomic.Bool
+
+	primary        *upstreamVersion
+	split          atomic.Pointer[trafficSplit]
+	modifyResponse func(*http.Response) error
+
+	mu     sync.RWMutex
+	health upstreamHealth
+}
+
+// NewUpstream creates the proxy for target. modifyResponse may be nil.
+func NewUpstream(name string, target *url.URL, breaker *circuitBreaker, modifyResponse func(*http.Response) error) *Upstream {
+	u := &Upstream{
+		Name:           name,
+		Target:         target,
+		breaker:        breaker,
+		modifyResponse: modifyResponse,
+	}
+	u.primary = u.newVersion(defaultVersionName, target, breaker)
+	return u
+}
+
+func (u *Upstream) newVersion(name string, target *url.URL, breaker *circuitBreaker) *upstreamVersion {
+	v := &upstreamVersion{
+		Name:    name,
+		Target:  target,
+		breaker: breaker,
+	}
+	var transport http.RoundTripper
+	if target.Scheme == h2cScheme {
+		plain := *target
+		plain.Scheme = "http"
+		target, transport = &plain, h2cTransport
+	}
+	v.proxy = &httputil.ReverseProxy{
+		Transport: transport,
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			stripUntrustedHeaders(pr.Out.Header)
+			rewriteForwarded(pr, u.Name, target)
+			currentHeaderRules.Load().applyRequest(pr.Out.Header, pr.In, routeFromContext(pr.In.Context()))
+		},
+		ModifyResponse: func(resp *http.Response) error {
+			if resp.StatusCode >= 500 {
+				v.breaker.RecordFailure()
+			} else {
+				v.breaker.RecordSuccess()
+			}
+			if u.modifyResponse != nil {
+				if err := u.modifyResponse(resp); err != nil {
+					return err
+				}
+			}
+			currentHeaderRules.Load().applyResponse(resp, routeFromContext(resp.Request.Context()))
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			p := proxyErrorProblem(err)
+			p.Upstream = u.Name
+			// 클라이언트 취소나 요청 본문 초과는 Upstream 장애가 아님
+			if p.Status >= http.StatusBadGateway {
+				v.breaker.RecordFailure()
+			}
+			log.Printf("http: proxy error (%s/%s): %v", u.Name, v.Name, err)
+			writeProblem(w, r, p)
+		},
+	}
+	return v
+}
+
+// proxyErrorProblem maps a ReverseProxy transport error to a response:
+// dial failures 502, timeouts 504, oversized request bodies 413 and
+// client cancellation 499.
+func proxyErrorProblem(err error) *problem {
+	var tooLarge *http.MaxBytesError
+	var netErr net.Error
+	var opErr *net.OpError
+	switch {
+	case errors.As(err, &tooLarge):
+		return &problem{
+			Type:   problemTypeRequestTooLarge,
+			Status: http.StatusRequestEntityTooLarge,
+			Detail: fmt.Sprintf("Request body exceeds %d bytes", tooLarge.Limit),
+		}
+	case errors.Is(err, context.Canceled):
+		return &problem{Type: problemTypeClientClosed, Status: statusClientClosedRequest, Detail: "Client closed the request"}
+	case errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()):
+		return &problem{Type: problemTypeUpstreamTimeout, Status: http.StatusGatewayTimeout, Detail: "Upstream did not respond in time"}
+	case errors.As(err, &opErr) && opErr.Op == "dial":
+		return &problem{Type: problemTypeUpstreamUnreachable, Status: http.StatusBadGateway, Detail: "Upstream is unreachable"}
+	default:
+		return &problem{Status: http.StatusBadGateway, Detail: "Invalid response from upstream"}
+	}
+}
+
+func (u *Upstream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if u.draining.Load() {
+		w.Header().Set("Retry-After", "30")
+		writeError(w, r, http.StatusServiceUnavailable, "Service Unavailable")
+		return
+	}
+
+	v := u.selectVersion(w, r)
+	ok, trial := v.breaker.Allow()
+	if !ok {
+		retry := int(v.breaker.remaining().Seconds()) + 1
+		w.Header().Set("Retry-After", strconv.Itoa(retry))
+		writeError(w, r, http.StatusServiceUnavailable, "Service Unavailable")
+		return
+	}
+	if trial {
+		// ModifyResponse/ErrorHandler가 결과를 기록하지 않은 경우(499, 413, panic)에도 시험 요청 해제
+		defer v.breaker.ReleaseTrial()
+	}
+
+	// Version별 에러율/지연시간 비교용 메트릭
+	recorder := wrapResponseWriter(w)
+	start := time.Now()
+	v.proxy.ServeHTTP(recorder, r)
+	upstreamVersionRequestDuration.WithLabelValues(u.Name, v.Name).Observe(time.Since(start).Seconds())
+	upstreamVersionRequestsTotal.WithLabelValues(u.Name, v.Name, statusClass(recorder.status)).Inc()
+}
+
+// SetDraining stops (or resumes) sending new requests to the upstream.
+func (u *Upstream) SetDraining(draining bool) {
+	u.draining.Store(draining)
+}
+
+func (u *Upstream) Health() upstreamHealth {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	return u.health
+}
+
+// checkHealth probes GET <target>/health, which every backend service exposes,
+// or the gRPC health service of h2c upstreams.
+func (u *Upstream) checkHealth(client *http.Client) {
+	h := upstreamHealth{CheckedAt: time.Now()}
+	if u.Target.Scheme == h2cScheme {
+		if err := checkGRPCHealth(&http.Client{Transport: h2cTransport, Timeout: client.Timeout}, u.Target); err != nil {
+			h.Error = err.Error()
+		} else {
+			h.Healthy = true
+		}
+		u.setHealth(h)
+		return
+	}
+	resp, err := client.Get(u.Target.ResolveReference(&url.URL{Path: "/health"}).String())
+	if err != nil {
+		h.Error = err.Error()
+	} else {
+		resp.Body.Close()
+		h.Healthy = resp.StatusCode == http.StatusOK
+		if !h.Healthy {
+			h.Error = "status " + strconv.Itoa(resp.StatusCode)
+		}
+	}
+	u.setHealth(h)
+}
+
+func (u *Upstream) setHealth(h upstreamHealth) {
+	u.mu.Lock()
+	u.health = h
+	u.mu.Unlock()
+	if h.Healthy {
+		upstreamHealthy.WithLabelValues(u.Name).Set(1)
+	} else {
+		upstreamHealthy.WithLabelValues(u.Name).Set(0)
+	}
+}
+
+// startHealthChecks probes every upstream at the given interval.
+func startHealthChecks(upstreams map[string]*Upstream, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	client := &http.Client{Timeout: 2 * time.Second}
+	go func() {
+		for {
+			for _, u := range upstreams {
+				u.checkHealth(client)
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
@@ -0,0 +1,239 @@
+// api-gateway/upstream_test.go
+// 단위 테스트: Circuit Breaker, Drain, 헬스 체크
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestCircuitBreaker tests the closed -> open -> half-open -> closed cycle
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	cb := newCircuitBreaker("test", 3, 30*time.Second)
+	cb.now = func() time.Time { return now }
+	allowed := func() bool {
+		ok, _ := cb.Allow()
+		return ok
+	}
+
+	t.Run("임계치 미만 실패는 closed 유지", func(t *testing.T) {
+		cb.RecordFailure()
+		cb.RecordFailure()
+		if ok, trial := cb.Allow(); cb.State() != breakerClosed || !ok || trial {
+			t.Errorf("State = %s, Allow = %v, %v; want closed and not a trial", cb.State(), ok, trial)
+		}
+	})
+
+	t.Run("임계치 도달 시 open", func(t *testing.T) {
+		cb.RecordFailure()
+		if cb.State() != breakerOpen {
+			t.Fatalf("State = %s; want open", cb.State())
+		}
+		if allowed() {
+			t.Error("open breaker should reject requests")
+		}
+	})
+
+	t.Run("timeout 후 half-open 시험 요청 1개만 허용", func(t *testing.T) {
+		now = now.Add(31 * time.Second)
+		if ok, trial := cb.Allow(); !ok || !trial {
+			t.Fatalf("Allow = %v, %v; first request after timeout should be the trial", ok, trial)
+		}
+		if cb.State() != breakerHalfOpen {
+			t.Errorf("State = %s; want half-open", cb.State())
+		}
+		if allowed() {
+			t.Error("only one trial request should be allowed")
+		}
+	})
+
+	t.Run("half-open 실패 시 다시 open", func(t *testing.T) {
+		cb.RecordFailure()
+		if cb.State() != breakerOpen {
+			t.Errorf("State = %s; want open", cb.State())
+		}
+	})
+
+	t.Run("half-open 성공 시 closed", func(t *testing.T) {
+		now = now.Add(31 * time.Second)
+		cb.Allow()
+		cb.RecordSuccess()
+		if cb.State() != breakerClosed || !allowed() {
+			t.Errorf("State = %s; want closed", cb.State())
+		}
+	})
+
+	t.Run("threshold 0이면 비활성화", func(t *testing.T) {
+		disabled := newCircuitBreaker("disabled", 0, time.Second)
+		for i := 0; i < 10; i++ {
+			disabled.RecordFailure()
+		}
+		if ok, _ := disabled.Allow(); !ok {
+			t.Error("disabled breaker should always allow")
+		}
+	})
+}
+
+// TestUpstreamServeHTTP tests proxying, passive failure tracking and draining
+func TestUpstreamServeHTTP(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusOK)
+	slowStarted := make(chan struct{})
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			slowStarted <- struct{}{}
+			<-r.Context().Done()
+			return
+		}
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer backend.Close()
+
+	target, _ := url.Parse(backend.URL)
+	u := NewUpstream("svc", target, newCircuitBreaker("svc", 2, time.Minute), nil)
+
+	serve := func() int {
+		rr := httptest.NewRecorder()
+		u.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/x", nil))
+		return rr.Code
+	}
+
+	if code := serve(); code != http.StatusOK {
+		t.Fatalf("Status = %d; want 200", code)
+	}
+
+	t.Run("5xx 연속 시 breaker open 후 503", func(t *testing.T) {
+		status.Store(http.StatusInternalServerError)
+		serve()
+		serve()
+		if u.breaker.State() != breakerOpen {
+			t.Fatalf("State = %s; want open", u.breaker.State())
+		}
+		if code := serve(); code != http.StatusServiceUnavailable {
+			t.Errorf("Status = %d; want 503", code)
+		}
+		u.breaker.Reset()
+		status.Store(http.StatusOK)
+	})
+
+	t.Run("drain 시 503", func(t *testing.T) {
+		u.SetDraining(true)
+		rr := httptest.NewRecorder()
+		u.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/x", nil))
+		if rr.Code != http.StatusServiceUnavailable || rr.Header().Get("Retry-After") == "" {
+			t.Errorf("Status = %d; want 503 with Retry-After", rr.Code)
+		}
+		u.SetDraining(false)
+		if code := serve(); code != http.StatusOK {
+			t.Errorf("Status after undrain = %d; want 200", code)
+		}
+	})
+
+	t.Run("half-open 시험 요청이 499로 끝나도 다음 요청 허용", func(t *testing.T) {
+		now := time.Now()
+		u.breaker.now = func() time.Time { return now }
+		defer func() { u.breaker.now = time.Now }()
+
+		status.Store(http.StatusInternalServerError)
+		serve()
+		serve()
+		status.Store(http.StatusOK)
+		now = now.Add(2 * time.Minute)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		rr := httptest.NewRecorder()
+		u.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/x", nil).WithContext(ctx))
+		if rr.Code != statusClientClosedRequest {
+			t.Fatalf("Trial status = %d; want 499", rr.Code)
+		}
+		if code := serve(); code != http.StatusOK {
+			t.Errorf("Status after released trial = %d; want 200", code)
+		}
+		if u.breaker.State() != breakerClosed {
+			t.Errorf("State = %s; want closed", u.breaker.State())
+		}
+	})
+
+	t.Run("시험 요청이 아닌 요청이 끝나도 시험 요청 유지", func(t *testing.T) {
+		now := time.Now()
+		u.breaker.now = func() time.Time { return now }
+		defer func() { u.breaker.now = time.Now }()
+
+		// closed 상태에서 시작한 요청이 half-open 중에 499로 끝나는 경우
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan int)
+		go func() {
+			rr := httptest.NewRecorder()
+			u.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/slow", nil).WithContext(ctx))
+			done <- rr.Code
+		}()
+		<-slowStarted
+
+		status.Store(http.StatusInternalServerError)
+		serve()
+		serve()
+		status.Store(http.StatusOK)
+		now = now.Add(2 * time.Minute)
+		if ok, trial := u.breaker.Allow(); !ok || !trial {
+			t.Fatalf("Allow = %v, %v; want the trial", ok, trial)
+		}
+		cancel()
+		if code := <-done; code != statusClientClosedRequest {
+			t.Fatalf("Status = %d; want 499", code)
+		}
+		if code := serve(); code != http.StatusServiceUnavailable {
+			t.Errorf("Status while the trial is in flight = %d; want 503", code)
+		}
+		u.breaker.Reset()
+	})
+
+	t.Run("연결 실패는 502 및 실패 기록", func(t *testing.T) {
+		dead, _ := url.Parse("http://127.0.0.1:1")
+		du := NewUpstream("dead", dead, newCircuitBreaker("dead", 1, time.Minute), nil)
+		rr := httptest.NewRecorder()
+		du.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/x", nil))
+		if rr.Code != http.StatusBadGateway {
+			t.Errorf("Status = %d; want 502", rr.Code)
+		}
+		if du.breaker.State() != breakerOpen {
+			t.Errorf("State = %s; want open", du.breaker.State())
+		}
+	})
+}
+
+// TestUpstreamCheckHealth tests the active /health probe
+func TestUpstreamCheckHealth(t *testing.T) {
+	var healthy atomic.Bool
+	healthy.Store(true)
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" || !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	target, _ := url.Parse(backend.URL)
+	u := NewUpstream("svc", target, newCircuitBreaker("svc", 0, 0), nil)
+	client := &http.Client{Timeout: time.Second}
+
+	u.checkHealth(client)
+	if h := u.Health(); !h.Healthy || h.CheckedAt.IsZero() {
+		t.Errorf("Health = %+v; want healthy", h)
+	}
+
+	healthy.Store(false)
+	u.checkHealth(client)
+	if h := u.Health(); h.Healthy || h.Error == "" {
+		t.Errorf("Health = %+v; want unhealthy with error", h)
+	}
+}
@@ -0,0 +1,340 @@
+// api-gateway/validation.go
+// OpenAPI 요청 검증: Upstream별 OpenAPI 3 문서로 경로/쿼리 파라미터, Content-Type, JSON 본문을 Route 단위 검증
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var requestValidationTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "request_validation_total",
+		Help: "Total number of OpenAPI request validations by result (valid, invalid, no_operation)",
+	},
+	[]string{"route", "result"},
+)
+
+const (
+	validationEnforce = "enforce"
+	validationLog     = "log"
+	validationOff     = "off"
+)
+
+// ValidationConfig is the "validation" section of the config file.
+type ValidationConfig struct {
+	// Specs maps upstream names to OpenAPI 3 documents, as a file path or an
+	// http(s) URL such as a service's FastAPI /openapi.json
+	Specs map[string]string `json:"specs"`
+	// Routes sets the mode per route name: enforce, log or off (the default)
+	Routes map[string]string `json:"routes"`
+}
+
+type requestValidator struct {
+	// specs holds one OpenAPI router per upstream, matched against upstream paths
+	specs map[string]routers.Router
+	modes map[string]string
+}
+
+func newRequestValidator(rt *Router, cfg ValidationConfig) (*requestValidator, error) {
+	v := &requestValidator{specs: map[string]routers.Router{}, modes: map[string]string{}}
+	for upstream, location := range cfg.Specs {
+		if rt.Upstream(upstream) == nil {
+			return nil, fmt.Errorf("validation: unknown upstream %q", upstream)
+		}
+		spec, err := loadOpenAPISpec(location)
+		if err != nil {
+			return nil, fmt.Errorf("validation: %s: %w", upstream, err)
+		}
+		v.specs[upstream] = spec
+	}
+	for name, mode := range cfg.Routes {
+		route := rt.route(name)
+		if route == nil {
+			return nil, fmt.Errorf("validation: unknown route %q", name)
+		}
+		switch mode {
+		case validationEnforce, validationLog:
+			if v.specs[route.Upstream] == nil {
+				return nil, fmt.Errorf("validation: route %s needs a spec for %s", name, route.Upstream)
+			}
+			v.modes[name] = mode
+		case validationOff, "":
+		default:
+			return nil, fmt.Errorf("validation: route %s: unknown mode %q", name, mode)
+		}
+	}
+	return v, nil
+}
+
+// loadOpenAPISpec loads and validates an OpenAPI 3 document. Servers are
+// dropped so that operations match on the request path alone.
+func loadOpenAPISpec(location string) (routers.Router, error) {
+	loader := openapi3.NewLoader()
+	var doc *openapi3.T
+	var err error
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		var u *url.URL
+		if u, err = url.Parse(location); err == nil {
+			doc, err = loader.LoadFromURI(u)
+		}
+	} else {
+		doc, err = loader.LoadFromFile(location)
+	}
+	if err != nil {
+		return nil, err
+	}
+	doc.Servers = nil
+	normalizeNullTypes(doc)
+	return legacy.NewRouter(doc)
+}
+
+// normalizeNullTypes rewrites OpenAPI 3.1 "null" types, which FastAPI emits
+// for Optional fields (anyOf: [{type: X}, {type: null}]), into the 3.0
+// nullable form understood by the validator.
+func normalizeNullTypes(doc *openapi3.T) {
+	seen := map[*openapi3.Schema]bool{}
+	visitParams := func(params openapi3.Parameters) {
+		for _, p := range params {
+			if p.Value != nil {
+				normalizeSchema(p.Value.Schema, seen)
+			}
+		}
+	}
+	visitBody := func(body *openapi3.RequestBodyRef) {
+		if body == nil || body.Value == nil {
+			return
+		}
+		for _, mt := range body.Value.Content {
+			normalizeSchema(mt.Schema, seen)
+		}
+	}
+
+	if doc.Components != nil {
+		for _, ref := range doc.Components.Schemas {
+			normalizeSchema(ref, seen)
+		}
+		for _, ref := range doc.Components.Parameters {
+			visitParams(openapi3.Parameters{ref})
+		}
+		for _, ref := range doc.Components.RequestBodies {
+			visitBody(ref)
+		}
+	}
+	if doc.Paths == nil {
+		return
+	}
+	for _, item := range doc.Paths.Map() {
+		visitParams(item.Parameters)
+		for _, op := range item.Operations() {
+			visitParams(op.Parameters)
+			visitBody(op.RequestBody)
+		}
+	}
+}
+
+func normalizeSchema(ref *openapi3.SchemaRef, seen map[*openapi3.Schema]bool) {
+	if ref == nil || ref.Value == nil || seen[ref.Value] {
+		return
+	}
+	s := ref.Value
+	seen[s] = true
+
+	if s.Type != nil && s.Type.Includes(openapi3.TypeNull) {
+		var types openapi3.Types
+		for _, t := range *s.Type {
+			if t != openapi3.TypeNull {
+				types = append(types, t)
+			}
+		}
+		s.Type = &types
+		if len(types) == 0 {
+			s.Type = nil
+		}
+		s.Nullable = true
+	}
+	s.AnyOf = dropNullSchemas(s, s.AnyOf)
+	s.OneOf = dropNullSchemas(s, s.OneOf)
+
+	for _, list := range []openapi3.SchemaRefs{s.AllOf, s.AnyOf, s.OneOf} {
+		for _, child := range list {
+			normalizeSchema(child, seen)
+		}
+	}
+	for _, child := range s.Properties {
+		normalizeSchema(child, seen)
+	}
+	normalizeSchema(s.Items, seen)
+	normalizeSchema(s.Not, seen)
+	normalizeSchema(s.AdditionalProperties.Schema, seen)
+}
+
+// dropNullSchemas removes {type: null} alternatives and marks parent nullable.
+func dropNullSchemas(parent *openapi3.Schema, refs openapi3.SchemaRefs) openapi3.SchemaRefs {
+	kept := refs[:0:0]
+	for _, ref := range refs {
+		if ref != nil && ref.Value != nil && ref.Value.Type != nil && ref.Value.Type.Is(openapi3.TypeNull) {
+			parent.Nullable = true
+			continue
+		}
+		kept = append(kept, ref)
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	return kept
+}
+
+// SetValidation replaces the validation config.
+func (rt *Router) SetValidation(cfg ValidationConfig) error {
+	v, err := newRequestValidator(rt, cfg)
+	if err != nil {
+		return err
+	}
+	rt.validator.Store(v)
+	return nil
+}
+
+// check validates r (already rewritten to the upstream path) and reports
+// whether it may be proxied. In enforce mode an invalid request is answered
+// with a problem+json error.
+func (v *requestValidator) check(w http.ResponseWriter, r *http.Request, route *Route) bool {
+	mode := v.modes[route.Name]
+	if mode == "" {
+		return true
+	}
+
+	op, pathParams, err := v.specs[route.Upstream].FindRoute(r)
+	if err != nil {
+		// 문서에 없는 Operation은 Upstream이 판단하도록 통과
+		requestValidationTotal.WithLabelValues(route.Name, "no_operation").Inc()
+		return true
+	}
+	err = openapi3filter.ValidateRequest(r.Context(), &openapi3filter.RequestValidationInput{
+		Request:    r,
+		PathParams: pathParams,
+		Route:      op,
+		Options: &openapi3filter.Options{
+			MultiError:          true,
+			AuthenticationFunc:  openapi3filter.NoopAuthenticationFunc,
+			SkipSettingDefaults: true,
+		},
+	})
+	if err == nil {
+		requestValidationTotal.WithLabelValues(route.Name, "valid").Inc()
+		return true
+	}
+
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		// 본문을 끝까지 읽지 못했으므로 log 모드에서도 전달할 수 없음
+		writeProblem(w, r, &problem{
+			Type:   problemTypeRequestTooLarge,
+			Status: http.StatusRequestEntityTooLarge,
+			Detail: fmt.Sprintf("Request body exceeds %d bytes", tooLarge.Limit),
+		})
+		return false
+	}
+
+	requestValidationTotal.WithLabelValues(route.Name, "invalid").Inc()
+	p := validationProblem(err)
+	if mode == validationLog {
+		log.Printf("[VALIDATION] %s %s %s: %s", route.Name, r.Method, r.URL.Path, p.Detail)
+		return true
+	}
+	writeProblem(w, r, p)
+	return false
+}
+
+// validationProblem converts kin-openapi errors into a problem with one
+// entry per invalid parameter or body field.
+func validationProblem(err error) *problem {
+	p := &problem{
+		Type:   problemTypeValidation,
+		Title:  "Request validation failed",
+		Status: http.StatusBadRequest,
+	}
+	for _, e := range flattenErrors(err) {
+		var reqErr *openapi3filter.RequestError
+		if !errors.As(e, &reqErr) {
+			p.Errors = append(p.Errors, fieldError{In: "request", Detail: e.Error()})
+			continue
+		}
+		if reqErr.Parameter != nil {
+			p.Errors = append(p.Errors, fieldError{In: reqErr.Parameter.In, Name: reqErr.Parameter.Name, Detail: reasonOf(reqErr)})
+			continue
+		}
+		if reqErr.RequestBody != nil && reqErr.Err == nil && strings.HasPrefix(reqErr.Reason, "header Content-Type") {
+			p.Status = http.StatusUnsupportedMediaType
+			p.Errors = append(p.Errors, fieldError{In: "header", Name: "Content-Type", Detail: reqErr.Reason})
+			continue
+		}
+		var schemaErrs []error
+		if reqErr.Err != nil {
+			schemaErrs = flattenErrors(reqErr.Err)
+		}
+		if len(schemaErrs) == 0 {
+			p.Errors = append(p.Errors, fieldError{In: "body", Detail: reasonOf(reqErr)})
+		}
+		for _, se := range schemaErrs {
+			fe := fieldError{In: "body", Detail: se.Error()}
+			var schemaErr *openapi3.SchemaError
+			if errors.As(se, &schemaErr) {
+				fe.Pointer = "/" + strings.Join(schemaErr.JSONPointer(), "/")
+				fe.Detail = schemaErr.Reason
+			}
+			p.Errors = append(p.Errors, fe)
+		}
+	}
+
+	details := make([]string, 0, len(p.Errors))
+	for _, fe := range p.Errors {
+		where := fe.In
+		if fe.Name != "" {
+			where += " " + fe.Name
+		}
+		if fe.Pointer != "" {
+			where += " " + fe.Pointer
+		}
+		details = append(details, where+": "+fe.Detail)
+	}
+	p.Detail = strings.Join(details, "; ")
+	return p
+}
+
+// flattenErrors expands nested MultiErrors. It only looks at the error
+// itself, not its chain, so a RequestError wrapping a MultiError stays whole.
+func flattenErrors(err error) []error {
+	multi, ok := err.(openapi3.MultiError)
+	if !ok {
+		return []error{err}
+	}
+	var out []error
+	for _, e := range multi {
+		out = append(out, flattenErrors(e)...)
+	}
+	return out
+}
+
+func reasonOf(err *openapi3filter.RequestError) string {
+	if err.Err != nil {
+		var schemaErr *openapi3.SchemaError
+		if errors.As(err.Err, &schemaErr) {
+			return schemaErr.Reason
+		}
+		return err.Err.Error()
+	}
+	return err.Reason
+}
@@ -0,0 +1,192 @@
+// api-gateway/validation_test.go
+// 단위 테스트: OpenAPI 요청 검증 (enforce/log/off, problem+json 응답)
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testUserServiceSpec mirrors the document FastAPI generates for user-service
+const testUserServiceSpec = `{
+  "openapi": "3.1.0",
+  "info": {"title": "User Service", "version": "1.0.0"},
+  "paths": {
+    "/users": {
+      "post": {
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/UserIn"}}}},
+        "responses": {"201": {"description": "Created"}}
+      }
+    },
+    "/users/{username}": {
+      "get": {
+        "parameters": [
+          {"name": "username", "in": "path", "required": true, "schema": {"type": "string", "minLength": 3}},
+          {"name": "limit", "in": "query", "required": false, "schema": {"anyOf": [{"type": "integer"}, {"type": "null"}]}}
+        ],
+        "responses": {"200": {"description": "OK"}}
+      }
+    }
+  },
+  "components": {
+    "schemas": {
+      "UserIn": {
+        "type": "object",
+        "required": ["username", "email", "password"],
+        "properties": {
+          "username": {"type": "string", "minLength": 3, "maxLength": 50},
+          "email": {"type": "string", "format": "email"},
+          "password": {"type": "string", "minLength": 8}
+        }
+      }
+    }
+  }
+}`
+
+func writeTestSpec(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "user-service.json")
+	if err := os.WriteFile(path, []byte(testUserServiceSpec), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func newValidatedRouter(t *testing.T, modes map[string]string) *Router {
+	t.Helper()
+	router := newTestRouter(t)
+	if err := router.SetValidation(ValidationConfig{
+		Specs:  map[string]string{"user-service": writeTestSpec(t)},
+		Routes: modes,
+	}); err != nil {
+		t.Fatalf("SetValidation error: %v", err)
+	}
+	return router
+}
+
+// TestRequestValidation tests enforce mode on bodies, content types and parameters
+func TestRequestValidation(t *testing.T) {
+	router := newValidatedRouter(t, map[string]string{"user-register": "enforce", "users": "enforce"})
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		expectCode  int
+		expectField string
+	}{
+		{"유효한 회원가입", http.MethodPost, "/api/register", "application/json", `{"username":"alice","email":"a@example.com","password":"password1"}`, http.StatusOK, ""},
+		{"필수 필드 누락", http.MethodPost, "/api/register", "application/json", `{"username":"alice","password":"password1"}`, http.StatusBadRequest, "body"},
+		{"짧은 비밀번호", http.MethodPost, "/api/register", "application/json", `{"username":"alice","email":"a@example.com","password":"x"}`, http.StatusBadRequest, "/password"},
+		{"잘못된 JSON", http.MethodPost, "/api/register", "application/json", `{"username":`, http.StatusBadRequest, "body"},
+		{"지원하지 않는 Content-Type", http.MethodPost, "/api/register", "text/plain", `hello`, http.StatusUnsupportedMediaType, "Content-Type"},
+		{"유효한 경로/쿼리 파라미터", http.MethodGet, "/api/users/alice?limit=5", "", "", http.StatusOK, ""},
+		{"짧은 경로 파라미터", http.MethodGet, "/api/users/al", "", "", http.StatusBadRequest, "username"},
+		{"잘못된 쿼리 파라미터", http.MethodGet, "/api/users/alice?limit=many", "", "", http.StatusBadRequest, "limit"},
+		{"문서에 없는 Operation은 통과", http.MethodDelete, "/api/users/alice", "", "", http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if rr.Code != tt.expectCode {
+				t.Fatalf("Status = %d; want %d (%s)", rr.Code, tt.expectCode, rr.Body.String())
+			}
+			if tt.expectField == "" {
+				return
+			}
+			if ct := rr.Header().Get("Content-Type"); ct != problemContentType {
+				t.Errorf("Content-Type = %s; want %s", ct, problemContentType)
+			}
+			var p problem
+			json.NewDecoder(rr.Body).Decode(&p)
+			if p.Type != problemTypeValidation || p.Status != tt.expectCode || !strings.Contains(p.Detail, tt.expectField) {
+				t.Errorf("problem = %+v; want detail mentioning %s", p, tt.expectField)
+			}
+		})
+	}
+
+	t.Run("검증 후 본문이 Upstream에 그대로 전달", func(t *testing.T) {
+		received := make(chan string, 1)
+		backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var b strings.Builder
+			buf := make([]byte, 512)
+			for {
+				n, err := r.Body.Read(buf)
+				b.Write(buf[:n])
+				if err != nil {
+					break
+				}
+			}
+			received <- b.String()
+		}))
+		defer backend.Close()
+		router.upstreams["user-service"] = newTestUpstream(t, "user-service", backend)
+
+		body := `{"username":"alice","email":"a@example.com","password":"password1"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/register", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+		if got := <-received; got != body {
+			t.Errorf("upstream body = %q; want %q", got, body)
+		}
+	})
+}
+
+// TestRequestValidationModes tests log-only and off
+func TestRequestValidationModes(t *testing.T) {
+	invalid := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/api/register", strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		return req
+	}
+
+	for _, mode := range []string{"log", "off"} {
+		t.Run(mode, func(t *testing.T) {
+			router := newValidatedRouter(t, map[string]string{"user-register": mode})
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, invalid())
+			if rr.Code != http.StatusOK {
+				t.Errorf("Status = %d; want 200 (request passed through)", rr.Code)
+			}
+		})
+	}
+}
+
+// TestValidationConfigErrors tests rejected validation configs
+func TestValidationConfigErrors(t *testing.T) {
+	router := newTestRouter(t)
+	spec := writeTestSpec(t)
+	broken := filepath.Join(t.TempDir(), "broken.json")
+	os.WriteFile(broken, []byte(`{"openapi": "3.0.0", "paths": `), 0o644)
+
+	tests := []struct {
+		name string
+		cfg  ValidationConfig
+	}{
+		{"알 수 없는 Upstream", ValidationConfig{Specs: map[string]string{"nope": spec}}},
+		{"읽을 수 없는 문서", ValidationConfig{Specs: map[string]string{"user-service": broken}}},
+		{"알 수 없는 Route", ValidationConfig{Routes: map[string]string{"nope": "enforce"}}},
+		{"문서 없는 Route", ValidationConfig{Routes: map[string]string{"auth-login": "enforce"}}},
+		{"알 수 없는 모드", ValidationConfig{Specs: map[string]string{"user-service": spec}, Routes: map[string]string{"users": "strict"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := router.SetValidation(tt.cfg); err == nil {
+				t.Error("expected config error")
+			}
+		})
+	}
+}
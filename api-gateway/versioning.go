@@ -0,0 +1,215 @@
+// api-gateway/versioning.go
+// API 버전 라우팅: 경로(/api/v2/...), API-Version 또는 Accept 헤더로 버전 선택, 버전별 Upstream/경로와 Deprecation/Sunset 헤더
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var deprecatedAPIRequestsTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "deprecated_api_requests_total",
+		Help: "Total number of requests to deprecated API versions by route, version and client (API key id or anonymous)",
+	},
+	[]string{"route", "version", "client"},
+)
+
+const (
+	apiVersionHeader                = "API-Version"
+	apiVersionContextKey contextKey = "api-version"
+
+	problemTypeUnsupportedVersion = "/problems/unsupported-api-version"
+)
+
+var (
+	versionName = regexp.MustCompile(`^v[0-9]+$`)
+	// acceptVersion matches application/vnd.titanium.v2+json and
+	// application/json; version=2
+	acceptVersion = regexp.MustCompile(`vnd\.titanium\.(v[0-9]+)\+json|;\s*version=v?([0-9]+)`)
+)
+
+// APIVersionConfig describes one version of a route.
+type APIVersionConfig struct {
+	// Upstream serves this version instead of the route's upstream
+	Upstream string `json:"upstream,omitempty"`
+	// PathPrefix is prepended to the rewritten upstream path ("/v2")
+	PathPrefix string `json:"path_prefix,omitempty"`
+	// Deprecation and Sunset are sent as response headers (RFC 9745, RFC
+	// 8594); after Sunset the version answers 410 Gone
+	Deprecation *time.Time `json:"deprecation,omitempty"`
+	Sunset      *time.Time `json:"sunset,omitempty"`
+	// Link points clients at the migration guide
+	Link string `json:"link,omitempty"`
+}
+
+// RouteVersionsConfig is one entry of the "versions" section of the config
+// file, keyed by route name. Requests that name no version get Default.
+type RouteVersionsConfig struct {
+	Default  string                      `json:"default"`
+	Versions map[string]APIVersionConfig `json:"versions"`
+}
+
+// apiVersion is the version a request was routed to.
+type apiVersion struct {
+	Name string
+	APIVersionConfig
+}
+
+func (rt *Router) newVersionConfigs(configs map[string]RouteVersionsConfig) (map[string]RouteVersionsConfig, error) {
+	for name, cfg := range configs {
+		if rt.route(name) == nil {
+			return nil, fmt.Errorf("versions: unknown route %q", name)
+		}
+		if _, ok := cfg.Versions[cfg.Default]; !ok {
+			return nil, fmt.Errorf("versions %s: default %q is not a listed version", name, cfg.Default)
+		}
+		for version, v := range cfg.Versions {
+			if !versionName.MatchString(version) {
+				return nil, fmt.Errorf("versions %s: invalid version %q (want v1, v2, ...)", name, version)
+			}
+			if v.Upstream != "" && rt.Upstream(v.Upstream) == nil {
+				return nil, fmt.Errorf("versions %s/%s: unknown upstream %q", name, version, v.Upstream)
+			}
+			if v.PathPrefix != "" && (!strings.HasPrefix(v.PathPrefix, "/") || strings.HasSuffix(v.PathPrefix, "/")) {
+				return nil, fmt.Errorf("versions %s/%s: path_prefix must start and not end with /", name, version)
+			}
+			if v.Deprecation != nil && v.Sunset != nil && v.Sunset.Before(*v.Deprecation) {
+				return nil, fmt.Errorf("versions %s/%s: sunset is before deprecation", name, version)
+			}
+			if v.Link != "" {
+				if u, err := url.Parse(v.Link); err != nil || strings.ContainsAny(v.Link, "<>\r\n") || u.Scheme == "" && !strings.HasPrefix(v.Link, "/") {
+					return nil, fmt.Errorf("versions %s/%s: invalid link %q", name, version, v.Link)
+				}
+			}
+		}
+	}
+	return configs, nil
+}
+
+// SetVersions replaces the per-route API versions.
+func (rt *Router) SetVersions(configs map[string]RouteVersionsConfig) error {
+	versions, err := rt.newVersionConfigs(configs)
+	if err != nil {
+		return err
+	}
+	rt.versions.Store(&versions)
+	return nil
+}
+
+// splitPathVersion removes a version segment following /api or /blog/api
+// ("/api/v2/users" → "/api/users", "v2").
+func splitPathVersion(path string) (string, string) {
+	for _, prefix := range []string{"/blog/api/", "/api/"} {
+		rest, ok := strings.CutPrefix(path, prefix)
+		if !ok {
+			continue
+		}
+		segment, tail, _ := strings.Cut(rest, "/")
+		if !versionName.MatchString(segment) {
+			return path, ""
+		}
+		return prefix + tail, segment
+	}
+	return path, ""
+}
+
+// headerVersion reads the version from API-Version ("2" or "v2") or Accept.
+func headerVersion(r *http.Request) string {
+	if v := strings.TrimSpace(r.Header.Get(apiVersionHeader)); v != "" {
+		if !strings.HasPrefix(v, "v") {
+			v = "v" + v
+		}
+		return v
+	}
+	if m := acceptVersion.FindStringSubmatch(r.Header.Get("Accept")); m != nil {
+		if m[1] != "" {
+			return m[1]
+		}
+		return "v" + m[2]
+	}
+	return ""
+}
+
+// selectVersion strips a path version from r and picks the route's version.
+// Routes without a versions entry ignore the requested version. It writes
+// the error response and returns false for unknown or sunset versions.
+func (rt *Router) selectVersion(w http.ResponseWriter, r *http.Request, route *Route) (*apiVersion, bool) {
+	path, requested := splitPathVersion(r.URL.Path)
+	if requested != "" {
+		r.URL.Path, r.URL.RawPath = path, ""
+	} else {
+		requested = headerVersion(r)
+	}
+
+	versions := rt.versions.Load()
+	if versions == nil {
+		return nil, true
+	}
+	cfg, ok := (*versions)[route.Name]
+	if !ok {
+		return nil, true
+	}
+	if requested == "" {
+		requested = cfg.Default
+	}
+	v, ok := cfg.Versions[requested]
+	if !ok {
+		writeProblem(w, r, &problem{
+			Type:   problemTypeUnsupportedVersion,
+			Status: http.StatusBadRequest,
+			Detail: fmt.Sprintf("API version %s is not supported by %s", requested, route.Name),
+		})
+		return nil, false
+	}
+
+	w.Header().Set(apiVersionHeader, requested)
+	if v.Deprecation != nil {
+		w.Header().Set("Deprecation", fmt.Sprintf("@%d", v.Deprecation.Unix()))
+	}
+	if v.Sunset != nil {
+		w.Header().Set("Sunset", v.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if v.Link != "" {
+		w.Header().Add("Link", fmt.Sprintf(`<%s>; rel="deprecation"; type="text/html"`, v.Link))
+	}
+	if v.Deprecation != nil || v.Sunset != nil {
+		client := "anonymous"
+		if k := apiKeyFromContext(r.Context()); k != nil {
+			client = k.ID
+		}
+		deprecatedAPIRequestsTotal.WithLabelValues(route.Name, requested, client).Inc()
+	}
+	if v.Sunset != nil && !time.Now().Before(*v.Sunset) {
+		writeProblem(w, r, &problem{
+			Type:   problemTypeUnsupportedVersion,
+			Status: http.StatusGone,
+			Detail: fmt.Sprintf("API version %s of %s was retired on %s", requested, route.Name, v.Sunset.UTC().Format(time.DateOnly)),
+		})
+		return nil, false
+	}
+	return &apiVersion{Name: requested, APIVersionConfig: v}, true
+}
+
+// apiVersionFromContext returns the version the request was routed to, or nil.
+func apiVersionFromContext(ctx context.Context) *apiVersion {
+	v, _ := ctx.Value(apiVersionContextKey).(*apiVersion)
+	return v
+}
+
+// upstreamName is the upstream serving route for the request's version.
+func upstreamName(ctx context.Context, route *Route) string {
+	if v := apiVersionFromContext(ctx); v != nil && v.Upstream != "" {
+		return v.Upstream
+	}
+	return route.Upstream
+}
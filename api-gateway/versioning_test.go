@@ -0,0 +1,172 @@
+// api-gateway/versioning_test.go
+// 단위 테스트: API 버전 선택(경로/헤더), 버전별 Upstream/경로, Deprecation/Sunset 헤더와 메트릭
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func newVersionTestRouter(t *testing.T) *Router {
+	t.Helper()
+	upstreams := map[string]*Upstream{}
+	for _, name := range []string{"user-service", "user-service-v2", "auth-service", "blog-service"} {
+		upstreams[name] = newTestUpstream(t, name, newTestBackend(t, name))
+	}
+	router := NewRouter(defaultRoutes(), upstreams, nil)
+
+	deprecated := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2099, 1, 1, 0, 0, 0, 0, time.UTC)
+	retired := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	err := router.SetVersions(map[string]RouteVersionsConfig{
+		"users": {
+			Default: "v1",
+			Versions: map[string]APIVersionConfig{
+				"v1": {Deprecation: &deprecated, Sunset: &sunset, Link: "https://docs.example.com/api/v2-migration"},
+				"v2": {Upstream: "user-service-v2", PathPrefix: "/v2"},
+			},
+		},
+		"blog-api": {
+			Default: "v2",
+			Versions: map[string]APIVersionConfig{
+				"v0": {Deprecation: &deprecated, Sunset: &retired},
+				"v2": {},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SetVersions error: %v", err)
+	}
+	return router
+}
+
+// TestAPIVersionRouting tests version selection and the upstream it maps to
+func TestAPIVersionRouting(t *testing.T) {
+	router := newVersionTestRouter(t)
+
+	tests := []struct {
+		name          string
+		path          string
+		headers       map[string]string
+		expectCode    int
+		expectBackend string
+		expectPath    string
+		expectVersion string
+	}{
+		{"버전 없음은 기본 버전", "/api/users/alice", nil, http.StatusOK, "user-service", "/users/alice", "v1"},
+		{"경로 버전", "/api/v2/users/alice", nil, http.StatusOK, "user-service-v2", "/v2/users/alice", "v2"},
+		{"API-Version 헤더", "/api/users/alice", map[string]string{"API-Version": "2"}, http.StatusOK, "user-service-v2", "/v2/users/alice", "v2"},
+		{"Accept 미디어 타입", "/api/users/alice", map[string]string{"Accept": "application/vnd.titanium.v2+json"}, http.StatusOK, "user-service-v2", "/v2/users/alice", "v2"},
+		{"Accept version 파라미터", "/api/users/alice", map[string]string{"Accept": "application/json; version=2"}, http.StatusOK, "user-service-v2", "/v2/users/alice", "v2"},
+		{"경로 버전이 헤더보다 우선", "/api/v1/users/alice", map[string]string{"API-Version": "v2"}, http.StatusOK, "user-service", "/users/alice", "v1"},
+		{"Blog API 경로 버전", "/blog/api/v2/posts/1", nil, http.StatusOK, "blog-service", "/blog/api/posts/1", "v2"},
+		{"지원하지 않는 버전", "/api/v3/users/alice", nil, http.StatusBadRequest, "", "", ""},
+		{"Sunset 지난 버전", "/blog/api/v0/posts", nil, http.StatusGone, "", "", "v0"},
+		{"버전 설정 없는 Route는 버전 무시", "/api/v7/login", nil, http.StatusOK, "auth-service", "/login", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if rr.Code != tt.expectCode {
+				t.Fatalf("Status = %d; want %d (%s)", rr.Code, tt.expectCode, rr.Body.String())
+			}
+			if got := rr.Header().Get(apiVersionHeader); got != tt.expectVersion {
+				t.Errorf("API-Version = %q; want %q", got, tt.expectVersion)
+			}
+			if tt.expectCode != http.StatusOK {
+				if p := decodeProblem(t, rr); p.Type != problemTypeUnsupportedVersion {
+					t.Errorf("problem type = %s; want %s", p.Type, problemTypeUnsupportedVersion)
+				}
+				return
+			}
+			if got := rr.Header().Get("X-Backend"); got != tt.expectBackend {
+				t.Errorf("backend = %s; want %s", got, tt.expectBackend)
+			}
+			if got := rr.Body.String(); got != tt.expectPath {
+				t.Errorf("upstream path = %s; want %s", got, tt.expectPath)
+			}
+		})
+	}
+}
+
+// TestAPIVersionDeprecationHeaders tests Deprecation, Sunset, Link and the usage metric
+func TestAPIVersionDeprecationHeaders(t *testing.T) {
+	router := newVersionTestRouter(t)
+	counter := deprecatedAPIRequestsTotal.WithLabelValues("users", "v1", "anonymous")
+	before := testutil.ToFloat64(counter)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/users/alice", nil))
+	expected := map[string]string{
+		"Deprecation": "@1767225600",
+		"Sunset":      "Thu, 01 Jan 2099 00:00:00 GMT",
+		"Link":        `<https://docs.example.com/api/v2-migration>; rel="deprecation"; type="text/html"`,
+	}
+	for k, want := range expected {
+		if got := rr.Header().Get(k); got != want {
+			t.Errorf("%s = %q; want %q", k, got, want)
+		}
+	}
+	if got := testutil.ToFloat64(counter) - before; got != 1 {
+		t.Errorf("deprecated_api_requests_total increase = %v; want 1", got)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v2/users/alice", nil))
+	if rr.Header().Get("Deprecation") != "" || rr.Header().Get("Sunset") != "" {
+		t.Errorf("current version should not be marked deprecated: %v", rr.Header())
+	}
+	if got := testutil.ToFloat64(counter) - before; got != 1 {
+		t.Errorf("current version should not be counted (increase %v)", got)
+	}
+}
+
+// TestAPIVersionResolve tests that a path version does not affect route matching
+func TestAPIVersionResolve(t *testing.T) {
+	router := newTestRouter(t)
+	for path, want := range map[string]string{
+		"/api/v2/users/alice":  "users",
+		"/blog/api/v1/posts":   "blog-api",
+		"/api/v1/register":     "user-register",
+		"/api/version/history": "",
+	} {
+		got := ""
+		if route := router.Resolve(path); route != nil {
+			got = route.Name
+		}
+		if got != want {
+			t.Errorf("Resolve(%s) = %q; want %q", path, got, want)
+		}
+	}
+}
+
+// TestVersionConfigErrors tests rejected versions sections
+func TestVersionConfigErrors(t *testing.T) {
+	router := newTestRouter(t)
+	early := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	late := early.AddDate(1, 0, 0)
+	for _, cfg := range []map[string]RouteVersionsConfig{
+		{"nope": {Default: "v1", Versions: map[string]APIVersionConfig{"v1": {}}}},
+		{"users": {Default: "v2", Versions: map[string]APIVersionConfig{"v1": {}}}},
+		{"users": {Default: "1", Versions: map[string]APIVersionConfig{"1": {}}}},
+		{"users": {Default: "v1", Versions: map[string]APIVersionConfig{"v1": {Upstream: "nope"}}}},
+		{"users": {Default: "v1", Versions: map[string]APIVersionConfig{"v1": {PathPrefix: "v1/"}}}},
+		{"users": {Default: "v1", Versions: map[string]APIVersionConfig{"v1": {Deprecation: &late, Sunset: &early}}}},
+		{"users": {Default: "v1", Versions: map[string]APIVersionConfig{"v1": {Link: "docs>; rel=x"}}}},
+	} {
+		if err := router.SetVersions(cfg); err == nil {
+			t.Errorf("SetVersions(%+v) should fail", cfg)
+		}
+	}
+}
@@ -438,4 +438,4 @@ if __name__ == "__main__":
     import uvicorn
     port = 8005
     logger.info(f"Blog Service starting on http://0.0.0.0:{port}")
-    uvicorn.run(app, host="0.0.0.0", port=port)
+    uvicorn.run(app, host="0.0.0.0", port=port, proxy_headers=True, forwarded_allow_ips="*")
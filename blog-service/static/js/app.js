@@ -208,7 +208,10 @@ document.addEventListener('DOMContentLoaded', () => {
     };
 
     // Logout handler
-    const logout = () => {
+    const logout = async () => {
+        if (!Auth.getToken()) {
+            await Api.auth.logout().catch(() => {});
+        }
         Auth.clearToken();
         updateAuthStatus();
         window.location.hash = '/';
@@ -226,7 +229,11 @@ document.addEventListener('DOMContentLoaded', () => {
 
             try {
                 const data = await Api.auth.login(username, password);
-                Auth.setToken(data.token);
+                if (data.token) {
+                    Auth.setToken(data.token);
+                } else {
+                    Auth.setSessionUser(data.username);
+                }
                 Modal.closeAll();
                 updateAuthStatus();
                 window.location.hash = '/';
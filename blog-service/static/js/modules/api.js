@@ -5,6 +5,12 @@ import { getToken, authHeader } from './auth.js';
 
 const API_BASE = '';
 
+// Gateway가 발급한 csrf_token Cookie를 상태 변경 요청의 X-CSRF-Token 헤더로 전달 (Double-Submit)
+function csrfHeader() {
+    const match = document.cookie.match(/(?:^|;\s*)csrf_token=([^;]+)/);
+    return match ? { 'X-CSRF-Token': match[1] } : {};
+}
+
 export const Api = {
     async get(endpoint) {
         const res = await fetch(`${API_BASE}${endpoint}`);
@@ -15,8 +21,8 @@ export const Api = {
         return res.json();
     },
 
-    async post(endpoint, data, authenticated = false) {
-        const headers = { 'Content-Type': 'application/json' };
+    async post(endpoint, data, authenticated = false, extraHeaders = {}) {
+        const headers = { 'Content-Type': 'application/json', ...csrfHeader(), ...extraHeaders };
         if (authenticated) {
             Object.assign(headers, authHeader());
         }
@@ -35,7 +41,7 @@ export const Api = {
     },
 
     async patch(endpoint, data, authenticated = false) {
-        const headers = { 'Content-Type': 'application/json' };
+        const headers = { 'Content-Type': 'application/json', ...csrfHeader() };
         if (authenticated) {
             Object.assign(headers, authHeader());
         }
@@ -54,7 +60,7 @@ export const Api = {
     },
 
     async delete(endpoint, authenticated = false) {
-        const headers = {};
+        const headers = { ...csrfHeader() };
         if (authenticated) {
             Object.assign(headers, authHeader());
         }
@@ -99,7 +105,11 @@ export const Api = {
 
     auth: {
         login(username, password) {
-            return Api.post('/api/login', { username, password });
+            // Gateway BFF 모드가 켜져 있으면 토큰 대신 HttpOnly 세션 Cookie를 받음
+            return Api.post('/api/login', { username, password }, false, { 'X-Auth-Mode': 'cookie' });
+        },
+        logout() {
+            return Api.post('/api/logout', {});
         },
         signup(username, email, password) {
             return Api.post('/api/users', { username, email, password });
@@ -5,7 +5,15 @@ export const getToken = () => sessionStorage.getItem('authToken') || '';
 
 export const setToken = (token) => sessionStorage.setItem('authToken', token);
 
-export const clearToken = () => sessionStorage.removeItem('authToken');
+// BFF 세션 모드: 토큰은 Gateway가 보관하고 브라우저에는 username만 저장
+export const getSessionUser = () => sessionStorage.getItem('authUser') || '';
+
+export const setSessionUser = (username) => sessionStorage.setItem('authUser', username);
+
+export const clearToken = () => {
+    sessionStorage.removeItem('authToken');
+    sessionStorage.removeItem('authUser');
+};
 
 export const parseJwt = (token) => {
     try {
@@ -19,7 +27,7 @@ export const parseJwt = (token) => {
 
 export const getUsernameFromToken = () => {
     const token = getToken();
-    if (!token) return '';
+    if (!token) return getSessionUser();
 
     // Local test token handling
     if (token.startsWith('session-token-for-')) {
@@ -31,9 +39,9 @@ export const getUsernameFromToken = () => {
     return (payload && payload.username) ? payload.username : '';
 };
 
-export const authHeader = () => ({ 'Authorization': `Bearer ${getToken()}` });
+export const authHeader = () => (getToken() ? { 'Authorization': `Bearer ${getToken()}` } : {});
 
-export const isAuthenticated = () => !!getToken();
+export const isAuthenticated = () => !!getToken() || !!getSessionUser();
 
 export const canEdit = (author) => {
     return isAuthenticated() && getUsernameFromToken() === author;
@@ -43,6 +51,8 @@ const Auth = {
     getToken,
     setToken,
     clearToken,
+    getSessionUser,
+    setSessionUser,
     parseJwt,
     getUsernameFromToken,
     authHeader,
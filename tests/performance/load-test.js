@@ -20,10 +20,12 @@ export const options = {
 };
 
 const BASE_URL = __ENV.BASE_URL || 'http://10.0.11.168:31304';
+// API_KEY가 주어지면 게이트웨이의 IP Rate Limit 대신 API Key Quota로 집계
+const params = __ENV.API_KEY ? { headers: { 'X-API-Key': __ENV.API_KEY } } : {};
 
 export default function () {
   // Test 1: Dashboard
-  let dashboardRes = http.get(`${BASE_URL}/`);
+  let dashboardRes = http.get(`${BASE_URL}/`, params);
   check(dashboardRes, {
     'dashboard status 200': (r) => r.status === 200,
     'dashboard response time < 1s': (r) => r.timings.duration < 1000,
@@ -32,7 +34,7 @@ export default function () {
   sleep(1);
 
   // Test 2: Blog
-  let blogRes = http.get(`${BASE_URL}/blog/`);
+  let blogRes = http.get(`${BASE_URL}/blog/`, params);
   check(blogRes, {
     'blog status 200': (r) => r.status === 200,
     'blog response time < 1s': (r) => r.timings.duration < 1000,
@@ -41,7 +43,7 @@ export default function () {
   sleep(1);
 
   // Test 3: Blog API
-  let blogApiRes = http.get(`${BASE_URL}/blog/api/posts`);
+  let blogApiRes = http.get(`${BASE_URL}/blog/api/posts`, params);
   check(blogApiRes, {
     'blog api status 200': (r) => r.status === 200,
     'blog api response time < 500ms': (r) => r.timings.duration < 500,
@@ -50,7 +52,7 @@ export default function () {
   sleep(1);
 
   // Test 4: Health Check
-  let healthRes = http.get(`${BASE_URL}/health`);
+  let healthRes = http.get(`${BASE_URL}/health`, params);
   check(healthRes, {
     'health status 200': (r) => r.status === 200,
     'health response time < 200ms': (r) => r.timings.duration < 200,